@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"net"
 	"os"
@@ -26,26 +25,10 @@ func main() {
 		os.Exit(1)
 	}
 	mux := otlp.NewServerMux()
-	enc := func(ctx context.Context, msg proto.Message) {
-		bs, err := otlp.MarshalJSON(msg)
-		if err != nil {
-			slog.ErrorContext(ctx, "failed to marshal proto message", "msg", err)
-			return
-		}
-		fmt.Fprint(os.Stdout, string(bs))
-	}
-	mux.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
-		enc(ctx, req)
-		return &otlp.TraceResponse{}, nil
-	})
-	mux.Metrics().HandleFunc(func(ctx context.Context, req *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
-		enc(ctx, req)
-		return &otlp.MetricsResponse{}, nil
-	})
-	mux.Logs().HandleFunc(func(ctx context.Context, req *otlp.LogsRequest) (*otlp.LogsResponse, error) {
-		enc(ctx, req)
-		return &otlp.LogsResponse{}, nil
-	})
+	sink := otlp.NewJSONLinesSink(os.Stdout)
+	mux.Trace().Handle(sink)
+	mux.Metrics().Handle(sink)
+	mux.Logs().Handle(sink)
 	mux.Use(func(next otlp.ProtoHandlerFunc) otlp.ProtoHandlerFunc {
 		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
 			headers, ok := otlp.HeadersFromContext(ctx)
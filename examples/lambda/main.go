@@ -1,16 +1,15 @@
 package main
 
 import (
-	"context"
-	"fmt"
 	"log/slog"
 	"os"
 
-	"github.com/fujiwara/ridge"
+	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/mashiike/go-otlp-helper/otlp"
-	"google.golang.org/protobuf/proto"
+	"github.com/mashiike/go-otlp-helper/otlp/otlplambda"
 )
 
+// main is an OTLP/HTTP receiver on Lambda, behind a Function URL, API Gateway or ALB, that writes the received telemetry to stdout.
 func main() {
 	slog.SetDefault(
 		slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
@@ -18,25 +17,9 @@ func main() {
 		})),
 	)
 	mux := otlp.NewServerMux()
-	enc := func(ctx context.Context, msg proto.Message) {
-		bs, err := otlp.MarshalJSON(msg)
-		if err != nil {
-			slog.ErrorContext(ctx, "failed to marshal proto message", "msg", err)
-			return
-		}
-		fmt.Fprint(os.Stdout, string(bs))
-	}
-	mux.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
-		enc(ctx, req)
-		return &otlp.TraceResponse{}, nil
-	})
-	mux.Metrics().HandleFunc(func(ctx context.Context, req *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
-		enc(ctx, req)
-		return &otlp.MetricsResponse{}, nil
-	})
-	mux.Logs().HandleFunc(func(ctx context.Context, req *otlp.LogsRequest) (*otlp.LogsResponse, error) {
-		enc(ctx, req)
-		return &otlp.LogsResponse{}, nil
-	})
-	ridge.Run(":4318", "/", mux)
+	sink := otlp.NewJSONLinesSink(os.Stdout)
+	mux.Trace().Handle(sink)
+	mux.Metrics().Handle(sink)
+	mux.Logs().Handle(sink)
+	lambda.Start(otlplambda.NewHandler(mux))
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/lambdaext"
+)
+
+// main is a Lambda extension that forwards the telemetry of the function to the OTLP endpoint.
+// build it for the function architecture and put it in the extensions directory of a layer, e.g. extensions/otlp-forwarder,
+// and configure the endpoint with OTEL_EXPORTER_OTLP_ENDPOINT etc.
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+	slog.SetDefault(logger)
+	if err := run(logger); err != nil {
+		slog.Error("extension stopped", "details", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the extension, it returns instead of calling os.Exit so that the deferred client.Stop flushes the telemetry.
+func run(logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := otlp.NewClient(
+		"http://localhost:4318",
+		otlp.WithProtocol("http/protobuf"),
+		otlp.DefaultClientOptions("OTEL_EXPORTER_"),
+		otlp.WithLogger(logger),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := client.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start client: %w", err)
+	}
+	defer client.Stop(context.Background())
+	return lambdaext.Run(ctx, client, lambdaext.WithLogger(logger))
+}
@@ -0,0 +1,68 @@
+package otlp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AccessLogMiddleware returns a middleware that logs every export request with the signal, the number of the items (spans, data points, log records or profiles),
+// the address of the peer, the transport, the duration, the status code and the request id, e.g. mux.Use(otlp.AccessLogMiddleware(logger)).
+// the successful requests are logged at Info, the failed ones at Warn with the error.
+func AccessLogMiddleware(logger *slog.Logger) MiddlewareFunc {
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			signalType, items := requestItems(req)
+			attrs := []slog.Attr{
+				slog.String("signal", signalType),
+				slog.Int("items", items),
+				slog.String("peer", peerAddr(ctx)),
+				slog.String("transport", transport(ctx)),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("code", status.Code(err).String()),
+			}
+			if id, ok := RequestIDFromContext(ctx); ok {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelWarn, "export request", append(attrs, slog.String("details", err.Error()))...)
+				return resp, err
+			}
+			logger.LogAttrs(ctx, slog.LevelInfo, "export request", attrs...)
+			return resp, nil
+		}
+	}
+}
+
+// requestItems returns the signal type and the number of the items of the export request.
+func requestItems(req proto.Message) (string, int) {
+	switch req := req.(type) {
+	case *TraceRequest:
+		return "traces", TotalSpans(req.GetResourceSpans())
+	case *MetricsRequest:
+		return "metrics", TotalDataPoints(req.GetResourceMetrics())
+	case *LogsRequest:
+		return "logs", TotalLogRecords(req.GetResourceLogs())
+	case *ProfilesRequest:
+		return "profiles", TotalProfiles(req.GetResourceProfiles())
+	}
+	return "unknown", 0
+}
+
+func peerAddr(ctx context.Context) string {
+	info, ok := ClientInfoFromContext(ctx)
+	if !ok || info.Addr == nil {
+		return ""
+	}
+	return info.Addr.String()
+}
+
+func transport(ctx context.Context) string {
+	info, _ := ClientInfoFromContext(ctx)
+	return info.Transport
+}
@@ -0,0 +1,63 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var expected otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(traceData, &expected))
+
+	var buf bytes.Buffer
+	mux := otlp.NewServerMux()
+	mux.Use(otlp.AccessLogMiddleware(slog.New(slog.NewJSONHandler(&buf, nil))))
+	var fail bool
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		if fail {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	post := func() {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+		req.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post()
+	fail = true
+	post()
+
+	var entries []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		require.NoError(t, dec.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	for i, want := range []struct{ level, code string }{{"INFO", "OK"}, {"WARN", "Unavailable"}} {
+		require.Equal(t, want.level, entries[i]["level"])
+		require.Equal(t, "export request", entries[i]["msg"])
+		require.Equal(t, "traces", entries[i]["signal"])
+		require.EqualValues(t, otlp.TotalSpans(expected.GetResourceSpans()), entries[i]["items"])
+		require.Equal(t, "192.0.2.1:1234", entries[i]["peer"])
+		require.Equal(t, "http", entries[i]["transport"])
+		require.Contains(t, entries[i], "duration")
+		require.Equal(t, want.code, entries[i]["code"])
+	}
+	require.Equal(t, "rpc error: code = Unavailable desc = unavailable", entries[1]["details"])
+}
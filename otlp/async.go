@@ -0,0 +1,166 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AsyncOverflowPolicy is the policy of AsyncHandler when the queue is full.
+type AsyncOverflowPolicy int
+
+const (
+	// AsyncOverflowReject rejects the request with UNAVAILABLE, which the exporters retry with backoff.
+	AsyncOverflowReject AsyncOverflowPolicy = iota
+	// AsyncOverflowBlock waits for the queue until the context of the request is done.
+	AsyncOverflowBlock
+	// AsyncOverflowDrop drops the request and acknowledges it, losing the telemetry.
+	AsyncOverflowDrop
+)
+
+type asyncOptions struct {
+	overflow AsyncOverflowPolicy
+	onError  func(ctx context.Context, err error)
+}
+
+// AsyncOption is the option for AsyncHandler.
+type AsyncOption func(*asyncOptions)
+
+// WithAsyncOverflow sets the policy when the queue is full. default is AsyncOverflowReject.
+func WithAsyncOverflow(policy AsyncOverflowPolicy) AsyncOption {
+	return func(o *asyncOptions) {
+		o.overflow = policy
+	}
+}
+
+// WithAsyncErrorHandler sets the callback of the errors of the handler and the dropped requests, as the exporters do not see them.
+// ctx is the context of the request without its cancellation.
+func WithAsyncErrorHandler(f func(ctx context.Context, err error)) AsyncOption {
+	return func(o *asyncOptions) {
+		o.onError = f
+	}
+}
+
+// ErrAsyncQueueFull is passed to the error handler of AsyncHandler for the requests dropped by AsyncOverflowDrop.
+var ErrAsyncQueueFull = errors.New("async queue is full")
+
+type asyncItem[Req any] struct {
+	ctx context.Context
+	req Req
+}
+
+// AsyncQueue is the queue of the requests and the workers of AsyncHandler.
+type AsyncQueue[Req ExportRequest, Resp proto.Message] struct {
+	handler func(context.Context, Req) (Resp, error)
+	o       asyncOptions
+
+	mu      sync.RWMutex
+	queue   chan asyncItem[Req]
+	closing chan struct{}
+	closed  bool
+	once    sync.Once
+	workers sync.WaitGroup
+}
+
+// AsyncHandler returns a queue whose Handle acknowledges the requests as soon as they are enqueued, and handles them with the workers in background,
+// for the handlers whose latency exceeds the timeouts of the exporters:
+//
+//	async := otlp.AsyncHandler(sink.HandleTrace, 1000, 4)
+//	defer async.Close(ctx)
+//	mux.Trace().HandleFunc(async.Handle)
+//
+// the handler is called with the context of the request without its cancellation, and its errors are not returned to the exporters,
+// see WithAsyncErrorHandler. the enqueued requests are lost if the process dies before Close.
+func AsyncHandler[Req ExportRequest, Resp proto.Message](handler func(ctx context.Context, request Req) (Resp, error), queueSize int, workers int, opts ...AsyncOption) *AsyncQueue[Req, Resp] {
+	o := asyncOptions{
+		onError: func(context.Context, error) {},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	q := &AsyncQueue[Req, Resp]{
+		handler: handler,
+		o:       o,
+		queue:   make(chan asyncItem[Req], max(queueSize, 0)),
+		closing: make(chan struct{}),
+	}
+	for i := 0; i < max(workers, 1); i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+func (q *AsyncQueue[Req, Resp]) work() {
+	defer q.workers.Done()
+	for item := range q.queue {
+		if _, err := q.handler(item.ctx, item.req); err != nil {
+			q.o.onError(item.ctx, err)
+		}
+	}
+}
+
+// Handle enqueues the request, and returns the empty response.
+func (q *AsyncQueue[Req, Resp]) Handle(ctx context.Context, request Req) (Resp, error) {
+	var zero Resp
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return zero, status.Error(codes.Unavailable, "async handler is closed")
+	}
+	item := asyncItem[Req]{ctx: context.WithoutCancel(ctx), req: request}
+	select {
+	case q.queue <- item:
+		return newResponse[Resp](), nil
+	default:
+	}
+	switch q.o.overflow {
+	case AsyncOverflowBlock:
+		select {
+		case q.queue <- item:
+			return newResponse[Resp](), nil
+		case <-ctx.Done():
+			return zero, status.FromContextError(ctx.Err()).Err()
+		case <-q.closing:
+			return zero, status.Error(codes.Unavailable, "async handler is closed")
+		}
+	case AsyncOverflowDrop:
+		q.o.onError(item.ctx, ErrAsyncQueueFull)
+		return newResponse[Resp](), nil
+	default:
+		return zero, status.Error(codes.Unavailable, ErrAsyncQueueFull.Error())
+	}
+}
+
+// Close stops accepting the requests, and waits for the workers to handle the enqueued ones until ctx is done.
+func (q *AsyncQueue[Req, Resp]) Close(ctx context.Context) error {
+	q.once.Do(func() {
+		// release the requests waiting for the queue, before waiting for them.
+		close(q.closing)
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.closed = true
+		close(q.queue)
+	})
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newResponse returns a new empty message of the response type.
+func newResponse[Resp proto.Message]() Resp {
+	var zero Resp
+	return zero.ProtoReflect().New().Interface().(Resp)
+}
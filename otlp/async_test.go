@@ -0,0 +1,90 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAsyncHandler(t *testing.T) {
+	var handled atomic.Int32
+	release := make(chan struct{})
+	errs := make(chan error, 10)
+	async := otlp.AsyncHandler(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		<-release
+		handled.Add(int32(otlp.TotalSpans(req.GetResourceSpans())))
+		return nil, errors.New("sink failed")
+	}, 1, 1, otlp.WithAsyncErrorHandler(func(_ context.Context, err error) {
+		errs <- err
+	}))
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(async.Handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := async.Handle(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	cancel()
+	// the worker takes the first one, and the second one is queued.
+	require.Eventually(t, func() bool {
+		_, err := async.Handle(context.Background(), &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+		return err == nil
+	}, time.Second, time.Millisecond)
+	_, err = async.Handle(context.Background(), &otlp.TraceRequest{ResourceSpans: newSpans(4)})
+	require.Equal(t, codes.Unavailable, status.Code(err), "the queue is full")
+
+	close(release)
+	require.NoError(t, async.Close(context.Background()))
+	require.EqualValues(t, 3, handled.Load(), "the canceled request is handled too")
+	require.EqualError(t, <-errs, "sink failed")
+	_, err = async.Handle(context.Background(), &otlp.TraceRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err), "closed")
+}
+
+func TestAsyncHandler_Overflow(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	newAsync := func(opts ...otlp.AsyncOption) *otlp.AsyncQueue[*otlp.LogsRequest, *otlp.LogsResponse] {
+		async := otlp.AsyncHandler(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+			started <- struct{}{}
+			<-release
+			return &otlp.LogsResponse{}, nil
+		}, 0, 1, opts...)
+		// the queue has no buffer, so retry until the worker is ready to receive.
+		for {
+			_, err := async.Handle(context.Background(), &otlp.LogsRequest{})
+			require.NoError(t, err)
+			select {
+			case <-started:
+				return async
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+	defer close(release)
+
+	t.Run("block", func(t *testing.T) {
+		async := newAsync(otlp.WithAsyncOverflow(otlp.AsyncOverflowBlock))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := async.Handle(ctx, &otlp.LogsRequest{})
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+	t.Run("drop", func(t *testing.T) {
+		var dropped error
+		async := newAsync(otlp.WithAsyncOverflow(otlp.AsyncOverflowDrop), otlp.WithAsyncErrorHandler(func(_ context.Context, err error) {
+			dropped = err
+		}))
+		dropped = nil
+		_, err := async.Handle(context.Background(), &otlp.LogsRequest{})
+		require.NoError(t, err)
+		require.ErrorIs(t, dropped, otlp.ErrAsyncQueueFull)
+	})
+}
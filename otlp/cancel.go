@@ -0,0 +1,122 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CanceledError is the error of an export that is canceled or whose deadline is exceeded,
+// returned by the uploads of Client, and passed to the canceled callbacks of Client and ServerMux.
+// errors.Is(err, context.Canceled) and errors.Is(err, context.DeadlineExceeded) distinguish them, also for gRPC status errors.
+type CanceledError struct {
+	Signal string
+	// Cause is context.Canceled or context.DeadlineExceeded.
+	Cause error
+	Err   error
+}
+
+func (e *CanceledError) Error() string {
+	reason := "canceled"
+	if e.Timeout() {
+		reason = "deadline exceeded"
+	}
+	return fmt.Sprintf("%s export %s: %v", e.Signal, reason, e.Err)
+}
+
+func (e *CanceledError) Unwrap() []error {
+	return []error{e.Err, e.Cause}
+}
+
+// Timeout reports whether the deadline is exceeded, i.e. the export was slow rather than shed.
+func (e *CanceledError) Timeout() bool {
+	return e.Cause == context.DeadlineExceeded
+}
+
+// contextCause returns context.Canceled or context.DeadlineExceeded if err is caused by them, including the gRPC status errors.
+func contextCause(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// newCanceledError returns *CanceledError if the failed export is caused by the cancellation of ctx or of the call, otherwise nil.
+func newCanceledError(ctx context.Context, signalType string, err error) *CanceledError {
+	if err == nil {
+		return nil
+	}
+	var canceled *CanceledError
+	if errors.As(err, &canceled) {
+		return canceled
+	}
+	cause := contextCause(err)
+	if cause == nil {
+		if ctx.Err() == nil {
+			return nil
+		}
+		cause = contextCause(ctx.Err())
+	}
+	return &CanceledError{Signal: signalType, Cause: cause, Err: err}
+}
+
+// WithCanceledCallback sets the callback called when an upload fails because it is canceled or its deadline is exceeded,
+// by the context of the caller, the export timeout or Stop. see CanceledError.Timeout to tell them apart.
+func WithCanceledCallback(callback func(err *CanceledError)) ClientOption {
+	return func(o *clientOptions) error {
+		o.onCanceled = callback
+		return nil
+	}
+}
+
+// SetCanceledCallback sets the callback called when the handler fails because the export is canceled or its deadline is exceeded,
+// e.g. the client disconnected before the response. ctx is the context of the request.
+func (mux *ServerMux) SetCanceledCallback(callback func(ctx context.Context, err *CanceledError)) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.onCanceled = callback
+}
+
+func (mux *ServerMux) canceledCallback() func(ctx context.Context, err *CanceledError) {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	return mux.onCanceled
+}
+
+// beginExport records the start of an export request, and returns the function to record its end.
+// it returns UNAVAILABLE if the mux is shutting down, see Shutdown.
+func (mux *ServerMux) beginExport(ctx context.Context, req proto.Message) (func(proto.Message, error), error) {
+	if err := mux.enterExport(); err != nil {
+		return nil, err
+	}
+	signalType, n := requestItems(req)
+	done := mux.stats.signal(signalType).begin(n)
+	report := mux.beginServerStat(signalType, n, req)
+	hooked := mux.hooks.begin(ctx, signalType, n)
+	return func(resp proto.Message, err error) {
+		defer mux.exporting.Done()
+		canceled := newCanceledError(ctx, signalType, err)
+		done(err, canceled)
+		report(err)
+		hooked(resp, err)
+		if canceled == nil {
+			return
+		}
+		if callback := mux.canceledCallback(); callback != nil {
+			callback(ctx, canceled)
+		}
+	}, nil
+}
@@ -0,0 +1,91 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlockingMux() *otlp.ServerMux {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	return mux
+}
+
+func TestClient_CanceledError(t *testing.T) {
+	server := otlptest.NewServer(newBlockingMux())
+	defer server.Close()
+	var callbacks []*otlp.CanceledError
+	client, err := otlp.NewClient(server.URL, otlp.WithCanceledCallback(func(err *otlp.CanceledError) {
+		callbacks = append(callbacks, err)
+	}))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(context.Background()))
+	defer client.Stop(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.UploadTraces(ctx, []*otlp.ResourceSpans{})
+	var canceled *otlp.CanceledError
+	require.ErrorAs(t, err, &canceled)
+	require.True(t, canceled.Timeout())
+	require.Equal(t, "traces", canceled.Signal)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err = client.UploadTraces(ctx, []*otlp.ResourceSpans{})
+	require.ErrorAs(t, err, &canceled)
+	require.False(t, canceled.Timeout())
+	require.ErrorIs(t, err, context.Canceled)
+
+	stats := client.Stats()["traces"]
+	require.EqualValues(t, 2, stats.Failures)
+	require.EqualValues(t, 1, stats.Canceled)
+	require.EqualValues(t, 1, stats.DeadlineExceeded)
+	require.Len(t, callbacks, 2)
+}
+
+func TestMux__CanceledCallback(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := newBlockingMux()
+	canceled := make(chan *otlp.CanceledError, 1)
+	mux.SetCanceledCallback(func(_ context.Context, err *otlp.CanceledError) {
+		canceled <- err
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/v1/traces", bytes.NewReader(traceData))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = http.DefaultClient.Do(req)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	select {
+	case err := <-canceled:
+		require.Equal(t, "traces", err.Signal)
+		require.False(t, err.Timeout())
+	case <-time.After(5 * time.Second):
+		t.Fatal("canceled callback is not called")
+	}
+	w := httptest.NewRecorder()
+	mux.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, w.Body.String(), `otlp_mux_canceled_total{signal="traces"} 1`+"\n")
+	require.Contains(t, w.Body.String(), `otlp_mux_deadline_exceeded_total{signal="traces"} 0`+"\n")
+}
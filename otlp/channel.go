@@ -0,0 +1,120 @@
+package otlp
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExportRequest is the constraint of the export requests of the signals.
+type ExportRequest interface {
+	*TraceRequest | *MetricsRequest | *LogsRequest | *ProfilesRequest
+}
+
+type channelHandlerOptions struct {
+	buffer int
+}
+
+// ChannelHandlerOption is the option for NewChannelHandler.
+type ChannelHandlerOption func(*channelHandlerOptions)
+
+// WithChannelHandlerBuffer sets the capacity of the channel. default is 0, i.e. the handler waits until a consumer receives the request.
+func WithChannelHandlerBuffer(n int) ChannelHandlerOption {
+	return func(o *channelHandlerOptions) {
+		o.buffer = n
+	}
+}
+
+// ChannelHandler is a handler that sends the requests of T to the channel returned by NewChannelHandler,
+// e.g. to process them with a pool of workers downstream of the mux:
+//
+//	h, requests := otlp.NewChannelHandler[*otlp.TraceRequest](otlp.WithChannelHandlerBuffer(100))
+//	mux.Trace().Handle(h)
+//	for i := 0; i < 4; i++ {
+//		go func() {
+//			for req := range requests {
+//				// process req
+//			}
+//		}()
+//	}
+//
+// the requests are acknowledged when sent to the channel. while the channel is full, the handler waits until the context of the request is done,
+// and returns its error to the exporter. it implements the handler interfaces of all signals, but the requests other than T are rejected with UNIMPLEMENTED.
+type ChannelHandler[T ExportRequest] struct {
+	mu      sync.RWMutex
+	ch      chan T
+	closing chan struct{}
+	closed  bool
+	once    sync.Once
+}
+
+var (
+	_ TraceHandler    = (*ChannelHandler[*TraceRequest])(nil)
+	_ MetricsHandler  = (*ChannelHandler[*MetricsRequest])(nil)
+	_ LogsHandler     = (*ChannelHandler[*LogsRequest])(nil)
+	_ ProfilesHandler = (*ChannelHandler[*ProfilesRequest])(nil)
+)
+
+// NewChannelHandler returns a new ChannelHandler and the channel of the requests, which is closed by Close.
+func NewChannelHandler[T ExportRequest](opts ...ChannelHandlerOption) (*ChannelHandler[T], <-chan T) {
+	o := channelHandlerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := &ChannelHandler[T]{
+		ch:      make(chan T, max(o.buffer, 0)),
+		closing: make(chan struct{}),
+	}
+	return h, h.ch
+}
+
+func (h *ChannelHandler[T]) HandleTrace(ctx context.Context, request *TraceRequest) (*TraceResponse, error) {
+	return &TraceResponse{}, h.send(ctx, request)
+}
+
+func (h *ChannelHandler[T]) HandleMetrics(ctx context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+	return &MetricsResponse{}, h.send(ctx, request)
+}
+
+func (h *ChannelHandler[T]) HandleLogs(ctx context.Context, request *LogsRequest) (*LogsResponse, error) {
+	return &LogsResponse{}, h.send(ctx, request)
+}
+
+func (h *ChannelHandler[T]) HandleProfiles(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error) {
+	return &ProfilesResponse{}, h.send(ctx, request)
+}
+
+func (h *ChannelHandler[T]) send(ctx context.Context, request proto.Message) error {
+	req, ok := request.(T)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "%T is not handled", request)
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return status.Error(codes.Unavailable, "channel handler is closed")
+	}
+	select {
+	case h.ch <- req:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-h.closing:
+		return status.Error(codes.Unavailable, "channel handler is closed")
+	}
+}
+
+// Close closes the channel, after the requests being sent return. the requests after Close are rejected with UNAVAILABLE.
+func (h *ChannelHandler[T]) Close() {
+	h.once.Do(func() {
+		// release the senders waiting for the channel, before waiting for them.
+		close(h.closing)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.closed = true
+		close(h.ch)
+	})
+}
@@ -0,0 +1,67 @@
+package otlp_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChannelHandler(t *testing.T) {
+	h, requests := otlp.NewChannelHandler[*otlp.TraceRequest](otlp.WithChannelHandlerBuffer(1))
+	ctx := context.Background()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		spans int
+	)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range requests {
+				mu.Lock()
+				spans += otlp.TotalSpans(req.GetResourceSpans())
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+		require.NoError(t, err)
+	}
+	_, err := h.HandleLogs(ctx, &otlp.LogsRequest{})
+	require.Equal(t, codes.Unimplemented, status.Code(err), "the other signals are rejected")
+
+	h.Close()
+	wg.Wait()
+	require.Equal(t, 20, spans)
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	h.Close()
+}
+
+func TestChannelHandler_Full(t *testing.T) {
+	h, requests := otlp.NewChannelHandler[*otlp.TraceRequest]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := h.HandleTrace(ctx, &otlp.TraceRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err), "no consumer receives the request")
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := h.HandleTrace(context.Background(), &otlp.TraceRequest{})
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	h.Close()
+	require.Equal(t, codes.Unavailable, status.Code(<-errs), "the waiting sender is released by Close")
+	_, ok := <-requests
+	require.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// ChunkOptions is the options for UploadTracesChunked.
+type ChunkOptions struct {
+	// MaxSpans is the maximum number of spans in a chunk. default is 1000.
+	MaxSpans int
+	// Concurrency is the maximum number of chunks uploaded at the same time. default is 1, chunks are uploaded sequentially.
+	Concurrency int
+}
+
+// ChunkProgress is the progress of UploadTracesChunked, reported after each chunk is uploaded.
+type ChunkProgress struct {
+	TotalChunks    int
+	UploadedChunks int
+	TotalSpans     int
+	UploadedSpans  int
+}
+
+// ChunkedUploadError is the error returned by UploadTracesChunked when some chunks are not uploaded.
+// Remaining holds the spans that are not uploaded, pass it to UploadTracesChunked again to resume the upload.
+type ChunkedUploadError struct {
+	Remaining []*tracepb.ResourceSpans
+	Err       error
+}
+
+func (e *ChunkedUploadError) Error() string {
+	return fmt.Sprintf("%d spans are not uploaded: %v", TotalSpans(e.Remaining), e.Err)
+}
+
+func (e *ChunkedUploadError) Unwrap() error {
+	return e.Err
+}
+
+// UploadTracesChunked uploads a large number of spans in chunks, e.g. for bulk backfills.
+// each chunk is retried on retryable errors, with the retry configuration of WithRetry or DefaultRetryConfig if retrying is disabled.
+// when a chunk fails or the context is done, no more chunks are started and *ChunkedUploadError with the remaining spans is returned.
+// onProgress is called after each chunk is uploaded, it may be nil. calls to onProgress are serialized.
+func (c *Client) UploadTracesChunked(ctx context.Context, protoSpans []*ResourceSpans, opts ChunkOptions, onProgress func(ChunkProgress)) error {
+	if c.o.traces.disabled {
+		return nil
+	}
+	if opts.MaxSpans <= 0 {
+		opts.MaxSpans = 1000
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	retry := c.o.retry
+	if !retry.Enabled {
+		retry = DefaultRetryConfig
+	}
+	chunks := ChunkResourceSpans(protoSpans, opts.MaxSpans)
+	progress := ChunkProgress{
+		TotalChunks: len(chunks),
+		TotalSpans:  TotalSpans(protoSpans),
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		errs     []error
+		uploaded = make([]bool, len(chunks))
+		sem      = make(chan struct{}, opts.Concurrency)
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}
+	for i, chunk := range chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil || failed() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := c.doWithRetryConfig(ctx, retry, "traces", TotalSpans(chunk), func(ctx context.Context) error {
+				c.mu.RLock()
+				defer c.mu.RUnlock()
+				return c.uploadTraces(ctx, chunk)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("chunk %d: %w", i, err))
+				return
+			}
+			uploaded[i] = true
+			progress.UploadedChunks++
+			progress.UploadedSpans += TotalSpans(chunk)
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}()
+	}
+	wg.Wait()
+	if progress.UploadedChunks == len(chunks) {
+		return nil
+	}
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	remaining := make([]*tracepb.ResourceSpans, 0)
+	for i, chunk := range chunks {
+		if !uploaded[i] {
+			remaining = append(remaining, chunk...)
+		}
+	}
+	return &ChunkedUploadError{
+		Remaining: remaining,
+		Err:       errors.Join(errs...),
+	}
+}
+
+// ChunkResourceSpans splits the given ResourceSpans slice into chunks of at most maxSpans spans, e.g. for the exporters with the limits of the items per request.
+// the spans of the same resource and scope in a chunk are grouped together as MergeResourceSpans does. maxSpans <= 0 means no limit.
+func ChunkResourceSpans(src []*tracepb.ResourceSpans, maxSpans int) [][]*tracepb.ResourceSpans {
+	return chunkItems(SplitResourceSpans(src), maxSpans, MergeResourceSpans)
+}
+
+// ChunkResourceMetrics splits the given ResourceMetrics slice into chunks of at most maxDataPoints data points, grouped as MergeResourceMetrics does.
+// maxDataPoints <= 0 means no limit.
+func ChunkResourceMetrics(src []*metricspb.ResourceMetrics, maxDataPoints int) [][]*metricspb.ResourceMetrics {
+	return chunkItems(SplitResourceMetrics(src), maxDataPoints, MergeResourceMetrics)
+}
+
+// ChunkResourceLogs splits the given ResourceLogs slice into chunks of at most maxLogRecords log records, grouped as MergeResourceLogs does.
+// maxLogRecords <= 0 means no limit.
+func ChunkResourceLogs(src []*logspb.ResourceLogs, maxLogRecords int) [][]*logspb.ResourceLogs {
+	return chunkItems(SplitResourceLogs(src), maxLogRecords, MergeResourceLogs)
+}
+
+// ChunkResourceSpansBySize splits the given ResourceSpans slice into chunks whose ExportTraceServiceRequest is at most maxBytes in the protobuf encoding,
+// e.g. for the collectors and the gateways with the max message sizes. the spans are grouped as MergeResourceSpans does, keeping the order.
+// a span larger than maxBytes by itself makes a chunk exceeding maxBytes. maxBytes <= 0 means no limit.
+func ChunkResourceSpansBySize(src []*tracepb.ResourceSpans, maxBytes int) [][]*tracepb.ResourceSpans {
+	return chunkItemsBySize(SplitResourceSpans(src), maxBytes, MergeResourceSpans)
+}
+
+// ChunkResourceMetricsBySize splits the given ResourceMetrics slice into chunks whose ExportMetricsServiceRequest is at most maxBytes, see ChunkResourceSpansBySize.
+func ChunkResourceMetricsBySize(src []*metricspb.ResourceMetrics, maxBytes int) [][]*metricspb.ResourceMetrics {
+	return chunkItemsBySize(SplitResourceMetrics(src), maxBytes, MergeResourceMetrics)
+}
+
+// ChunkResourceLogsBySize splits the given ResourceLogs slice into chunks whose ExportLogsServiceRequest is at most maxBytes, see ChunkResourceSpansBySize.
+func ChunkResourceLogsBySize(src []*logspb.ResourceLogs, maxBytes int) [][]*logspb.ResourceLogs {
+	return chunkItemsBySize(SplitResourceLogs(src), maxBytes, MergeResourceLogs)
+}
+
+// chunkItemsBySize chunks the split elements by the size of the export request of the merged chunk.
+// the size of an element as the repeated field, the tag, the length and the message, is the upper bound of its growth of the merged chunk,
+// so the exact size is computed only when the estimated one exceeds maxBytes.
+func chunkItemsBySize[T proto.Message](split []T, maxBytes int, merge func([]T) []T) [][]T {
+	if len(split) == 0 {
+		return nil
+	}
+	if maxBytes <= 0 {
+		return [][]T{merge(split)}
+	}
+	var (
+		chunks [][]T
+		start  int
+		size   int
+	)
+	for i, elem := range split {
+		n := repeatedFieldSize(elem)
+		if i > start && size+n > maxBytes {
+			size = mergedSize(merge(split[start : i+1]))
+			if size <= maxBytes {
+				continue
+			}
+			chunks = append(chunks, merge(split[start:i]))
+			start, size = i, 0
+		}
+		size += n
+	}
+	return append(chunks, merge(split[start:]))
+}
+
+func repeatedFieldSize(msg proto.Message) int {
+	return protowire.SizeTag(1) + protowire.SizeBytes(proto.Size(msg))
+}
+
+func mergedSize[T proto.Message](merged []T) int {
+	var size int
+	for _, elem := range merged {
+		size += repeatedFieldSize(elem)
+	}
+	return size
+}
+
+// chunkItems chunks the split elements, each containing only one item, and merges each chunk.
+func chunkItems[T any](split []T, maxItems int, merge func([]T) []T) [][]T {
+	if len(split) == 0 {
+		return nil
+	}
+	if maxItems <= 0 {
+		maxItems = len(split)
+	}
+	chunks := make([][]T, 0, (len(split)+maxItems-1)/maxItems)
+	for i := 0; i < len(split); i += maxItems {
+		chunks = append(chunks, merge(split[i:min(i+maxItems, len(split))]))
+	}
+	return chunks
+}
@@ -0,0 +1,218 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func newSpans(n int) []*otlp.ResourceSpans {
+	spans := make([]*tracepb.Span, 0, n)
+	for i := 0; i < n; i++ {
+		spans = append(spans, &tracepb.Span{
+			TraceId: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+			SpanId:  []byte{0, 0, 0, 0, 0, 0, 0, byte(i + 1)},
+			Name:    "span",
+		})
+	}
+	return []*otlp.ResourceSpans{
+		{ScopeSpans: []*tracepb.ScopeSpans{{Spans: spans}}},
+	}
+}
+
+func newChunkTestServer(t *testing.T, handle func(call int32) int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var spans atomic.Int32
+	var calls atomic.Int32
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		spans.Add(int32(otlp.TotalSpans(request.GetResourceSpans())))
+		return &otlp.TraceResponse{}, nil
+	})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if code := handle(calls.Add(1)); code != http.StatusOK {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(code)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		},
+	))
+	t.Cleanup(server.Close)
+	return server, &spans
+}
+
+func TestClient_UploadTracesChunked(t *testing.T) {
+	server, spans := newChunkTestServer(t, func(call int32) int {
+		if call == 2 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	var progress []otlp.ChunkProgress
+	err = client.UploadTracesChunked(ctx, newSpans(5), otlp.ChunkOptions{MaxSpans: 2}, func(p otlp.ChunkProgress) {
+		progress = append(progress, p)
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 5, spans.Load())
+	require.Equal(t, []otlp.ChunkProgress{
+		{TotalChunks: 3, UploadedChunks: 1, TotalSpans: 5, UploadedSpans: 2},
+		{TotalChunks: 3, UploadedChunks: 2, TotalSpans: 5, UploadedSpans: 4},
+		{TotalChunks: 3, UploadedChunks: 3, TotalSpans: 5, UploadedSpans: 5},
+	}, progress)
+}
+
+func TestClient_UploadTracesChunked__Resume(t *testing.T) {
+	var broken atomic.Bool
+	broken.Store(true)
+	server, spans := newChunkTestServer(t, func(call int32) int {
+		if call == 2 && broken.Load() {
+			return http.StatusBadRequest
+		}
+		return http.StatusOK
+	})
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	err = client.UploadTracesChunked(ctx, newSpans(5), otlp.ChunkOptions{MaxSpans: 2}, nil)
+	var chunkedErr *otlp.ChunkedUploadError
+	require.True(t, errors.As(err, &chunkedErr))
+	require.Equal(t, 3, otlp.TotalSpans(chunkedErr.Remaining))
+	require.EqualValues(t, 2, spans.Load())
+
+	broken.Store(false)
+	require.NoError(t, client.UploadTracesChunked(ctx, chunkedErr.Remaining, otlp.ChunkOptions{MaxSpans: 2, Concurrency: 2}, nil))
+	require.EqualValues(t, 5, spans.Load())
+}
+
+func TestChunkResourceSpans(t *testing.T) {
+	src := newSpans(5)
+	chunks := otlp.ChunkResourceSpans(src, 2)
+	require.Len(t, chunks, 3)
+	for i, expected := range []int{2, 2, 1} {
+		require.Equal(t, expected, otlp.TotalSpans(chunks[i]))
+		require.Len(t, chunks[i], 1, "the spans of the same resource are grouped")
+		require.Len(t, chunks[i][0].GetScopeSpans(), 1, "the spans of the same scope are grouped")
+	}
+	require.Equal(t, byte(5), chunks[2][0].GetScopeSpans()[0].GetSpans()[0].GetSpanId()[7], "the order of the spans is kept")
+	require.Equal(t, 5, otlp.TotalSpans(src), "src is not modified")
+
+	chunks = otlp.ChunkResourceSpans(src, 0)
+	require.Len(t, chunks, 1)
+	assertEqualMessage(t, &tracepb.TracesData{ResourceSpans: src}, &tracepb.TracesData{ResourceSpans: chunks[0]})
+	require.Empty(t, otlp.ChunkResourceSpans(nil, 2))
+}
+
+func TestChunkResourceMetrics(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_metrics.json")
+	require.NoError(t, err)
+	var data metricspb.MetricsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+
+	total := otlp.TotalDataPoints(data.GetResourceMetrics())
+	chunks := otlp.ChunkResourceMetrics(data.GetResourceMetrics(), 3)
+	require.Len(t, chunks, (total+2)/3)
+	var sum int
+	for _, chunk := range chunks {
+		n := otlp.TotalDataPoints(chunk)
+		require.LessOrEqual(t, n, 3)
+		sum += n
+	}
+	require.Equal(t, total, sum)
+	require.Len(t, otlp.ChunkResourceMetrics(data.GetResourceMetrics(), total), 1)
+}
+
+func TestChunkResourceLogs(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_logs.json")
+	require.NoError(t, err)
+	var data logspb.LogsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+
+	total := otlp.TotalLogRecords(data.GetResourceLogs())
+	chunks := otlp.ChunkResourceLogs(data.GetResourceLogs(), 1)
+	require.Len(t, chunks, total)
+	for _, chunk := range chunks {
+		require.Equal(t, 1, otlp.TotalLogRecords(chunk))
+	}
+	chunks = otlp.ChunkResourceLogs(data.GetResourceLogs(), total)
+	require.Len(t, chunks, 1)
+	assertEqualMessage(t, &logspb.LogsData{ResourceLogs: otlp.MergeResourceLogs(data.GetResourceLogs())}, &logspb.LogsData{ResourceLogs: chunks[0]})
+}
+
+func TestChunkResourceSpansBySize(t *testing.T) {
+	src := newSpans(10)
+	total := proto.Size(&otlp.TraceRequest{ResourceSpans: src})
+	chunks := otlp.ChunkResourceSpansBySize(src, total)
+	require.Len(t, chunks, 1)
+	assertEqualMessage(t, &tracepb.TracesData{ResourceSpans: src}, &tracepb.TracesData{ResourceSpans: chunks[0]})
+	require.Len(t, otlp.ChunkResourceSpansBySize(src, 0), 1)
+
+	maxBytes := total / 3
+	chunks = otlp.ChunkResourceSpansBySize(src, maxBytes)
+	require.Greater(t, len(chunks), 2)
+	var n int
+	for _, chunk := range chunks {
+		require.LessOrEqual(t, proto.Size(&otlp.TraceRequest{ResourceSpans: chunk}), maxBytes)
+		require.Len(t, chunk, 1)
+		n += otlp.TotalSpans(chunk)
+	}
+	require.Equal(t, 10, n)
+
+	chunks = otlp.ChunkResourceSpansBySize(src, 1)
+	require.Len(t, chunks, 10, "a span larger than maxBytes makes a chunk by itself")
+	require.Empty(t, otlp.ChunkResourceSpansBySize(nil, 1024))
+}
+
+func TestChunkResourceMetricsAndLogsBySize(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_metrics.json")
+	require.NoError(t, err)
+	var metrics metricspb.MetricsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &metrics))
+	var maxBytes int
+	for _, elem := range otlp.SplitResourceMetrics(metrics.GetResourceMetrics()) {
+		maxBytes = max(maxBytes, proto.Size(&otlp.MetricsRequest{ResourceMetrics: []*metricspb.ResourceMetrics{elem}}))
+	}
+	maxBytes *= 2
+	chunks := otlp.ChunkResourceMetricsBySize(metrics.GetResourceMetrics(), maxBytes)
+	require.Less(t, len(chunks), otlp.TotalDataPoints(metrics.GetResourceMetrics()))
+	var n int
+	for _, chunk := range chunks {
+		require.LessOrEqual(t, proto.Size(&otlp.MetricsRequest{ResourceMetrics: chunk}), maxBytes)
+		n += otlp.TotalDataPoints(chunk)
+	}
+	require.Equal(t, otlp.TotalDataPoints(metrics.GetResourceMetrics()), n)
+
+	bs, err = os.ReadFile("testdata/batched_logs.json")
+	require.NoError(t, err)
+	var logs logspb.LogsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &logs))
+	total := proto.Size(&otlp.LogsRequest{ResourceLogs: logs.GetResourceLogs()})
+	require.Len(t, otlp.ChunkResourceLogsBySize(logs.GetResourceLogs(), total), 1)
+	chunks2 := otlp.ChunkResourceLogsBySize(logs.GetResourceLogs(), total-1)
+	require.Len(t, chunks2, otlp.TotalLogRecords(logs.GetResourceLogs()))
+	for _, chunk := range chunks2 {
+		require.LessOrEqual(t, proto.Size(&otlp.LogsRequest{ResourceLogs: chunk}), total-1)
+	}
+}
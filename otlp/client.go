@@ -8,8 +8,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 
+	"go.opentelemetry.io/otel/propagation"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
@@ -35,8 +37,20 @@ type Client struct {
 	mu sync.RWMutex
 
 	conns        map[string]*grpc.ClientConn
+	wsConns      map[string]*wsClientConn
 	stopContexts map[string]context.Context
 	stopFuncs    map[string]context.CancelFunc
+
+	partialMu          sync.Mutex
+	lastPartialSuccess map[string]PartialSuccess
+	stats              clientStats
+
+	abortMu  sync.Mutex
+	abortCtx context.Context
+	abort    context.CancelFunc
+	dropped  DroppedError
+
+	inflight inflightUploads
 }
 
 func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
@@ -53,33 +67,24 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 	}
 	o.logger.Debug(
 		"initializing client",
-		slog.Group("traces",
-			"protocol", o.traces.protocol,
-			"endpoint", o.traces.endpoint.String(),
-			"address", o.traces.endpoint.Host,
-			"insecure", o.traces.endpoint.Scheme != "https",
-			"timeout", o.traces.exportTimeout,
-		),
-		slog.Group("metrics",
-			"protocol", o.metrics.protocol,
-			"endpoint", o.metrics.endpoint.String(),
-			"address", o.metrics.endpoint.Host,
-			"insecure", o.metrics.endpoint.Scheme != "https",
-			"timeout", o.metrics.exportTimeout,
-		),
-		slog.Group("logs",
-			"protocol", o.logs.protocol,
-			"endpoint", o.logs.endpoint.String(),
-			"address", o.logs.endpoint.Host,
-			"insecure", o.logs.endpoint.Scheme != "https",
-			"timeout", o.logs.exportTimeout,
-		),
+		slog.Group("traces", o.traces.logAttrs()...),
+		slog.Group("metrics", o.metrics.logAttrs()...),
+		slog.Group("logs", o.logs.logAttrs()...),
+		slog.Group("profiles", o.profiles.logAttrs()...),
 	)
 	client := &Client{
 		o:            o,
 		conns:        make(map[string]*grpc.ClientConn, 3),
+		wsConns:      make(map[string]*wsClientConn, 3),
 		stopContexts: make(map[string]context.Context, 3),
 		stopFuncs:    make(map[string]context.CancelFunc, 3),
+
+		lastPartialSuccess: make(map[string]PartialSuccess, 3),
+	}
+	for _, so := range []*clientSignalsOptions{&o.traces, &o.metrics, &o.logs} {
+		if so.isWebSocketProtocol() && !so.disabled {
+			client.wsConns[so.signalType] = &wsClientConn{so: so}
+		}
 	}
 	return client, nil
 }
@@ -87,21 +92,26 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 func (c *Client) Start(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.o.traces.isGRPCProtocol() {
+	if c.o.traces.isGRPCProtocol() && !c.o.traces.disabled {
 		if err := c.startGRPC(ctx, &c.o.traces); err != nil {
 			return fmt.Errorf("start traces gRPC client: %w", err)
 		}
 	}
-	if c.o.metrics.isGRPCProtocol() {
+	if c.o.metrics.isGRPCProtocol() && !c.o.metrics.disabled {
 		if err := c.startGRPC(ctx, &c.o.metrics); err != nil {
 			return fmt.Errorf("start metrics gRPC client: %w", err)
 		}
 	}
-	if c.o.logs.isGRPCProtocol() {
+	if c.o.logs.isGRPCProtocol() && !c.o.logs.disabled {
 		if err := c.startGRPC(ctx, &c.o.logs); err != nil {
 			return fmt.Errorf("start logs gRPC client: %w", err)
 		}
 	}
+	if c.o.profiles.isGRPCProtocol() && !c.o.profiles.disabled && c.o.profiles.endpoint != nil {
+		if err := c.startGRPC(ctx, &c.o.profiles); err != nil {
+			return fmt.Errorf("start profiles gRPC client: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -131,8 +141,17 @@ func (c *Client) newGRPCContext(parent context.Context, so *clientSignalsOptions
 		ctx, cancel = context.WithCancel(parent)
 	}
 
-	if len(so.headers) > 0 {
-		ctx = metadata.NewOutgoingContext(ctx, metadata.New(so.headers))
+	md := metadata.New(so.headers)
+	for k, v := range outgoingHeadersFromContext(ctx) {
+		if _, ok := md[strings.ToLower(k)]; !ok {
+			md.Append(k, v...)
+		}
+	}
+	if so.propagator != nil {
+		so.propagator.Inject(ctx, metadataCarrier(md))
+	}
+	if len(md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 	_, _, connHash := so.grpcConnectionInfo()
 	stopCtx, ok := c.stopContexts[connHash]
@@ -155,12 +174,26 @@ var (
 )
 
 func (c *Client) UploadTraces(ctx context.Context, protoSpans []*ResourceSpans) error {
+	if c.o.traces.disabled {
+		return nil
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	if c.o.traces.isGRPCProtocol() {
+	defer c.inflight.begin()()
+	return c.doWithRetry(ctx, "traces", TotalSpans(protoSpans), func(ctx context.Context) error {
+		return c.uploadTraces(ctx, protoSpans)
+	})
+}
+
+func (c *Client) uploadTraces(ctx context.Context, protoSpans []*ResourceSpans) error {
+	switch {
+	case c.o.traces.isGRPCProtocol():
 		return c.uploadTracesWithGRPC(ctx, protoSpans)
+	case c.o.traces.isWebSocketProtocol():
+		return c.uploadTracesWithWebSocket(ctx, protoSpans)
+	default:
+		return c.uploadTracesWithHTTP(ctx, protoSpans)
 	}
-	return c.uploadTracesWithHTTP(ctx, protoSpans)
 }
 
 type UploadTracesPartialSuccessError struct {
@@ -202,11 +235,13 @@ func (c *Client) uploadTracesWithGRPC(ctx context.Context, protoSpans []*Resourc
 	sericeClient := coltracepb.NewTraceServiceClient(conn)
 	ctx, cancel := c.newGRPCContext(ctx, &c.o.traces)
 	defer cancel()
+	callOpts, observe := c.grpcResponseCallOptions(&c.o.traces)
 
 	c.o.logger.InfoContext(ctx, "uploading traces with gRPC", "conn_hash", connHash[0:8], "num_resource_spans", len(protoSpans))
 	resp, err := sericeClient.Export(ctx, &coltracepb.ExportTraceServiceRequest{
 		ResourceSpans: protoSpans,
-	})
+	}, callOpts...)
+	observe(err)
 	if err != nil && status.Code(err) != codes.OK {
 		return err
 	}
@@ -225,6 +260,12 @@ func newHTTPRequest[T proto.Message](ctx context.Context, so *clientSignalsOptio
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal body: %w", err)
 	}
+	if so.compression != "" {
+		bs, err = compressBody(so.compressor, bs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress body: %w", err)
+		}
+	}
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodPost,
@@ -236,14 +277,35 @@ func newHTTPRequest[T proto.Message](ctx context.Context, so *clientSignalsOptio
 	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", so.userAgent)
+	if so.compression != "" {
+		req.Header.Set("Content-Encoding", so.compression)
+	}
+	for k, v := range outgoingHeadersFromContext(ctx) {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
 	if len(so.headers) > 0 {
 		for k, v := range so.headers {
 			req.Header.Set(k, v)
 		}
 	}
+	if so.propagator != nil {
+		so.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	}
 	return req, nil
 }
 
+type outgoingHeadersKey struct{}
+
+// withOutgoingHeaders returns the context to send the headers with the uploads, in addition to the headers of the options, which take precedence.
+func withOutgoingHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, outgoingHeadersKey{}, headers)
+}
+
+func outgoingHeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(outgoingHeadersKey{}).(http.Header)
+	return headers
+}
+
 func (c *Client) uploadTracesWithHTTP(ctx context.Context, protoSpans []*ResourceSpans) error {
 	data := &coltracepb.ExportTraceServiceRequest{
 		ResourceSpans: protoSpans,
@@ -266,15 +328,19 @@ func (c *Client) uploadTracesWithHTTP(ctx context.Context, protoSpans []*Resourc
 			c.o.logger.WarnContext(ctx, "failed to close response body", "details", err)
 		}
 	}()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	c.observeHTTPResponse(&c.o.traces, resp)
+	if !c.o.isAcceptedStatusCode(resp.StatusCode) {
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
 	}
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	if len(respBody) == 0 && resp.StatusCode != http.StatusOK {
+		return nil
+	}
 	var respData coltracepb.ExportTraceServiceResponse
-	switch resp.Header.Get("Content-Type") {
+	switch parseMediaType(resp.Header.Get("Content-Type")) {
 	case "application/x-protobuf":
 		if err := proto.Unmarshal(respBody, &respData); err != nil {
 			return fmt.Errorf("failed to unmarshal response: %w", err)
@@ -290,13 +356,23 @@ func (c *Client) uploadTracesWithHTTP(ctx context.Context, protoSpans []*Resourc
 }
 
 func (c *Client) UploadMetrics(ctx context.Context, protoMetrics []*ResourceMetrics) error {
+	if c.o.metrics.disabled {
+		return nil
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-
-	if c.o.metrics.isGRPCProtocol() {
-		return c.uploadMetricsWithGRPC(ctx, protoMetrics)
-	}
-	return c.uploadMetricsWithHTTP(ctx, protoMetrics)
+	defer c.inflight.begin()()
+
+	return c.doWithRetry(ctx, "metrics", TotalDataPoints(protoMetrics), func(ctx context.Context) error {
+		switch {
+		case c.o.metrics.isGRPCProtocol():
+			return c.uploadMetricsWithGRPC(ctx, protoMetrics)
+		case c.o.metrics.isWebSocketProtocol():
+			return c.uploadMetricsWithWebSocket(ctx, protoMetrics)
+		default:
+			return c.uploadMetricsWithHTTP(ctx, protoMetrics)
+		}
+	})
 }
 
 type UploadMetricsPartialSuccessError struct {
@@ -338,11 +414,13 @@ func (c *Client) uploadMetricsWithGRPC(ctx context.Context, protoMetrics []*Reso
 	serviceClient := colmetricpb.NewMetricsServiceClient(conn)
 	ctx, cancel := c.newGRPCContext(ctx, &c.o.metrics)
 	defer cancel()
+	callOpts, observe := c.grpcResponseCallOptions(&c.o.metrics)
 
 	c.o.logger.InfoContext(ctx, "uploading metrics", "conn_hash", connHash[0:8], "num_resource_metrics", len(protoMetrics))
 	resp, err := serviceClient.Export(ctx, &colmetricpb.ExportMetricsServiceRequest{
 		ResourceMetrics: protoMetrics,
-	})
+	}, callOpts...)
+	observe(err)
 	if err != nil && status.Code(err) != codes.OK {
 		return err
 	}
@@ -371,15 +449,19 @@ func (c *Client) uploadMetricsWithHTTP(ctx context.Context, protoMetrics []*Reso
 			c.o.logger.WarnContext(ctx, "failed to close response body", "details", err)
 		}
 	}()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	c.observeHTTPResponse(&c.o.metrics, resp)
+	if !c.o.isAcceptedStatusCode(resp.StatusCode) {
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
 	}
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	if len(respBody) == 0 && resp.StatusCode != http.StatusOK {
+		return nil
+	}
 	var respData colmetricpb.ExportMetricsServiceResponse
-	switch resp.Header.Get("Content-Type") {
+	switch parseMediaType(resp.Header.Get("Content-Type")) {
 	case "application/x-protobuf":
 		if err := proto.Unmarshal(respBody, &respData); err != nil {
 			return fmt.Errorf("failed to unmarshal response: %w", err)
@@ -395,13 +477,23 @@ func (c *Client) uploadMetricsWithHTTP(ctx context.Context, protoMetrics []*Reso
 }
 
 func (c *Client) UploadLogs(ctx context.Context, protoLogs []*ResourceLogs) error {
+	if c.o.logs.disabled {
+		return nil
+	}
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-
-	if c.o.logs.isGRPCProtocol() {
-		return c.uploadLogsWithGRPC(ctx, protoLogs)
-	}
-	return c.uploadLogsWithHTTP(ctx, protoLogs)
+	defer c.inflight.begin()()
+
+	return c.doWithRetry(ctx, "logs", TotalLogRecords(protoLogs), func(ctx context.Context) error {
+		switch {
+		case c.o.logs.isGRPCProtocol():
+			return c.uploadLogsWithGRPC(ctx, protoLogs)
+		case c.o.logs.isWebSocketProtocol():
+			return c.uploadLogsWithWebSocket(ctx, protoLogs)
+		default:
+			return c.uploadLogsWithHTTP(ctx, protoLogs)
+		}
+	})
 }
 
 type UploadLogsPartialSuccessError struct {
@@ -443,10 +535,12 @@ func (c *Client) uploadLogsWithGRPC(ctx context.Context, protoLogs []*ResourceLo
 	serviceClient := collogspb.NewLogsServiceClient(conn)
 	ctx, cancel := c.newGRPCContext(ctx, &c.o.logs)
 	defer cancel()
+	callOpts, observe := c.grpcResponseCallOptions(&c.o.logs)
 	c.o.logger.InfoContext(ctx, "uploading logs with gRPC", "conn_hash", connHash[0:8], "num_resource_logs", len(protoLogs))
 	resp, err := serviceClient.Export(ctx, &collogspb.ExportLogsServiceRequest{
 		ResourceLogs: protoLogs,
-	})
+	}, callOpts...)
+	observe(err)
 	if err != nil && status.Code(err) != codes.OK {
 		return err
 	}
@@ -475,15 +569,19 @@ func (c *Client) uploadLogsWithHTTP(ctx context.Context, protoLogs []*ResourceLo
 			c.o.logger.WarnContext(ctx, "failed to close response body", "details", err)
 		}
 	}()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	c.observeHTTPResponse(&c.o.logs, resp)
+	if !c.o.isAcceptedStatusCode(resp.StatusCode) {
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
 	}
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
+	if len(respBody) == 0 && resp.StatusCode != http.StatusOK {
+		return nil
+	}
 	var respData collogspb.ExportLogsServiceResponse
-	switch resp.Header.Get("Content-Type") {
+	switch parseMediaType(resp.Header.Get("Content-Type")) {
 	case "application/x-protobuf":
 		if err := proto.Unmarshal(respBody, &respData); err != nil {
 			return fmt.Errorf("failed to unmarshal response: %w", err)
@@ -509,6 +607,7 @@ func (c *Client) Stop(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
+		c.abortExports()
 		for _, stopFunc := range c.stopFuncs {
 			stopFunc()
 		}
@@ -518,13 +617,29 @@ func (c *Client) Stop(ctx context.Context) error {
 	case <-acquired:
 	}
 	defer c.mu.Unlock()
+	dropped := c.takeDropped()
+	if dropped != nil {
+		dropped.Err = err
+		err = dropped
+	}
+	var colseErrs []error
+	for signalType, wc := range c.wsConns {
+		if closeErr := wc.close(); closeErr != nil {
+			colseErrs = append(colseErrs, fmt.Errorf("close %s websocket: %w", signalType, closeErr))
+		}
+	}
 	if len(c.conns) == 0 {
+		if len(colseErrs) > 0 {
+			return errors.Join(colseErrs...)
+		}
+		if dropped != nil {
+			return err
+		}
 		if c.o.maxGRPCConns() == 0 {
 			return nil
 		}
 		return ErrAlreadyClosed
 	}
-	var colseErrs []error
 	for connHash, conn := range c.conns {
 		if conn == nil {
 			continue
@@ -546,3 +661,52 @@ func (c *Client) Stop(ctx context.Context) error {
 	c.stopContexts = make(map[string]context.Context, 3)
 	return err
 }
+
+// Export uploads the export request and returns the response, dispatching by the request type.
+// req must be *TraceRequest, *MetricsRequest, *LogsRequest or *ProfilesRequest, see UploadProfiles for how profiles are sent.
+// on partial success, the response is returned together with the partial success error.
+func (c *Client) Export(ctx context.Context, req proto.Message) (proto.Message, error) {
+	switch req := req.(type) {
+	case *TraceRequest:
+		err := c.UploadTraces(ctx, req.GetResourceSpans())
+		var psErr *UploadTracesPartialSuccessError
+		if errors.As(err, &psErr) {
+			return psErr.Response(), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &TraceResponse{}, nil
+	case *MetricsRequest:
+		err := c.UploadMetrics(ctx, req.GetResourceMetrics())
+		var psErr *UploadMetricsPartialSuccessError
+		if errors.As(err, &psErr) {
+			return psErr.Response(), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &MetricsResponse{}, nil
+	case *LogsRequest:
+		err := c.UploadLogs(ctx, req.GetResourceLogs())
+		var psErr *UploadLogsPartialSuccessError
+		if errors.As(err, &psErr) {
+			return psErr.Response(), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &LogsResponse{}, nil
+	case *ProfilesRequest:
+		err := c.UploadProfiles(ctx, req.GetResourceProfiles())
+		var psErr *UploadProfilesPartialSuccessError
+		if errors.As(err, &psErr) {
+			return psErr.Response(), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &ProfilesResponse{}, nil
+	}
+	return nil, fmt.Errorf("unsupported request type %T", req)
+}
@@ -0,0 +1,216 @@
+package otlp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clientConfig is the schema of the config file read by ClientOptionsFromConfig.
+type clientConfig struct {
+	clientSignalConfig `yaml:",inline"`
+	Traces             *clientSignalConfig `yaml:"traces"`
+	Metrics            *clientSignalConfig `yaml:"metrics"`
+	Logs               *clientSignalConfig `yaml:"logs"`
+}
+
+type clientSignalConfig struct {
+	Endpoint          configString  `yaml:"endpoint"`
+	Protocol          configString  `yaml:"protocol"`
+	Timeout           configString  `yaml:"timeout"`
+	Headers           configHeaders `yaml:"headers"`
+	Certificate       configString  `yaml:"certificate"`
+	ClientCertificate configString  `yaml:"client_certificate"`
+	ClientKey         configString  `yaml:"client_key"`
+}
+
+// configString accepts any scalar, so that timeout: 10000 and timeout: 10s are both read as is.
+type configString string
+
+func (s *configString) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a scalar value", node.Line)
+	}
+	*s = configString(node.Value)
+	return nil
+}
+
+// configHeaders accepts a mapping, or a string in the same format as OTEL_EXPORTER_OTLP_HEADERS.
+type configHeaders map[string]string
+
+func (h *configHeaders) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return nil
+		}
+		headers, err := parseHeadersString(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*h = headers
+		return nil
+	case yaml.MappingNode:
+		var headers map[string]string
+		if err := node.Decode(&headers); err != nil {
+			return err
+		}
+		*h = headers
+		return nil
+	}
+	return fmt.Errorf("line %d: headers must be a mapping or a string", node.Line)
+}
+
+var configSignalOptions = map[string]struct {
+	headers   func(map[string]string) ClientOption
+	tlsConfig func(*tls.Config) ClientOption
+}{
+	"":        {WithHeaders, WithTLSConfig},
+	"traces":  {WithTracesHeaders, WithTracesTLSConfig},
+	"metrics": {WithMetricsHeaders, WithMetricsTLSConfig},
+	"logs":    {WithLogsHeaders, WithLogsTLSConfig},
+}
+
+func (c *clientSignalConfig) apply(o *clientOptions, signalType string, dir string) error {
+	prefix := "OTLP_"
+	if signalType != "" {
+		prefix += strings.ToUpper(signalType) + "_"
+	}
+	for _, field := range []struct {
+		key   string
+		name  string
+		value configString
+	}{
+		{"endpoint", "ENDPOINT", c.Endpoint},
+		{"protocol", "PROTOCOL", c.Protocol},
+		{"timeout", "TIMEOUT", c.Timeout},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if err := envSetters[prefix+field.name](o)(string(field.value)); err != nil {
+			return fmt.Errorf("%s: %w", configKey(signalType, field.key), err)
+		}
+	}
+	options := configSignalOptions[signalType]
+	if c.Headers != nil {
+		if err := options.headers(c.Headers)(o); err != nil {
+			return fmt.Errorf("%s: %w", configKey(signalType, "headers"), err)
+		}
+	}
+	if c.Certificate == "" && c.ClientCertificate == "" && c.ClientKey == "" {
+		return nil
+	}
+	tlsConfig, err := loadTLSConfig(
+		resolveConfigPath(dir, string(c.Certificate)),
+		resolveConfigPath(dir, string(c.ClientCertificate)),
+		resolveConfigPath(dir, string(c.ClientKey)),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configKey(signalType, "certificate"), err)
+	}
+	return options.tlsConfig(tlsConfig)(o)
+}
+
+func configKey(signalType, key string) string {
+	if signalType == "" {
+		return key
+	}
+	return signalType + "." + key
+}
+
+// resolveConfigPath resolves the path relative to the directory of the config file.
+func resolveConfigPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// loadTLSConfig returns the tls config trusting the certificate, and presenting the client certificate if set.
+func loadTLSConfig(certificate, clientCertificate, clientKey string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if certificate != "" {
+		pem, err := os.ReadFile(certificate)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", certificate)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if (clientCertificate == "") != (clientKey == "") {
+		return nil, errors.New("client_certificate and client_key must be set together")
+	}
+	if clientCertificate != "" {
+		cert, err := tls.LoadX509KeyPair(clientCertificate, clientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// ClientOptionsFromConfig returns the client options from the YAML or JSON config file.
+// the keys are the same as the environment variables without the OTEL_EXPORTER_OTLP_ prefix, in lower case,
+// and the per-signal overrides are nested under traces, metrics and logs:
+//
+//	endpoint: http://localhost:4318
+//	protocol: http/protobuf
+//	timeout: 10s
+//	headers:
+//	  Api-Key: xxx
+//	certificate: ca.pem
+//	traces:
+//	  endpoint: http://localhost:4318/v1/traces
+//
+// relative certificate paths are resolved from the directory of the config file. unknown keys are errors.
+// options are applied in order, so to layer the environment variables on top, put DefaultClientOptions after it.
+func ClientOptionsFromConfig(path string) ClientOption {
+	return func(o *clientOptions) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		defer f.Close()
+		var cfg clientConfig
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+		dir := filepath.Dir(path)
+		if err := cfg.clientSignalConfig.apply(o, "", dir); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+		for _, signal := range []struct {
+			signalType string
+			cfg        *clientSignalConfig
+		}{
+			{"traces", cfg.Traces},
+			{"metrics", cfg.Metrics},
+			{"logs", cfg.Logs},
+		} {
+			if signal.cfg == nil {
+				continue
+			}
+			if err := signal.cfg.apply(o, signal.signalType, dir); err != nil {
+				return fmt.Errorf("config %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+}
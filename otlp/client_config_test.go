@@ -0,0 +1,154 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	path   string
+	header http.Header
+}
+
+func newRecordingServer(t *testing.T, tls bool) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []recordedRequest
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, recordedRequest{path: r.URL.Path, header: r.Header.Clone()})
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	})
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(handler)
+	} else {
+		server = httptest.NewServer(handler)
+	}
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest{}, requests...)
+	}
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func uploadTracesAndLogs(t *testing.T, client *otlp.Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+}
+
+func TestClientOptionsFromConfig_YAML(t *testing.T) {
+	server, requests := newRecordingServer(t, false)
+	path := writeConfig(t, "otlp.yaml", `
+endpoint: `+server.URL+`
+protocol: http/json
+timeout: 10000
+headers:
+  Api-Key: dummy
+traces:
+  endpoint: `+server.URL+`/custom/traces
+  headers: Api-Key=traces,X-Signal=traces
+`)
+	client, err := otlp.NewClient("http://localhost:4317", otlp.ClientOptionsFromConfig(path))
+	require.NoError(t, err)
+	uploadTracesAndLogs(t, client)
+
+	got := requests()
+	require.Len(t, got, 2)
+	require.Equal(t, "/custom/traces", got[0].path)
+	require.Equal(t, "traces", got[0].header.Get("Api-Key"))
+	require.Equal(t, "traces", got[0].header.Get("X-Signal"))
+	require.Equal(t, "/v1/logs", got[1].path)
+	require.Equal(t, "dummy", got[1].header.Get("Api-Key"))
+}
+
+func TestClientOptionsFromConfig_JSON(t *testing.T) {
+	server, requests := newRecordingServer(t, true)
+	dir := t.TempDir()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), caPEM, 0o644))
+	path := filepath.Join(dir, "otlp.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "endpoint": "`+server.URL+`",
+  "protocol": "http/protobuf",
+  "certificate": "ca.pem",
+  "logs": {"headers": {"Api-Key": "logs"}}
+}`), 0o644))
+	client, err := otlp.NewClient("http://localhost:4317", otlp.ClientOptionsFromConfig(path))
+	require.NoError(t, err)
+	uploadTracesAndLogs(t, client)
+
+	got := requests()
+	require.Len(t, got, 2)
+	require.Equal(t, "/v1/traces", got[0].path)
+	require.Equal(t, "application/x-protobuf", got[0].header.Get("Content-Type"))
+	require.Equal(t, "logs", got[1].header.Get("Api-Key"))
+}
+
+func TestClientOptionsFromConfig_EnvOverride(t *testing.T) {
+	server, requests := newRecordingServer(t, false)
+	path := writeConfig(t, "otlp.yaml", `
+endpoint: http://localhost:4317
+protocol: grpc
+headers: {Api-Key: config}
+`)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	client, err := otlp.NewClient(
+		"http://localhost:4317",
+		otlp.ClientOptionsFromConfig(path),
+		otlp.DefaultClientOptions("OTEL_EXPORTER_"),
+	)
+	require.NoError(t, err)
+	uploadTracesAndLogs(t, client)
+
+	got := requests()
+	require.Len(t, got, 2)
+	require.Equal(t, "config", got[0].header.Get("Api-Key"))
+}
+
+func TestClientOptionsFromConfig_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":      "endpont: http://localhost:4318\n",
+		"invalid protocol": "protocol: udp\n",
+		"invalid timeout":  "traces:\n  timeout: soon\n",
+		"invalid headers":  "headers: [a, b]\n",
+		"client key only":  "client_key: key.pem\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig(t, "otlp.yaml", content)
+			_, err := otlp.NewClient("http://localhost:4317", otlp.ClientOptionsFromConfig(path))
+			require.Error(t, err)
+		})
+	}
+	_, err := otlp.NewClient("http://localhost:4317", otlp.ClientOptionsFromConfig(filepath.Join(t.TempDir(), "missing.yaml")))
+	require.Error(t, err)
+}
@@ -2,6 +2,8 @@ package otlp
 
 import (
 	"crypto/sha512"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -11,39 +13,78 @@ import (
 	"os"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/stats"
 )
 
 type clientOptions struct {
-	logger        *slog.Logger
-	endpoint      *url.URL
-	protocol      string
-	userAgent     string
-	headers       map[string]string
-	gzip          *bool
-	exportTimeout time.Duration
-	httpClient    *http.Client
+	logger         *slog.Logger
+	endpoint       *url.URL
+	endpointAsIs   bool
+	endpoints      []*url.URL
+	lbPolicy       string
+	grpcAuthority  string
+	grpcTarget     string
+	resolvers      []resolver.Builder
+	statsHandlers  []stats.Handler
+	protocol       string
+	userAgent      string
+	uaSuffix       string
+	headers        map[string]string
+	gzip           *bool
+	compression    string
+	compressors    map[string]Compressor
+	exportTimeout  time.Duration
+	httpClient     *http.Client
+	tlsConfig      *tls.Config
+	dnsCacheTTL    time.Duration
+	dnsCache       *dnsCache
+	acceptedCodes  []int
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	onResponse     ResponseCallback
+	onCanceled     func(err *CanceledError)
+	strictEnv      bool
+	retry          RetryConfig
+	deprecatedEnv  []deprecatedEnvUsage
 
-	traces  clientSignalsOptions
-	metrics clientSignalsOptions
-	logs    clientSignalsOptions
+	traces   clientSignalsOptions
+	metrics  clientSignalsOptions
+	logs     clientSignalsOptions
+	profiles clientSignalsOptions
 }
 
 type clientSignalsOptions struct {
 	gzip          *bool
+	compression   string
+	compressor    Compressor
 	userAgent     string
 	signalType    string
 	endpoint      *url.URL
+	endpoints     []*url.URL
+	lbPolicy      string
+	grpcAuthority string
+	grpcTarget    string
+	resolvers     []resolver.Builder
+	statsHandlers []stats.Handler
 	protocol      string
 	exportTimeout time.Duration
 	headers       map[string]string
 	httpClient    *http.Client
+	tlsConfig     *tls.Config
+	propagator    propagation.TextMapPropagator
+	disabled      bool
 
 	mu          sync.Mutex
 	target      string
@@ -72,36 +113,96 @@ var allowedProtocols = []string{
 	"http/protobuf",
 }
 
-// AllowedProtocols is the list of allowed protocol values.
+// AllowedProtocols is the list of allowed protocol values of the OTLP specification, accepted by the environment variables and the flags.
 var AllowedProtocols = allowedProtocols
 
+// clientProtocols are the protocols accepted by WithProtocol, the spec ones and ws, which is not an OTLP transport.
+var clientProtocols = append(slices.Clone(allowedProtocols), "ws")
+
+// checkAllowedProtocol returns an error if the protocol of the environment variables or the flags is not one of AllowedProtocols, e.g. ws.
+func checkAllowedProtocol(protocol string) error {
+	if !slices.Contains(allowedProtocols, protocol) {
+		return fmt.Errorf("protocol %q is not allowed, must be one of %s", protocol, strings.Join(allowedProtocols, ", "))
+	}
+	return nil
+}
+
 func (so *clientSignalsOptions) fillDefaults(o *clientOptions) error {
 	if so.userAgent == "" {
 		so.userAgent = o.userAgent
 	}
+	// build may run more than once with the nested options, e.g. ClientOptionsWithFlags.
+	if o.uaSuffix != "" && !strings.HasSuffix(so.userAgent, " "+o.uaSuffix) {
+		so.userAgent += " " + o.uaSuffix
+	}
 	if so.protocol == "" {
 		so.protocol = o.protocol
 	}
-	if !slices.Contains(allowedProtocols, so.protocol) {
+	if !slices.Contains(clientProtocols, so.protocol) {
 		return fmt.Errorf("protocol %q is not allowed", so.protocol)
 	}
 	if so.gzip == nil {
 		so.gzip = o.gzip
 	}
+	if so.compression == "" {
+		so.compression = o.compression
+	}
+	if so.compression == "" && *so.gzip {
+		so.compression = "gzip"
+	}
+	if so.compression != "" && so.isWebSocketProtocol() {
+		return fmt.Errorf("%s compression %q is not supported over WebSocket", so.signalType, so.compression)
+	}
+	if so.compression != "" {
+		compressor, err := o.lookupCompressor(so.compression, so.isGRPCProtocol())
+		if err != nil {
+			return fmt.Errorf("%s compression: %w", so.signalType, err)
+		}
+		so.compressor = compressor
+	}
 	if so.exportTimeout == 0 {
 		so.exportTimeout = o.exportTimeout
 	}
 	if so.httpClient == nil {
 		so.httpClient = o.httpClient
 	}
+	if so.tlsConfig == nil {
+		so.tlsConfig = o.tlsConfig
+	}
+	if so.tlsConfig != nil && !so.isGRPCProtocol() {
+		httpClient, err := httpClientWithTLSConfig(so.httpClient, so.tlsConfig)
+		if err != nil {
+			return fmt.Errorf("%s tls config: %w", so.signalType, err)
+		}
+		so.httpClient = httpClient
+	}
+	if o.dnsCache != nil && !so.isGRPCProtocol() {
+		httpClient, err := httpClientWithDNSCache(so.httpClient, o.dnsCache)
+		if err != nil {
+			return fmt.Errorf("%s dns cache: %w", so.signalType, err)
+		}
+		so.httpClient = httpClient
+	}
 	if so.endpoint == nil {
-		if strings.HasPrefix(so.protocol, "http/") {
-			so.endpoint = o.endpoint.JoinPath("v1/" + so.signalType)
+		if strings.HasPrefix(so.protocol, "http/") && o.endpoint != nil && !o.endpointAsIs {
+			so.endpoint = o.endpoint.JoinPath(so.httpPath())
+		} else if so.isWebSocketProtocol() && o.endpoint != nil && !o.endpointAsIs {
+			so.endpoint = o.endpoint.JoinPath(webSocketPath)
 		} else {
 			so.endpoint = o.endpoint
+			so.endpoints = o.endpoints
 		}
 	}
+	so.lbPolicy = o.lbPolicy
+	so.propagator = o.propagator
+	so.grpcAuthority = o.grpcAuthority
+	so.grpcTarget = o.grpcTarget
+	so.resolvers = o.resolvers
+	so.statsHandlers = o.statsHandlers
 	if so.endpoint == nil {
+		if so.disabled {
+			return nil
+		}
 		return fmt.Errorf("%s endpoint is required", so.signalType)
 	}
 	if so.headers == nil {
@@ -115,6 +216,36 @@ func (so *clientSignalsOptions) fillDefaults(o *clientOptions) error {
 	return nil
 }
 
+// httpClientWithTLSConfig returns a copy of the http client whose transport uses the tls config.
+func httpClientWithTLSConfig(httpClient *http.Client, tlsConfig *tls.Config) (*http.Client, error) {
+	return httpClientWithTransport(httpClient, "tls config", func(transport *http.Transport) {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	})
+}
+
+// httpClientWithDNSCache returns a copy of the http client whose transport dials with the dns cache.
+func httpClientWithDNSCache(httpClient *http.Client, cache *dnsCache) (*http.Client, error) {
+	return httpClientWithTransport(httpClient, "dns cache", func(transport *http.Transport) {
+		transport.DialContext = cache.DialContext
+	})
+}
+
+func httpClientWithTransport(httpClient *http.Client, feature string, configure func(*http.Transport)) (*http.Client, error) {
+	var transport *http.Transport
+	switch t := httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("http client transport %T does not support %s", t, feature)
+	}
+	configure(transport)
+	cloned := *httpClient
+	cloned.Transport = transport
+	return &cloned, nil
+}
+
 func (o *clientOptions) build() error {
 	if o.logger == nil {
 		o.logger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{
@@ -122,6 +253,10 @@ func (o *clientOptions) build() error {
 		}))
 	}
 	o.logger = o.logger.With("module", "otlp-client")
+	for _, u := range o.deprecatedEnv {
+		o.logger.Warn("deprecated environment variable is used", "name", u.name, "replacement", u.replacement)
+	}
+	o.deprecatedEnv = nil
 	if o.userAgent == "" {
 		o.userAgent = fmt.Sprintf(
 			"go-otlp-helper/%s (github.com/mashiike/go-otlp-helper/otlp.Client) go/%s",
@@ -138,6 +273,9 @@ func (o *clientOptions) build() error {
 	if o.httpClient == nil {
 		o.httpClient = http.DefaultClient
 	}
+	if o.dnsCacheTTL > 0 {
+		o.dnsCache = newDNSCache(o.dnsCacheTTL)
+	}
 	o.traces.signalType = "traces"
 	if err := o.traces.fillDefaults(o); err != nil {
 		return err
@@ -150,9 +288,40 @@ func (o *clientOptions) build() error {
 	if err := o.logs.fillDefaults(o); err != nil {
 		return err
 	}
+	o.profiles.signalType = "profiles"
+	// profiles have no environment variables, so they need WithProfilesEndpoint or the shared endpoint.
+	if o.profiles.endpoint != nil || o.endpoint != nil {
+		if err := o.profiles.fillDefaults(o); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// httpPath returns the path of the signal appended to the shared endpoint, e.g. v1/traces. profiles are in development.
+func (so *clientSignalsOptions) httpPath() string {
+	if so.signalType == "profiles" {
+		return "v1development/profiles"
+	}
+	return "v1/" + so.signalType
+}
+
+func (so *clientSignalsOptions) logAttrs() []any {
+	if so.disabled {
+		return []any{"disabled", true}
+	}
+	if so.endpoint == nil {
+		return []any{"endpoint", ""}
+	}
+	return []any{
+		"protocol", so.protocol,
+		"endpoint", so.endpoint.String(),
+		"address", so.endpoint.Host,
+		"insecure", so.endpoint.Scheme != "https",
+		"timeout", so.exportTimeout,
+	}
+}
+
 func (so *clientSignalsOptions) isGRPCProtocol() bool {
 	return so.protocol == "grpc"
 }
@@ -161,6 +330,10 @@ func (so *clientSignalsOptions) isHTTPProtocol() bool {
 	return strings.HasPrefix(so.protocol, "http/")
 }
 
+func (so *clientSignalsOptions) isWebSocketProtocol() bool {
+	return so.protocol == "ws"
+}
+
 func (so *clientSignalsOptions) httpContentType() string {
 	if !so.isHTTPProtocol() {
 		return ""
@@ -177,13 +350,16 @@ func (so *clientSignalsOptions) httpContentType() string {
 
 func (so *clientOptions) maxGRPCConns() int {
 	var maxConns int
-	if so.traces.isGRPCProtocol() {
+	if so.traces.isGRPCProtocol() && !so.traces.disabled {
 		maxConns++
 	}
-	if so.metrics.isGRPCProtocol() {
+	if so.metrics.isGRPCProtocol() && !so.metrics.disabled {
 		maxConns++
 	}
-	if so.logs.isGRPCProtocol() {
+	if so.logs.isGRPCProtocol() && !so.logs.disabled {
+		maxConns++
+	}
+	if so.profiles.isGRPCProtocol() && !so.profiles.disabled {
 		maxConns++
 	}
 	return maxConns
@@ -206,19 +382,64 @@ func (so *clientSignalsOptions) buildGRPCConnectionInfo() (string, []grpc.DialOp
 		grpc.WithUserAgent(so.userAgent),
 	}
 	haser.Write([]byte(so.userAgent))
+	target := so.endpoint.Host
+	policy := so.lbPolicy
+	switch {
+	case so.grpcTarget != "":
+		target = so.grpcTarget
+		haser.Write([]byte(target))
+		if len(so.resolvers) > 0 {
+			opts = append(opts, grpc.WithResolvers(so.resolvers...))
+		}
+		for _, r := range so.resolvers {
+			haser.Write([]byte(fmt.Sprintf("%T:%s", r, r.Scheme())))
+		}
+	case len(so.endpoints) > 1:
+		addrs := make([]resolver.Address, 0, len(so.endpoints))
+		for _, u := range so.endpoints[1:] {
+			haser.Write([]byte(u.Host))
+		}
+		for _, u := range so.endpoints {
+			addrs = append(addrs, resolver.Address{Addr: u.Host, ServerName: u.Hostname()})
+		}
+		r := manual.NewBuilderWithScheme("otlp-endpoints")
+		r.InitialState(resolver.State{Addresses: addrs})
+		opts = append(opts, grpc.WithResolvers(r))
+		target = r.Scheme() + ":///" + so.endpoint.Host
+		if policy == "" {
+			policy = "pick_first"
+		}
+	}
+	if policy != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, policy)))
+		haser.Write([]byte(policy))
+	}
+	if so.grpcAuthority != "" {
+		opts = append(opts, grpc.WithAuthority(so.grpcAuthority))
+		haser.Write([]byte("authority:" + so.grpcAuthority))
+	}
 	if so.endpoint.Scheme != "https" {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 		haser.Write([]byte("insecure"))
 	} else {
-		cred := credentials.NewTLS(nil)
+		cred := credentials.NewTLS(so.tlsConfig)
 		opts = append(opts, grpc.WithTransportCredentials(cred))
 		haser.Write([]byte("tls"))
+		if so.tlsConfig != nil {
+			// different tls configs must not share the connection.
+			haser.Write([]byte(fmt.Sprintf("%p", so.tlsConfig)))
+		}
+	}
+	if so.compression != "" {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(so.compression)))
+		haser.Write([]byte(so.compression))
 	}
-	if *so.gzip {
-		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor("gzip")))
-		haser.Write([]byte("gzip"))
+	for _, h := range so.statsHandlers {
+		opts = append(opts, grpc.WithStatsHandler(h))
+		// different stats handlers must not share the connection.
+		haser.Write([]byte(fmt.Sprintf("stats:%T:%p", h, h)))
 	}
-	return so.endpoint.Host, opts, fmt.Sprintf("%x", haser.Sum(nil))
+	return target, opts, fmt.Sprintf("%x", haser.Sum(nil))
 }
 
 // WithUserAgent sets the user agent to be sent with the request.
@@ -229,6 +450,23 @@ func WithUserAgent(userAgent string) ClientOption {
 	}
 }
 
+// WithUserAgentSuffix appends the suffix to the user agent of all signals, e.g. "myapp/1.2.3",
+// keeping the default user agent, or the one set by WithUserAgent, for both HTTP and gRPC.
+// the suffixes of the multiple calls are appended in order.
+func WithUserAgentSuffix(suffix string) ClientOption {
+	return func(o *clientOptions) error {
+		suffix = strings.TrimSpace(suffix)
+		if suffix == "" {
+			return nil
+		}
+		if o.uaSuffix != "" {
+			suffix = o.uaSuffix + " " + suffix
+		}
+		o.uaSuffix = suffix
+		return nil
+	}
+}
+
 // WithTracesUserAgent sets the user agent to be sent with the trace request. by default, the user agent is shared with all signals.
 func WithTracesUserAgent(userAgent string) ClientOption {
 	return func(o *clientOptions) error {
@@ -317,6 +555,14 @@ func WithLogsHeaders(headers map[string]string) ClientOption {
 	}
 }
 
+// WithProfilesHeaders sets the headers to be sent with the profiles request. by default, the headers are shared with all signals.
+func WithProfilesHeaders(headers map[string]string) ClientOption {
+	return func(o *clientOptions) error {
+		o.profiles.headers = headers
+		return nil
+	}
+}
+
 func parseHeadersString(headers string) (map[string]string, error) {
 	parts := strings.Split(headers, ",")
 	h := make(map[string]string, len(parts))
@@ -374,10 +620,12 @@ func WithLogsHeadersString(headers string) ClientOption {
 	}
 }
 
-// WithProtocol sets the protocol to be used with the request.
+// WithProtocol sets the protocol to be used with the request, one of AllowedProtocols or ws.
+// ws sends the requests over a WebSocket connection, see WebSocketHandler. it is not an OTLP transport, so it is not accepted by the environment variables,
+// and the compression, e.g. WithGzip or WithCompressor, is not supported with it.
 func WithProtocol(protocol string) ClientOption {
 	return func(o *clientOptions) error {
-		if !slices.Contains(allowedProtocols, protocol) {
+		if !slices.Contains(clientProtocols, protocol) {
 			return fmt.Errorf("protocol %q is not allowed", protocol)
 		}
 		o.protocol = protocol
@@ -388,7 +636,7 @@ func WithProtocol(protocol string) ClientOption {
 // WithTracesProtocol sets the protocol to be used with the trace request. by default, the protocol is shared with all signals.
 func WithTracesProtocol(protocol string) ClientOption {
 	return func(o *clientOptions) error {
-		if !slices.Contains(allowedProtocols, protocol) {
+		if !slices.Contains(clientProtocols, protocol) {
 			return fmt.Errorf("traces protocol %q is not allowed", protocol)
 		}
 		o.traces.protocol = protocol
@@ -399,7 +647,7 @@ func WithTracesProtocol(protocol string) ClientOption {
 // WithMetricsProtocol sets the protocol to be used with the metrics request. by default, the protocol is shared with all signals.
 func WithMetricsProtocol(protocol string) ClientOption {
 	return func(o *clientOptions) error {
-		if !slices.Contains(allowedProtocols, protocol) {
+		if !slices.Contains(clientProtocols, protocol) {
 			return fmt.Errorf("metrics protocol %q is not allowed", protocol)
 		}
 		o.metrics.protocol = protocol
@@ -410,7 +658,7 @@ func WithMetricsProtocol(protocol string) ClientOption {
 // WithLogsProtocol sets the protocol to be used with the log request. by default, the protocol is shared with all signals.
 func WithLogsProtocol(protocol string) ClientOption {
 	return func(o *clientOptions) error {
-		if !slices.Contains(allowedProtocols, protocol) {
+		if !slices.Contains(clientProtocols, protocol) {
 			return fmt.Errorf("logs protocol %q is not allowed", protocol)
 		}
 		o.logs.protocol = protocol
@@ -450,6 +698,47 @@ func WithLogsExportTimeout(exportTimeout time.Duration) ClientOption {
 	}
 }
 
+// WithProfilesExportTimeout sets the timeout to be used with the profiles request. by default, the timeout is shared with all signals.
+func WithProfilesExportTimeout(exportTimeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		o.profiles.exportTimeout = exportTimeout
+		return nil
+	}
+}
+
+// WithTracesDisabled disables the traces, UploadTraces does nothing and returns nil.
+// it is useful to turn off a signal of a client configured from the shared environment variables, without a dummy endpoint.
+func WithTracesDisabled() ClientOption {
+	return func(o *clientOptions) error {
+		o.traces.disabled = true
+		return nil
+	}
+}
+
+// WithMetricsDisabled disables the metrics, UploadMetrics does nothing and returns nil.
+func WithMetricsDisabled() ClientOption {
+	return func(o *clientOptions) error {
+		o.metrics.disabled = true
+		return nil
+	}
+}
+
+// WithLogsDisabled disables the logs, UploadLogs does nothing and returns nil.
+func WithLogsDisabled() ClientOption {
+	return func(o *clientOptions) error {
+		o.logs.disabled = true
+		return nil
+	}
+}
+
+// WithProfilesDisabled disables the profiles, UploadProfiles does nothing and returns nil.
+func WithProfilesDisabled() ClientOption {
+	return func(o *clientOptions) error {
+		o.profiles.disabled = true
+		return nil
+	}
+}
+
 func parseEndpoint(endpoint string) (*url.URL, error) {
 	u, err := url.Parse(endpoint)
 	if err != nil {
@@ -472,6 +761,102 @@ func WithEndpoint(endpoint string) ClientOption {
 			return fmt.Errorf("endpoint parse error: %w", err)
 		}
 		o.endpoint = u
+		o.endpointAsIs = false
+		o.endpoints = nil
+		return nil
+	}
+}
+
+// WithEndpointURL sets the endpoint to be used with the request.
+// unlike WithEndpoint, the path of the endpoint is used verbatim for all HTTP signals, /v1/{signal} is not appended.
+// e.g. WithEndpoint("https://gateway/otel") sends traces to https://gateway/otel/v1/traces, WithEndpointURL("https://gateway/otel") sends traces to https://gateway/otel.
+func WithEndpointURL(endpoint string) ClientOption {
+	return func(o *clientOptions) error {
+		u, err := parseEndpoint(endpoint)
+		if err != nil {
+			return fmt.Errorf("endpoint parse error: %w", err)
+		}
+		o.endpoint = u
+		o.endpointAsIs = true
+		o.endpoints = nil
+		return nil
+	}
+}
+
+// WithEndpoints sets multiple endpoints to be used with the gRPC request, so the client survives a single collector going down.
+// by default, the endpoints are tried in order and the first reachable one is used (failover), see WithLoadBalancingPolicy.
+// all endpoints must have the same scheme. HTTP signals use the first endpoint only.
+func WithEndpoints(endpoints ...string) ClientOption {
+	return func(o *clientOptions) error {
+		if len(endpoints) == 0 {
+			return errors.New("at least one endpoint is required")
+		}
+		urls := make([]*url.URL, 0, len(endpoints))
+		for _, endpoint := range endpoints {
+			u, err := parseEndpoint(endpoint)
+			if err != nil {
+				return fmt.Errorf("endpoint parse error: %w", err)
+			}
+			if len(urls) > 0 && u.Scheme != urls[0].Scheme {
+				return fmt.Errorf("endpoint %q: all endpoints must have the same scheme", endpoint)
+			}
+			urls = append(urls, u)
+		}
+		o.endpoint = urls[0]
+		o.endpointAsIs = false
+		o.endpoints = urls
+		return nil
+	}
+}
+
+var allowedLoadBalancingPolicies = []string{
+	"pick_first",
+	"round_robin",
+}
+
+// WithLoadBalancingPolicy sets how the gRPC requests are distributed to the endpoints set by WithEndpoints.
+// "pick_first" (default) fails over to the next endpoint when the current one is down, "round_robin" spreads the requests across all endpoints.
+func WithLoadBalancingPolicy(policy string) ClientOption {
+	return func(o *clientOptions) error {
+		if !slices.Contains(allowedLoadBalancingPolicies, policy) {
+			return fmt.Errorf("load balancing policy %q is not allowed", policy)
+		}
+		o.lbPolicy = policy
+		return nil
+	}
+}
+
+// WithGRPCAuthority sets the :authority header of the gRPC request, e.g. to route the request through a service mesh.
+// by default, the authority is derived from the dial target.
+func WithGRPCAuthority(authority string) ClientOption {
+	return func(o *clientOptions) error {
+		o.grpcAuthority = authority
+		return nil
+	}
+}
+
+// WithResolver sets the gRPC dial target, e.g. dns:///collector:4317 or xds:///collector, instead of host:port derived from the endpoint.
+// builders are the resolvers for custom naming schemes, the schemes registered globally (dns, passthrough, xds if imported) are always available.
+// the endpoint is still used to decide whether TLS is used.
+func WithResolver(target string, builders ...resolver.Builder) ClientOption {
+	return func(o *clientOptions) error {
+		if target == "" {
+			return errors.New("resolver target is required")
+		}
+		o.grpcTarget = target
+		o.resolvers = builders
+		return nil
+	}
+}
+
+// WithGRPCStatsHandler adds the stats handler to the gRPC connections, e.g. otelgrpc.NewClientHandler() to observe the exports with the standard gRPC instrumentation.
+// it can be specified multiple times; the handlers are called in order.
+func WithGRPCStatsHandler(h stats.Handler) ClientOption {
+	return func(o *clientOptions) error {
+		if h == nil {
+			return errors.New("grpc stats handler is nil")
+		}
+		o.statsHandlers = append(o.statsHandlers, h)
 		return nil
 	}
 }
@@ -512,6 +897,19 @@ func WithLogsEndpoint(endpoint string) ClientOption {
 	}
 }
 
+// WithProfilesEndpoint sets the endpoint to be used with the profiles request, used verbatim like WithTracesEndpoint.
+// by default, the profiles are sent to the shared endpoint, at /v1development/profiles over HTTP.
+func WithProfilesEndpoint(endpoint string) ClientOption {
+	return func(o *clientOptions) error {
+		u, err := parseEndpoint(endpoint)
+		if err != nil {
+			return fmt.Errorf("profiles endpoint parse error: %w", err)
+		}
+		o.profiles.endpoint = u
+		return nil
+	}
+}
+
 // WithHTTPClient sets the http client to be used with the request.
 func WithHTTPClient(httpClient *http.Client) ClientOption {
 	return func(o *clientOptions) error {
@@ -544,47 +942,174 @@ func WithLogsHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
-func lookupEnvValue(name string, envPrefixes []string, setter func(string) error) error {
+// WithDNSCache caches the addresses of the endpoint hosts for ttl, instead of resolving them for each new connection of the HTTP transport.
+// when none of the cached addresses can be dialed, the host is resolved again, so a shorter ttl makes failover to new collector addresses faster.
+// the cache is shared with all signals. it does not apply to the grpc protocol, which has its own resolver, see WithResolver.
+func WithDNSCache(ttl time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		if ttl <= 0 {
+			return errors.New("dns cache ttl must be positive")
+		}
+		o.dnsCacheTTL = ttl
+		return nil
+	}
+}
+
+// WithTLSConfig sets the tls config to be used with https endpoints.
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		o.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithTracesTLSConfig sets the tls config to be used with the trace request. by default, the tls config is shared with all signals.
+func WithTracesTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		o.traces.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithMetricsTLSConfig sets the tls config to be used with the metrics request. by default, the tls config is shared with all signals.
+func WithMetricsTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		o.metrics.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithLogsTLSConfig sets the tls config to be used with the log request. by default, the tls config is shared with all signals.
+func WithLogsTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		o.logs.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithAcceptedStatusCodes sets the HTTP status codes treated as success in addition to 200, e.g. 202 and 204 returned by some vendors.
+// the response body of the accepted status codes may be empty.
+func WithAcceptedStatusCodes(codes []int) ClientOption {
+	return func(o *clientOptions) error {
+		for _, code := range codes {
+			if code < 200 || code > 299 {
+				return fmt.Errorf("status code %d is not a success status code", code)
+			}
+		}
+		o.acceptedCodes = codes
+		return nil
+	}
+}
+
+func (o *clientOptions) isAcceptedStatusCode(code int) bool {
+	return code == http.StatusOK || slices.Contains(o.acceptedCodes, code)
+}
+
+func lookupEnvValue(name string, envPrefixes []string) (string, string, bool) {
 	upperName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
 	lowerName := strings.ToLower(strings.ReplaceAll(name, "-", "_"))
 	if len(envPrefixes) == 0 {
 		envPrefixes = []string{""}
 	}
 	for _, prefix := range envPrefixes {
-		if s, ok := os.LookupEnv(strings.ToUpper(prefix) + upperName); ok {
-			return setter(s)
-		}
-		if s, ok := os.LookupEnv(strings.ToUpper(prefix) + lowerName); ok {
-			return setter(s)
+		for _, key := range []string{
+			strings.ToUpper(prefix) + upperName,
+			strings.ToUpper(prefix) + lowerName,
+			prefix + upperName,
+			prefix + lowerName,
+		} {
+			if s, ok := os.LookupEnv(key); ok {
+				return key, s, true
+			}
 		}
-		if s, ok := os.LookupEnv(prefix + upperName); ok {
-			return setter(s)
+	}
+	return "", "", false
+}
+
+// deprecatedEnvNames maps historical environment variable names used by old SDKs to the current names.
+var deprecatedEnvNames = map[string]string{
+	"OTLP_SPAN_PROTOCOL":   "OTLP_TRACES_PROTOCOL",
+	"OTLP_SPAN_ENDPOINT":   "OTLP_TRACES_ENDPOINT",
+	"OTLP_SPAN_TIMEOUT":    "OTLP_TRACES_TIMEOUT",
+	"OTLP_SPAN_HEADERS":    "OTLP_TRACES_HEADERS",
+	"OTLP_METRIC_PROTOCOL": "OTLP_METRICS_PROTOCOL",
+	"OTLP_METRIC_ENDPOINT": "OTLP_METRICS_ENDPOINT",
+	"OTLP_METRIC_TIMEOUT":  "OTLP_METRICS_TIMEOUT",
+	"OTLP_METRIC_HEADERS":  "OTLP_METRICS_HEADERS",
+}
+
+type deprecatedEnvUsage struct {
+	name        string
+	replacement string
+}
+
+// lookupDeprecatedEnvValue looks up the deprecated names of the given environment variable name.
+// it returns the found key, the value and the key that should be used instead.
+func lookupDeprecatedEnvValue(name string, envPrefixes []string) (string, string, string, bool) {
+	deprecatedNames := make([]string, 0, 1)
+	for deprecated, current := range deprecatedEnvNames {
+		if current == name {
+			deprecatedNames = append(deprecatedNames, deprecated)
 		}
-		if s, ok := os.LookupEnv(prefix + lowerName); ok {
-			return setter(s)
+	}
+	slices.Sort(deprecatedNames)
+	for _, deprecated := range deprecatedNames {
+		if key, value, ok := lookupEnvValue(deprecated, envPrefixes); ok {
+			prefix := key[:len(key)-len(deprecated)]
+			return key, value, strings.ToUpper(prefix + name), true
 		}
 	}
-	return nil
+	return "", "", "", false
+}
+
+// EnvError is the error returned when an environment variable has an invalid value.
+type EnvError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("env %s=%q is invalid", e.Name, e.Value)
+	}
+	return fmt.Sprintf("env %s=%q is invalid: %s", e.Name, e.Value, e.Err.Error())
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
 }
 
 var envSetters = map[string]func(o *clientOptions) func(string) error{
 	"OTLP_PROTOCOL": func(o *clientOptions) func(string) error {
 		return func(s string) error {
+			if err := checkAllowedProtocol(s); err != nil {
+				return err
+			}
 			return WithProtocol(s)(o)
 		}
 	},
 	"OTLP_TRACES_PROTOCOL": func(o *clientOptions) func(string) error {
 		return func(s string) error {
+			if err := checkAllowedProtocol(s); err != nil {
+				return err
+			}
 			return WithTracesProtocol(s)(o)
 		}
 	},
 	"OTLP_METRICS_PROTOCOL": func(o *clientOptions) func(string) error {
 		return func(s string) error {
+			if err := checkAllowedProtocol(s); err != nil {
+				return err
+			}
 			return WithMetricsProtocol(s)(o)
 		}
 	},
 	"OTLP_LOGS_PROTOCOL": func(o *clientOptions) func(string) error {
 		return func(s string) error {
+			if err := checkAllowedProtocol(s); err != nil {
+				return err
+			}
 			return WithLogsProtocol(s)(o)
 		}
 	},
@@ -610,7 +1135,7 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 	},
 	"OTLP_TIMEOUT": func(o *clientOptions) func(string) error {
 		return func(s string) error {
-			d, err := time.ParseDuration(s)
+			d, err := parseTimeout(s)
 			if err != nil {
 				return fmt.Errorf("export timeout parse error: %w", err)
 			}
@@ -619,7 +1144,7 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 	},
 	"OTLP_TRACES_TIMEOUT": func(o *clientOptions) func(string) error {
 		return func(s string) error {
-			d, err := time.ParseDuration(s)
+			d, err := parseTimeout(s)
 			if err != nil {
 				return fmt.Errorf("traces export timeout parse error: %w", err)
 			}
@@ -628,7 +1153,7 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 	},
 	"OTLP_METRICS_TIMEOUT": func(o *clientOptions) func(string) error {
 		return func(s string) error {
-			d, err := time.ParseDuration(s)
+			d, err := parseTimeout(s)
 			if err != nil {
 				return fmt.Errorf("metrics export timeout parse error: %w", err)
 			}
@@ -637,7 +1162,7 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 	},
 	"OTLP_LOGS_TIMEOUT": func(o *clientOptions) func(string) error {
 		return func(s string) error {
-			d, err := time.ParseDuration(s)
+			d, err := parseTimeout(s)
 			if err != nil {
 				return fmt.Errorf("logs export timeout parse error: %w", err)
 			}
@@ -664,6 +1189,36 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 			return WithLogsHeadersString(s)(o)
 		}
 	},
+	"OTLP_TRACES_DISABLED": func(o *clientOptions) func(string) error {
+		return func(s string) error {
+			disabled, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("traces disabled parse error: %w", err)
+			}
+			o.traces.disabled = disabled
+			return nil
+		}
+	},
+	"OTLP_METRICS_DISABLED": func(o *clientOptions) func(string) error {
+		return func(s string) error {
+			disabled, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("metrics disabled parse error: %w", err)
+			}
+			o.metrics.disabled = disabled
+			return nil
+		}
+	},
+	"OTLP_LOGS_DISABLED": func(o *clientOptions) func(string) error {
+		return func(s string) error {
+			disabled, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("logs disabled parse error: %w", err)
+			}
+			o.logs.disabled = disabled
+			return nil
+		}
+	},
 }
 
 // DefaultClientOptions returns the default client options from the environment variables.
@@ -672,15 +1227,94 @@ var envSetters = map[string]func(o *clientOptions) func(string) error{
 // OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4317
 func DefaultClientOptions(envPrefixes ...string) ClientOption {
 	return func(o *clientOptions) error {
-		for name, setter := range envSetters {
-			if err := lookupEnvValue(name, envPrefixes, setter(o)); err != nil {
-				return err
+		var errs []error
+		for _, name := range envNames() {
+			key, value, ok := lookupEnvValue(name, envPrefixes)
+			if !ok {
+				var replacement string
+				key, value, replacement, ok = lookupDeprecatedEnvValue(name, envPrefixes)
+				if !ok {
+					continue
+				}
+				o.deprecatedEnv = append(o.deprecatedEnv, deprecatedEnvUsage{
+					name:        key,
+					replacement: replacement,
+				})
+			}
+			if err := envSetters[name](o)(value); err != nil {
+				envErr := &EnvError{Name: key, Value: value, Err: err}
+				if !o.strictEnv {
+					return envErr
+				}
+				errs = append(errs, envErr)
+			}
+		}
+		if o.strictEnv {
+			errs = append(errs, unknownEnvErrors(envPrefixes)...)
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func envNames() []string {
+	names := make([]string, 0, len(envSetters))
+	for name := range envSetters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// unknownEnvErrors reports environment variables that look like OTLP exporter settings but are not supported, e.g. typos.
+func unknownEnvErrors(envPrefixes []string) []error {
+	if len(envPrefixes) == 0 {
+		envPrefixes = []string{""}
+	}
+	var errs []error
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		for _, prefix := range envPrefixes {
+			otlpPrefix := strings.ToUpper(prefix) + "OTLP_"
+			if !strings.HasPrefix(strings.ToUpper(key), otlpPrefix) {
+				continue
 			}
+			name := strings.ToUpper(key)[len(strings.ToUpper(prefix)):]
+			_, known := envSetters[name]
+			if _, deprecated := deprecatedEnvNames[name]; !known && !deprecated {
+				errs = append(errs, &EnvError{Name: key, Value: value, Err: errors.New("unknown environment variable")})
+			}
+			break
 		}
+	}
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+	return errs
+}
+
+// WithStrictEnv enables strict environment parsing.
+// In strict mode, DefaultClientOptions and ClientOptionsWithFlagSet do not stop at the first invalid environment variable or flag,
+// but return an error listing every invalid one, and every unknown OTLP environment variable.
+// WithStrictEnv must be specified before DefaultClientOptions or ClientOptionsWithFlagSet.
+func WithStrictEnv() ClientOption {
+	return func(o *clientOptions) error {
+		o.strictEnv = true
 		return nil
 	}
 }
 
+// parseTimeout parses the OTLP timeout value.
+// a bare integer is treated as milliseconds as specified by OTEL_EXPORTER_OTLP_TIMEOUT, otherwise a Go duration string e.g. 5s is accepted.
+func parseTimeout(s string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if ms < 0 {
+			return 0, fmt.Errorf("timeout %q must not be negative", s)
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(s)
+}
+
 func flagEnvString(name string, envPrefixes []string) string {
 	names := make([]string, 0, len(envPrefixes))
 	if len(envPrefixes) == 0 {
@@ -705,7 +1339,7 @@ var flagUsages = map[string]string{
 	"OTLP_TRACES_ENDPOINT":  "OTLP traces endpoint to use, overrides --otlp-endpoint",
 	"OTLP_METRICS_ENDPOINT": "OTLP metrics endpoint to use, overrides --otlp-endpoint",
 	"OTLP_LOGS_ENDPOINT":    "OTLP logs endpoint to use, overrides --otlp-endpoint",
-	"OTLP_TIMEOUT":          "OTLP export timeout to use, e.g. 5s",
+	"OTLP_TIMEOUT":          "OTLP export timeout to use, milliseconds e.g. 10000 or duration e.g. 5s",
 	"OTLP_TRACES_TIMEOUT":   "OTLP traces export timeout to use, overrides --otlp-timeout",
 	"OTLP_METRICS_TIMEOUT":  "OTLP metrics export timeout to use, overrides --otlp-timeout",
 	"OTLP_LOGS_TIMEOUT":     "OTLP logs export timeout to use, overrides --otlp-timeout",
@@ -713,29 +1347,89 @@ var flagUsages = map[string]string{
 	"OTLP_TRACES_HEADERS":   "OTLP traces headers to use, append or override --otlp-headers",
 	"OTLP_METRICS_HEADERS":  "OTLP metrics headers to use, append or override --otlp-headers",
 	"OTLP_LOGS_HEADERS":     "OTLP logs headers to use, append or override --otlp-headers",
+	"OTLP_TRACES_DISABLED":  "disable OTLP traces export, true or false",
+	"OTLP_METRICS_DISABLED": "disable OTLP metrics export, true or false",
+	"OTLP_LOGS_DISABLED":    "disable OTLP logs export, true or false",
+}
+
+// ClientOptionFlag describes a command line flag for a client option.
+type ClientOptionFlag struct {
+	Name  string
+	Usage string
+}
+
+// ClientOptionFlags returns the flag definitions used by ClientOptionsWithFlagSet.
+// it is useful to bind the client options with other flag libraries e.g. kingpin, kong.
+func ClientOptionFlags(flagPrefix string, envPrefixes ...string) []ClientOptionFlag {
+	names := envNames()
+	flags := make([]ClientOptionFlag, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, ClientOptionFlag{
+			Name:  flagNameString(name, flagPrefix),
+			Usage: flagUsages[name] + flagEnvString(name, envPrefixes),
+		})
+	}
+	return flags
+}
+
+// FlagBinder is the interface to bind string flags. *flag.FlagSet and *pflag.FlagSet implement it.
+type FlagBinder interface {
+	StringVar(p *string, name string, value string, usage string)
 }
 
 // ClientOptionsWithFlagSet returns the client options from the flag set.
 func ClientOptionsWithFlagSet(fs *flag.FlagSet, flagPrefix string, envPrefixes ...string) ClientOption {
-	options := make([]ClientOption, 0, len(envSetters))
-	options = append(options, DefaultClientOptions(envPrefixes...))
-	for name, setter := range envSetters {
-		flagName := flagNameString(name, flagPrefix)
+	return ClientOptionsWithFlagBinder(fs, flagPrefix, envPrefixes...)
+}
+
+// ClientOptionsWithFlagBinder returns the client options from the flags bound by the FlagBinder.
+func ClientOptionsWithFlagBinder(fb FlagBinder, flagPrefix string, envPrefixes ...string) ClientOption {
+	values := make(map[string]*string, len(envSetters))
+	for _, f := range ClientOptionFlags(flagPrefix, envPrefixes...) {
 		var value string
-		usage := flagUsages[name] + flagEnvString(name, envPrefixes)
-		fs.StringVar(&value, flagName, "", usage)
-		options = append(options, func(o *clientOptions) error {
-			if value != "" {
-				return setter(o)(value)
-			}
-			return nil
-		})
+		fb.StringVar(&value, f.Name, "", f.Usage)
+		values[f.Name] = &value
+	}
+	options := []ClientOption{
+		DefaultClientOptions(envPrefixes...),
+		clientOptionsFromLookup(flagPrefix, func(name string) (string, bool) {
+			return *values[name], true
+		}),
 	}
 	return func(o *clientOptions) error {
 		return o.apply(options...)
 	}
 }
 
+// ClientOptionsFromLookup returns the client options from the lookup function.
+// lookup is called with the flag names without prefix, e.g. otlp-endpoint, otlp-traces-protocol.
+// empty values are ignored.
+// the errors are wrapped with the flag names, and all of them are joined with WithStrictEnv instead of returning the first one.
+func ClientOptionsFromLookup(lookup func(name string) (string, bool)) ClientOption {
+	return clientOptionsFromLookup("", lookup)
+}
+
+func clientOptionsFromLookup(flagPrefix string, lookup func(name string) (string, bool)) ClientOption {
+	return func(o *clientOptions) error {
+		var errs []error
+		for _, name := range envNames() {
+			flagName := flagNameString(name, flagPrefix)
+			value, ok := lookup(flagName)
+			if !ok || value == "" {
+				continue
+			}
+			if err := envSetters[name](o)(value); err != nil {
+				flagErr := fmt.Errorf("flag %s=%q is invalid: %w", flagName, value, err)
+				if !o.strictEnv {
+					return flagErr
+				}
+				errs = append(errs, flagErr)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
 // WithLogger sets the logger to be used with the request.
 func WithLogger(logger *slog.Logger) ClientOption {
 	return func(o *clientOptions) error {
@@ -0,0 +1,150 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	colprofilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
+	profilespb "go.opentelemetry.io/proto/otlp/profiles/v1experimental"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type ResourceProfiles = profilespb.ResourceProfiles
+
+var (
+	// ErrProfilesOverWebSocket is returned by UploadProfiles when the protocol is ws, which has no profiles route.
+	ErrProfilesOverWebSocket = errors.New("profiles are not supported over WebSocket")
+	// ErrProfilesEndpointRequired is returned by UploadProfiles when neither the shared endpoint nor WithProfilesEndpoint is set.
+	ErrProfilesEndpointRequired = errors.New("profiles endpoint is required")
+)
+
+// UploadProfiles uploads the profiles, in development.
+// over HTTP, they are sent to /v1development/profiles of the shared endpoint, e.g. http://localhost:4318/v1development/profiles,
+// or verbatim to the endpoint of WithEndpointURL or WithProfilesEndpoint.
+// it does nothing when profiles are disabled with WithProfilesDisabled.
+func (c *Client) UploadProfiles(ctx context.Context, protoProfiles []*ResourceProfiles) error {
+	if c.o.profiles.disabled {
+		return nil
+	}
+	if c.o.profiles.endpoint == nil {
+		return ErrProfilesEndpointRequired
+	}
+	if c.o.profiles.isWebSocketProtocol() {
+		return ErrProfilesOverWebSocket
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	defer c.inflight.begin()()
+	return c.doWithRetry(ctx, "profiles", TotalProfiles(protoProfiles), func(ctx context.Context) error {
+		if c.o.profiles.isGRPCProtocol() {
+			return c.uploadProfilesWithGRPC(ctx, protoProfiles)
+		}
+		return c.uploadProfilesWithHTTP(ctx, protoProfiles)
+	})
+}
+
+type UploadProfilesPartialSuccessError struct {
+	resp *colprofilespb.ExportProfilesServiceResponse
+}
+
+func (e *UploadProfilesPartialSuccessError) Response() *colprofilespb.ExportProfilesServiceResponse {
+	return e.resp
+}
+
+func (e *UploadProfilesPartialSuccessError) Error() string {
+	partialSuccess := e.resp.GetPartialSuccess()
+	msg := partialSuccess.GetErrorMessage()
+	n := partialSuccess.GetRejectedProfiles()
+	return fmt.Sprintf("failed to export %d profiles: %s", n, msg)
+}
+
+func errorCheckForUploadProfiles(resp *colprofilespb.ExportProfilesServiceResponse) error {
+	if resp == nil {
+		return nil
+	}
+	ps := resp.GetPartialSuccess()
+	if ps == nil {
+		return nil
+	}
+	if ps.GetRejectedProfiles() > 0 {
+		return &UploadProfilesPartialSuccessError{resp: resp}
+	}
+	return nil
+}
+
+func (c *Client) uploadProfilesWithGRPC(ctx context.Context, protoProfiles []*ResourceProfiles) error {
+	_, _, connHash := c.o.profiles.grpcConnectionInfo()
+	conn, ok := c.conns[connHash]
+	if !ok || conn == nil {
+		return ErrNotStarted
+	}
+
+	serviceClient := colprofilespb.NewProfilesServiceClient(conn)
+	ctx, cancel := c.newGRPCContext(ctx, &c.o.profiles)
+	defer cancel()
+	callOpts, observe := c.grpcResponseCallOptions(&c.o.profiles)
+
+	c.o.logger.InfoContext(ctx, "uploading profiles with gRPC", "conn_hash", connHash[0:8], "num_resource_profiles", len(protoProfiles))
+	resp, err := serviceClient.Export(ctx, &colprofilespb.ExportProfilesServiceRequest{
+		ResourceProfiles: protoProfiles,
+	}, callOpts...)
+	observe(err)
+	if err != nil && status.Code(err) != codes.OK {
+		return err
+	}
+	return errorCheckForUploadProfiles(resp)
+}
+
+func (c *Client) uploadProfilesWithHTTP(ctx context.Context, protoProfiles []*ResourceProfiles) error {
+	data := &colprofilespb.ExportProfilesServiceRequest{
+		ResourceProfiles: protoProfiles,
+	}
+	req, err := newHTTPRequest(ctx, &c.o.profiles, data)
+	if err != nil {
+		return err
+	}
+	client := c.o.profiles.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c.o.logger.InfoContext(ctx, "uploading profiles with HTTP", "endpoint", req.URL.String(), "num_resource_profiles", len(protoProfiles))
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.o.logger.WarnContext(ctx, "failed to close response body", "details", err)
+		}
+	}()
+	c.observeHTTPResponse(&c.o.profiles, resp)
+	if !c.o.isAcceptedStatusCode(resp.StatusCode) {
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(respBody) == 0 && resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var respData colprofilespb.ExportProfilesServiceResponse
+	switch parseMediaType(resp.Header.Get("Content-Type")) {
+	case "application/x-protobuf":
+		if err := proto.Unmarshal(respBody, &respData); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	case "application/json":
+		if err := UnmarshalJSON(respBody, &respData); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	default:
+		return fmt.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+	return errorCheckForUploadProfiles(&respData)
+}
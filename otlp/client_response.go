@@ -0,0 +1,50 @@
+package otlp
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ResponseCallback is called with the headers and the status of each export response.
+// for HTTP, header is the response header and status is the HTTP status code.
+// for gRPC, header is the header and trailer metadata, with canonical keys, and status is the gRPC status code.
+type ResponseCallback func(signal string, header http.Header, status int)
+
+// WithResponseCallback sets the callback to observe the export responses, e.g. rate limit headers, server versions and collector hints.
+// it is called for the failed responses too, but not for the ws protocol, whose headers are received once per connection.
+func WithResponseCallback(callback ResponseCallback) ClientOption {
+	return func(o *clientOptions) error {
+		o.onResponse = callback
+		return nil
+	}
+}
+
+func (c *Client) observeHTTPResponse(so *clientSignalsOptions, resp *http.Response) {
+	if c.o.onResponse == nil {
+		return
+	}
+	c.o.onResponse(so.signalType, resp.Header.Clone(), resp.StatusCode)
+}
+
+// grpcResponseCallOptions returns the call options capturing the response metadata, and the function to report it after the call.
+func (c *Client) grpcResponseCallOptions(so *clientSignalsOptions) ([]grpc.CallOption, func(error)) {
+	if c.o.onResponse == nil {
+		return nil, func(error) {}
+	}
+	var header, trailer metadata.MD
+	opts := []grpc.CallOption{grpc.Header(&header), grpc.Trailer(&trailer)}
+	return opts, func(err error) {
+		h := make(http.Header, len(header)+len(trailer))
+		for _, md := range []metadata.MD{header, trailer} {
+			for key, values := range md {
+				for _, value := range values {
+					h.Add(key, value)
+				}
+			}
+		}
+		c.o.onResponse(so.signalType, h, int(status.Code(err)))
+	}
+}
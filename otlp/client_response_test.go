@@ -0,0 +1,98 @@
+package otlp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+type observedResponse struct {
+	signal string
+	header http.Header
+	status int
+}
+
+func newResponseRecorder() (otlp.ResponseCallback, func() []observedResponse) {
+	var (
+		mu        sync.Mutex
+		responses []observedResponse
+	)
+	return func(signal string, header http.Header, status int) {
+			mu.Lock()
+			defer mu.Unlock()
+			responses = append(responses, observedResponse{signal: signal, header: header, status: status})
+		}, func() []observedResponse {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]observedResponse{}, responses...)
+		}
+}
+
+func TestClient_ResponseCallback_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "41")
+		if r.URL.Path == "/v1/logs" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	callback, responses := newResponseRecorder()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"), otlp.WithResponseCallback(callback))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Error(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+
+	got := responses()
+	require.Len(t, got, 2)
+	require.Equal(t, "traces", got[0].signal)
+	require.Equal(t, http.StatusOK, got[0].status)
+	require.Equal(t, "41", got[0].header.Get("X-Ratelimit-Remaining"))
+	require.Equal(t, "logs", got[1].signal)
+	require.Equal(t, http.StatusBadRequest, got[1].status)
+}
+
+func TestClient_ResponseCallback_GRPC(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		if err := grpc.SetHeader(ctx, metadata.Pairs("x-collector-version", "0.100.0")); err != nil {
+			return nil, err
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	callback, responses := newResponseRecorder()
+	client, err := otlp.NewClient(server.URL, otlp.WithResponseCallback(callback))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Error(t, client.UploadMetrics(ctx, []*otlp.ResourceMetrics{}))
+
+	got := responses()
+	require.Len(t, got, 2)
+	require.Equal(t, "traces", got[0].signal)
+	require.Equal(t, int(codes.OK), got[0].status)
+	require.Equal(t, "0.100.0", got[0].header.Get("X-Collector-Version"))
+	require.Equal(t, "metrics", got[1].signal)
+	require.Equal(t, int(codes.Unimplemented), got[1].status)
+}
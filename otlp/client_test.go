@@ -2,10 +2,16 @@ package otlp_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +19,15 @@ import (
 	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colprofilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
+	profilespb "go.opentelemetry.io/proto/otlp/profiles/v1experimental"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -476,3 +491,709 @@ func TestClient_EnvOptions(t *testing.T) {
 	assert.Equal(t, "application/grpc", actualMetricsProtocol)
 	assert.Equal(t, "application/grpc", actualLogsProtocol)
 }
+
+func TestClient_StrictEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "udp")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "invalid")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "five seconds")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPONT", "http://localhost:4317")
+
+	_, err := otlp.NewClient("http://localhost:4317", otlp.DefaultClientOptions("OTEL_EXPORTER_"))
+	require.Error(t, err)
+	var envErr *otlp.EnvError
+	require.ErrorAs(t, err, &envErr)
+	require.NotContains(t, err.Error(), "OTEL_EXPORTER_OTLP_ENDPONT")
+
+	_, err = otlp.NewClient(
+		"http://localhost:4317",
+		otlp.WithStrictEnv(),
+		otlp.DefaultClientOptions("OTEL_EXPORTER_"),
+	)
+	for _, name := range []string{
+		"OTEL_EXPORTER_OTLP_PROTOCOL",
+		"OTEL_EXPORTER_OTLP_HEADERS",
+		"OTEL_EXPORTER_OTLP_TIMEOUT",
+		"OTEL_EXPORTER_OTLP_ENDPONT",
+	} {
+		require.ErrorContains(t, err, name)
+	}
+}
+
+func TestClient_DeprecatedEnv(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var actualHeader string
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		headers, ok := otlp.HeadersFromContext(ctx)
+		assert.True(t, ok)
+		actualHeader = headers.Get("Api-Key")
+		return &otlp.TraceResponse{}, nil
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	t.Setenv("OTEL_EXPORTER_OTLP_SPAN_ENDPOINT", server.URL+"/v1/traces")
+	t.Setenv("OTEL_EXPORTER_OTLP_SPAN_HEADERS", "Api-Key=deprecated")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS", "Api-Key=current")
+
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	client, err := otlp.NewClient(
+		"http://localhost:4317",
+		otlp.WithStrictEnv(),
+		otlp.DefaultClientOptions("OTEL_EXPORTER_"),
+		otlp.WithLogger(logger),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Equal(t, "current", actualHeader)
+	require.Contains(t, buf.String(), "OTEL_EXPORTER_OTLP_SPAN_ENDPOINT")
+	require.Contains(t, buf.String(), "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	require.NotContains(t, buf.String(), "OTEL_EXPORTER_OTLP_SPAN_HEADERS")
+}
+
+func TestClient_EnvTimeoutMilliseconds(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "100")
+	client, err := otlp.NewClient(server.URL, otlp.DefaultClientOptions("OTEL_EXPORTER_"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	start := time.Now()
+	err = client.UploadTraces(ctx, []*otlp.ResourceSpans{})
+	require.Error(t, err)
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "1s")
+	_, err = otlp.NewClient(server.URL, otlp.DefaultClientOptions("OTEL_EXPORTER_"))
+	require.NoError(t, err)
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "-1")
+	_, err = otlp.NewClient(server.URL, otlp.DefaultClientOptions("OTEL_EXPORTER_"))
+	require.Error(t, err)
+}
+
+func TestClient_OptionsFromLookup(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var actualHeader string
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		headers, ok := otlp.HeadersFromContext(ctx)
+		assert.True(t, ok)
+		actualHeader = headers.Get("Api-Key")
+		return &otlp.TraceResponse{}, nil
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	values := map[string]string{
+		"otlp-protocol": "http/json",
+		"otlp-headers":  "Api-Key=lookup",
+	}
+	flagNames := make([]string, 0)
+	for _, f := range otlp.ClientOptionFlags("") {
+		flagNames = append(flagNames, f.Name)
+	}
+	for name := range values {
+		require.Contains(t, flagNames, name)
+	}
+	client, err := otlp.NewClient(server.URL, otlp.ClientOptionsFromLookup(func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Equal(t, "lookup", actualHeader)
+
+	_, err = otlp.NewClient(server.URL, otlp.ClientOptionsFromLookup(func(name string) (string, bool) {
+		if name == "otlp-protocol" {
+			return "udp", true
+		}
+		return "", false
+	}))
+	require.ErrorContains(t, err, "otlp-protocol")
+
+	invalid := map[string]string{
+		"otlp-protocol": "udp",
+		"otlp-headers":  "invalid",
+		"otlp-timeout":  "five seconds",
+	}
+	_, err = otlp.NewClient(server.URL, otlp.WithStrictEnv(), otlp.ClientOptionsFromLookup(func(name string) (string, bool) {
+		v, ok := invalid[name]
+		return v, ok
+	}))
+	for name := range invalid {
+		require.ErrorContains(t, err, name)
+	}
+}
+
+func TestClient_HTTP_EndpointPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		option   func(string) otlp.ClientOption
+		expected string
+	}{
+		{name: "append", option: otlp.WithEndpoint, expected: "/otel/v1/traces"},
+		{name: "verbatim", option: otlp.WithEndpointURL, expected: "/otel"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var actualPath string
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					actualPath = r.URL.Path
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte("{}"))
+				},
+			))
+			defer server.Close()
+			client, err := otlp.NewClient("", c.option(server.URL+"/otel"), otlp.WithProtocol("http/json"))
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+			require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+			require.Equal(t, c.expected, actualPath)
+		})
+	}
+}
+
+func TestClient_HTTP_PerSignalTLSConfig(t *testing.T) {
+	var tracesCalled, logsCalled bool
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			tracesCalled = true
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	defer tlsServer.Close()
+	plainServer := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			logsCalled = true
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	defer plainServer.Close()
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(tlsServer.Certificate())
+	client, err := otlp.NewClient(
+		plainServer.URL,
+		otlp.WithProtocol("http/json"),
+		otlp.WithTracesEndpoint(tlsServer.URL+"/v1/traces"),
+		otlp.WithTracesTLSConfig(&tls.Config{RootCAs: rootCAs}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.True(t, tracesCalled)
+	require.True(t, logsCalled)
+
+	// without the tls config, the self-signed certificate is rejected.
+	client, err = otlp.NewClient(
+		plainServer.URL,
+		otlp.WithProtocol("http/json"),
+		otlp.WithTracesEndpoint(tlsServer.URL+"/v1/traces"),
+	)
+	require.NoError(t, err)
+	require.Error(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+}
+
+func TestClient_GRPC_EndpointsFailover(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var called int
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		called++
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	// the first endpoint is down, so the client fails over to the second one.
+	down := otlptest.NewServer(otlp.NewServerMux())
+	downURL := down.URL
+	down.Close()
+
+	client, err := otlp.NewClient(
+		"",
+		otlp.WithEndpoints(downURL, server.URL),
+		otlp.WithProtocol("grpc"),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Equal(t, 1, called)
+
+	_, err = otlp.NewClient("", otlp.WithEndpoints("http://localhost:4317", "https://localhost:4317"))
+	require.Error(t, err)
+	_, err = otlp.NewClient("http://localhost:4317", otlp.WithLoadBalancingPolicy("random"))
+	require.Error(t, err)
+}
+
+func TestClient_GRPC_ResolverAndAuthority(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var authority string
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get(":authority"); len(v) > 0 {
+			authority = v[0]
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	r := manual.NewBuilderWithScheme("test")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: u.Host}}})
+
+	client, err := otlp.NewClient(
+		"http://collector.invalid:4317",
+		otlp.WithProtocol("grpc"),
+		otlp.WithResolver("test:///collector", r),
+		otlp.WithGRPCAuthority("otel-collector.mesh.local"),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.Equal(t, "otel-collector.mesh.local", authority)
+}
+
+type countingStatsHandler struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (h *countingStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.methods = append(h.methods, info.FullMethodName)
+	return ctx
+}
+
+func (h *countingStatsHandler) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (h *countingStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *countingStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestClient_GRPC_StatsHandler(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return &otlp.LogsResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	h := &countingStatsHandler{}
+	client, err := otlp.NewClient(server.URL, otlp.WithGRPCStatsHandler(h))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.Equal(t, []string{
+		"/opentelemetry.proto.collector.trace.v1.TraceService/Export",
+		"/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+	}, h.methods)
+
+	_, err = otlp.NewClient(server.URL, otlp.WithGRPCStatsHandler(nil))
+	require.Error(t, err)
+}
+
+func TestClient_Export(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var called []string
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		called = append(called, "traces")
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Metrics().HandleFunc(func(_ context.Context, _ *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
+		called = append(called, "metrics")
+		return &otlp.MetricsResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		called = append(called, "logs")
+		return &otlp.LogsResponse{
+			PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "rejected"},
+		}, nil
+	})
+	mux.Profiles().HandleFunc(func(_ context.Context, _ *otlp.ProfilesRequest) (*otlp.ProfilesResponse, error) {
+		called = append(called, "profiles")
+		return &otlp.ProfilesResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	resp, err := client.Export(ctx, &otlp.TraceRequest{})
+	require.NoError(t, err)
+	require.IsType(t, &otlp.TraceResponse{}, resp)
+	resp, err = client.Export(ctx, &otlp.MetricsRequest{})
+	require.NoError(t, err)
+	require.IsType(t, &otlp.MetricsResponse{}, resp)
+	resp, err = client.Export(ctx, &otlp.LogsRequest{})
+	var psErr *otlp.UploadLogsPartialSuccessError
+	require.ErrorAs(t, err, &psErr)
+	require.EqualValues(t, 1, resp.(*otlp.LogsResponse).GetPartialSuccess().GetRejectedLogRecords())
+	resp, err = client.Export(ctx, &otlp.ProfilesRequest{})
+	require.NoError(t, err)
+	require.IsType(t, &otlp.ProfilesResponse{}, resp)
+	require.Equal(t, []string{"traces", "metrics", "logs", "profiles"}, called)
+
+	_, err = client.Export(ctx, &otlp.TraceResponse{})
+	require.Error(t, err)
+}
+
+func TestClient_HTTP_UploadProfiles(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var received int
+	mux.Profiles().HandleFunc(func(_ context.Context, req *otlp.ProfilesRequest) (*otlp.ProfilesResponse, error) {
+		received = otlp.TotalProfiles(req.GetResourceProfiles())
+		return &otlp.ProfilesResponse{
+			PartialSuccess: &colprofilespb.ExportProfilesPartialSuccess{RejectedProfiles: 1, ErrorMessage: "rejected"},
+		}, nil
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	err = client.UploadProfiles(ctx, []*otlp.ResourceProfiles{{
+		ScopeProfiles: []*profilespb.ScopeProfiles{{
+			Profiles: []*profilespb.ProfileContainer{{}, {}},
+		}},
+	}})
+	var psErr *otlp.UploadProfilesPartialSuccessError
+	require.ErrorAs(t, err, &psErr)
+	require.EqualValues(t, 1, psErr.Response().GetPartialSuccess().GetRejectedProfiles())
+	require.Equal(t, 2, received)
+	ps, ok := client.LastPartialSuccess("profiles")
+	require.True(t, ok)
+	require.EqualValues(t, 1, ps.Rejected)
+}
+
+func TestClient_HTTP_UploadProfiles_Endpoint(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name     string
+		opts     []otlp.ClientOption
+		expected string
+	}{
+		{
+			name: "per-signal traces endpoint",
+			opts: []otlp.ClientOption{
+				otlp.WithTracesEndpoint(server.URL + "/custom/traces"),
+				otlp.WithTracesHeaders(map[string]string{"X-Traces": "only"}),
+			},
+			expected: "/v1development/profiles",
+		},
+		{
+			name:     "verbatim endpoint",
+			opts:     []otlp.ClientOption{otlp.WithEndpointURL(server.URL + "/custom")},
+			expected: "/custom",
+		},
+		{
+			name:     "profiles endpoint",
+			opts:     []otlp.ClientOption{otlp.WithProfilesEndpoint(server.URL + "/custom/profiles")},
+			expected: "/custom/profiles",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mu.Lock()
+			paths, headers = nil, nil
+			mu.Unlock()
+			opts := append([]otlp.ClientOption{otlp.WithProtocol("http/protobuf")}, c.opts...)
+			client, err := otlp.NewClient(server.URL, opts...)
+			require.NoError(t, err)
+			require.NoError(t, client.UploadProfiles(ctx, []*otlp.ResourceProfiles{}))
+			mu.Lock()
+			defer mu.Unlock()
+			require.Equal(t, []string{c.expected}, paths)
+			require.Empty(t, headers[0].Get("X-Traces"))
+		})
+	}
+
+	client, err := otlp.NewClient(
+		"",
+		otlp.WithProtocol("http/protobuf"),
+		otlp.WithTracesEndpoint(server.URL+"/v1/traces"),
+		otlp.WithMetricsDisabled(),
+		otlp.WithLogsDisabled(),
+	)
+	require.NoError(t, err)
+	require.ErrorIs(t, client.UploadProfiles(ctx, []*otlp.ResourceProfiles{}), otlp.ErrProfilesEndpointRequired)
+
+	client, err = otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"), otlp.WithProfilesDisabled())
+	require.NoError(t, err)
+	require.NoError(t, client.UploadProfiles(ctx, []*otlp.ResourceProfiles{}))
+}
+
+func TestClient_HTTP_AcceptedStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		},
+	))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	var httpErr *otlp.HTTPStatusError
+	require.ErrorAs(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}), &httpErr)
+	require.Equal(t, http.StatusAccepted, httpErr.StatusCode)
+
+	client, err = otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("http/protobuf"),
+		otlp.WithAcceptedStatusCodes([]int{http.StatusAccepted, http.StatusNoContent}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadMetrics(ctx, []*otlp.ResourceMetrics{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+
+	_, err = otlp.NewClient(server.URL, otlp.WithAcceptedStatusCodes([]int{http.StatusBadRequest}))
+	require.Error(t, err)
+}
+
+func TestClient_LastPartialSuccess(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return &otlp.LogsResponse{
+			PartialSuccess: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 2, ErrorMessage: "too old"},
+		}, nil
+	})
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	_, ok := client.LastPartialSuccess("logs")
+	require.False(t, ok)
+	before := time.Now()
+	require.Error(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	ps, ok := client.LastPartialSuccess("logs")
+	require.True(t, ok)
+	require.EqualValues(t, 2, ps.Rejected)
+	require.Equal(t, "too old", ps.ErrorMessage)
+	require.False(t, ps.Time.Before(before))
+	_, ok = client.LastPartialSuccess("traces")
+	require.False(t, ok)
+}
+
+func TestClient_HTTP_DNSCache(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	client, err := otlp.NewClient(
+		"http://localhost:"+u.Port(),
+		otlp.WithProtocol("http/json"),
+		otlp.WithDNSCache(time.Minute),
+		// disable keep-alives, so that each upload dials with the cache.
+		otlp.WithHTTPClient(&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.EqualValues(t, 2, count.Load())
+
+	_, err = otlp.NewClient(server.URL, otlp.WithDNSCache(0))
+	require.Error(t, err)
+}
+
+func TestClient_UserAgentSuffix(t *testing.T) {
+	for _, protocol := range []string{"grpc", "http/protobuf", "http/json"} {
+		t.Run(protocol, func(t *testing.T) {
+			mux := otlp.NewServerMux()
+			userAgents := make(chan string, 1)
+			mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+				headers, ok := otlp.HeadersFromContext(ctx)
+				assert.True(t, ok)
+				userAgents <- headers.Get("User-Agent")
+				return &otlp.TraceResponse{}, nil
+			})
+			mux.Logs().HandleFunc(func(ctx context.Context, request *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+				headers, ok := otlp.HeadersFromContext(ctx)
+				assert.True(t, ok)
+				userAgents <- headers.Get("User-Agent")
+				return &otlp.LogsResponse{}, nil
+			})
+			var serverURL string
+			if protocol == "grpc" {
+				server := otlptest.NewServer(mux)
+				defer server.Close()
+				serverURL = server.URL
+			} else {
+				server := httptest.NewServer(mux)
+				defer server.Close()
+				serverURL = server.URL
+			}
+			client, err := otlp.NewClient(
+				serverURL,
+				otlp.WithProtocol(protocol),
+				otlp.WithUserAgentSuffix("myapp/1.2.3"),
+				otlp.WithUserAgentSuffix(" (build 42) "),
+				otlp.WithLogsUserAgent("mylogger/0.1.0"),
+			)
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+
+			require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+			userAgent := <-userAgents
+			require.True(t, strings.HasPrefix(userAgent, "go-otlp-helper/"), userAgent)
+			require.Contains(t, userAgent, "(github.com/mashiike/go-otlp-helper/otlp.Client) go/")
+			require.Contains(t, userAgent, " myapp/1.2.3 (build 42)")
+			require.Equal(t, 1, strings.Count(userAgent, "myapp/1.2.3"))
+
+			require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{{}}))
+			userAgent = <-userAgents
+			require.True(t, strings.HasPrefix(userAgent, "mylogger/0.1.0 myapp/1.2.3 (build 42)"), userAgent)
+		})
+	}
+}
+
+func TestClient_SignalDisabled(t *testing.T) {
+	server, requests := newRecordingServer(t, false)
+	t.Setenv("OTLP_ENDPOINT", server.URL)
+	t.Setenv("OTLP_PROTOCOL", "http/json")
+	t.Setenv("OTLP_METRICS_DISABLED", "true")
+	client, err := otlp.NewClient("", otlp.DefaultClientOptions(), otlp.WithLogsDisabled())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, client.UploadMetrics(ctx, []*otlp.ResourceMetrics{}))
+	require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.NoError(t, client.Stop(ctx))
+	got := requests()
+	require.Len(t, got, 1)
+	require.Equal(t, "/v1/traces", got[0].path)
+
+	// the disabled signals do not require the endpoint.
+	_, err = otlp.NewClient("",
+		otlp.WithTracesEndpoint(server.URL+"/v1/traces"),
+		otlp.WithMetricsDisabled(),
+		otlp.WithLogsDisabled(),
+	)
+	require.NoError(t, err)
+
+	t.Setenv("OTLP_TRACES_DISABLED", "maybe")
+	_, err = otlp.NewClient(server.URL, otlp.DefaultClientOptions())
+	var envErr *otlp.EnvError
+	require.ErrorAs(t, err, &envErr)
+	require.Equal(t, "OTLP_TRACES_DISABLED", envErr.Name)
+}
+
+func TestClient_HTTP_ResponseContentTypeParameters(t *testing.T) {
+	for _, c := range []struct {
+		protocol    string
+		contentType string
+		body        string
+	}{
+		{"http/json", "application/json; charset=utf-8", `{}`},
+		{"http/protobuf", "application/x-protobuf; charset=binary", ""},
+	} {
+		t.Run(c.contentType, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", c.contentType)
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte(c.body))
+				},
+			))
+			defer server.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			client, err := otlp.NewClient(server.URL, otlp.WithProtocol(c.protocol))
+			require.NoError(t, err)
+			require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+			require.NoError(t, client.UploadMetrics(ctx, []*otlp.ResourceMetrics{}))
+			require.NoError(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const tracerName = "github.com/mashiike/go-otlp-helper/otlp"
+
+var grpcServiceNames = map[string]string{
+	"traces":  "opentelemetry.proto.collector.trace.v1.TraceService",
+	"metrics": "opentelemetry.proto.collector.metrics.v1.MetricsService",
+	"logs":    "opentelemetry.proto.collector.logs.v1.LogsService",
+}
+
+// WithTracerProvider sets the tracer provider used to emit spans for the client's own export calls, with rpc.* and http.* semantic attributes.
+// the tracer provider should export to a separate pipeline, otherwise the spans of the exports are exported by the client itself.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(o *clientOptions) error {
+		o.tracerProvider = tp
+		return nil
+	}
+}
+
+// WithPropagator injects the context of the export call into the headers of the HTTP requests and the metadata of the gRPC calls,
+// e.g. propagation.TraceContext{} for traceparent and tracestate, so that the collector can correlate the exports with the caller's trace.
+// it does not apply to the ws protocol, whose headers are sent once per connection.
+func WithPropagator(propagator propagation.TextMapPropagator) ClientOption {
+	return func(o *clientOptions) error {
+		o.propagator = propagator
+		return nil
+	}
+}
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (o *clientOptions) signalOptions(signalType string) *clientSignalsOptions {
+	switch signalType {
+	case "traces":
+		return &o.traces
+	case "metrics":
+		return &o.metrics
+	case "logs":
+		return &o.logs
+	case "profiles":
+		return &o.profiles
+	}
+	return nil
+}
+
+// traceExport wraps an export attempt with a client span, if a tracer provider is set.
+func (c *Client) traceExport(ctx context.Context, signalType string, f func(context.Context) error) error {
+	so := c.o.signalOptions(signalType)
+	if c.o.tracerProvider == nil || so == nil {
+		return f(ctx)
+	}
+	attrs := []attribute.KeyValue{
+		semconv.ServerAddress(so.endpoint.Hostname()),
+	}
+	if port, err := strconv.Atoi(so.endpoint.Port()); err == nil {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	var spanName string
+	switch {
+	case so.isGRPCProtocol():
+		service := grpcServiceNames[signalType]
+		spanName = service + "/Export"
+		attrs = append(attrs,
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod("Export"),
+		)
+	default:
+		spanName = "POST"
+		attrs = append(attrs,
+			semconv.HTTPRequestMethodPost,
+			semconv.URLFull(so.endpoint.String()),
+		)
+	}
+	ctx, span := c.o.tracerProvider.Tracer(tracerName, trace.WithInstrumentationVersion(version)).Start(
+		ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+	err := f(ctx)
+	if so.isGRPCProtocol() {
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+	}
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(httpErr.StatusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package otlp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestClient_TracerProvider_GRPC(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	recorder := tracetest.NewSpanRecorder()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "opentelemetry.proto.collector.trace.v1.TraceService/Export", spans[0].Name())
+	require.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	attrs := spanAttributes(spans[0])
+	require.Equal(t, "grpc", attrs["rpc.system"].AsString())
+	require.Equal(t, "Export", attrs["rpc.method"].AsString())
+	require.EqualValues(t, 0, attrs["rpc.grpc.status_code"].AsInt64())
+}
+
+func TestClient_TracerProvider_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		},
+	))
+	defer server.Close()
+	recorder := tracetest.NewSpanRecorder()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("http/protobuf"),
+		otlp.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.Error(t, client.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "POST", spans[0].Name())
+	require.Equal(t, otelcodes.Error, spans[0].Status().Code)
+	attrs := spanAttributes(spans[0])
+	require.Equal(t, server.URL+"/v1/logs", attrs["url.full"].AsString())
+	require.EqualValues(t, http.StatusBadRequest, attrs["http.response.status_code"].AsInt64())
+}
+
+func TestClient_Propagator(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traceState, err := trace.ParseTraceState("vendor=value")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		TraceState: traceState,
+	}))
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	mux := otlp.NewServerMux()
+	var headers http.Header
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		headers, _ = otlp.HeadersFromContext(ctx)
+		return &otlp.TraceResponse{}, nil
+	})
+	for _, protocol := range []string{"grpc", "http/protobuf"} {
+		t.Run(protocol, func(t *testing.T) {
+			headers = nil
+			var endpoint string
+			if protocol == "grpc" {
+				server := otlptest.NewServer(mux)
+				defer server.Close()
+				endpoint = server.URL
+			} else {
+				server := otlptest.NewHTTPServer(mux)
+				defer server.Close()
+				endpoint = server.URL
+			}
+			client, err := otlp.NewClient(endpoint,
+				otlp.WithProtocol(protocol),
+				otlp.WithPropagator(propagation.TraceContext{}),
+			)
+			require.NoError(t, err)
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+			require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+			require.Equal(t, traceparent, headers.Get("Traceparent"))
+			require.Equal(t, "vendor=value", headers.Get("Tracestate"))
+		})
+	}
+
+	// without the propagator, the trace context is not sent.
+	server := otlptest.NewHTTPServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+	require.Empty(t, headers.Get("Traceparent"))
+}
@@ -0,0 +1,53 @@
+package otlp
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientInfo is the information of the client of an export request.
+type ClientInfo struct {
+	// Addr is the remote address of the client, nil if unknown.
+	Addr net.Addr
+	// Transport is "grpc", "http", "grpc-web", "connect" or "websocket".
+	Transport string
+	// Protocol is the HTTP protocol negotiated with the client, e.g. "HTTP/1.1" or "HTTP/2.0".
+	Protocol string
+}
+
+type clientInfoKey struct{}
+
+func withClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the information of the client in the context of the handlers, over gRPC, HTTP and WebSocket alike,
+// e.g. for the rate limiting and the logging per source.
+func ClientInfoFromContext(ctx context.Context) (ClientInfo, bool) {
+	if info, ok := ctx.Value(clientInfoKey{}).(ClientInfo); ok {
+		return info, true
+	}
+	// the gRPC server does not go through ServeHTTP.
+	if p, ok := peer.FromContext(ctx); ok {
+		return ClientInfo{Addr: p.Addr, Transport: "grpc", Protocol: "HTTP/2.0"}, true
+	}
+	return ClientInfo{}, false
+}
+
+// PeerCertificatesFromContext returns the verified certificate chain of the client, leaf first, in the context of the handlers over gRPC and HTTP alike,
+// e.g. to identify the tenants by the client certificates of mTLS. it returns false without TLS or if the client certificate is not verified.
+func PeerCertificatesFromContext(ctx context.Context) ([]*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil, false
+	}
+	return tlsInfo.State.VerifiedChains[0], true
+}
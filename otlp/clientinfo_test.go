@@ -0,0 +1,168 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestClientInfoFromContext(t *testing.T) {
+	infos := make(chan otlp.ClientInfo, 1)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		info, ok := otlp.ClientInfoFromContext(ctx)
+		require.True(t, ok)
+		infos <- info
+		return &otlp.TraceResponse{}, nil
+	})
+
+	t.Run("http", func(t *testing.T) {
+		body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+		info := <-infos
+		require.Equal(t, "http", info.Transport)
+		require.Equal(t, "HTTP/1.1", info.Protocol)
+		require.Equal(t, "192.0.2.1:1234", info.Addr.String())
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		ctx := context.Background()
+		require.NoError(t, client.Start(ctx))
+		defer client.Stop(ctx)
+		require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+		info := <-infos
+		require.Equal(t, "grpc", info.Transport)
+		require.Equal(t, "HTTP/2.0", info.Protocol)
+		require.Contains(t, info.Addr.String(), "127.0.0.1:")
+	})
+	t.Run("none", func(t *testing.T) {
+		_, ok := otlp.ClientInfoFromContext(context.Background())
+		require.False(t, ok)
+	})
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func (c testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return testCert{cert: cert, key: key}
+}
+
+func TestPeerCertificatesFromContext(t *testing.T) {
+	ca := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	serverCert := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	clientCert := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "tenant-a"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	clientTLS := &tls.Config{
+		Certificates: []tls.Certificate{clientCert.tlsCertificate()},
+		RootCAs:      pool,
+	}
+
+	tenants := make(chan string, 1)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		chain, ok := otlp.PeerCertificatesFromContext(ctx)
+		require.True(t, ok)
+		require.Len(t, chain, 2)
+		tenants <- chain[0].Subject.CommonName
+		return &otlp.TraceResponse{}, nil
+	})
+	req := &otlp.TraceRequest{ResourceSpans: newSpans(1)}
+
+	t.Run("http", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(mux)
+		server.TLS = serverTLS
+		server.StartTLS()
+		defer server.Close()
+		body, err := proto.Marshal(req)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := client.Post(server.URL+"/v1/traces", "application/x-protobuf", bytes.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "tenant-a", <-tenants)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := mux.NewGRPCServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+		go server.Serve(l)
+		defer server.Stop()
+		conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = coltracepb.NewTraceServiceClient(conn).Export(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, "tenant-a", <-tenants)
+	})
+	t.Run("without tls", func(t *testing.T) {
+		_, ok := otlp.PeerCertificatesFromContext(context.Background())
+		require.False(t, ok)
+	})
+}
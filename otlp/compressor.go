@@ -0,0 +1,140 @@
+package otlp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // register the built-in gzip compressor
+)
+
+func init() {
+	// zstd is sent by some OTLP/HTTP exporters, e.g. the OpenTelemetry Collector with compression: zstd.
+	if encoding.GetCompressor("zstd") == nil {
+		RegisterCompressor("zstd", zstdCompressor{})
+	}
+}
+
+// Compressor compresses and decompresses the export request bodies.
+// it is the same as encoding.Compressor of google.golang.org/grpc without Name, so grpc compressors can be used as is.
+type Compressor interface {
+	Compress(w io.Writer) (io.WriteCloser, error)
+	Decompress(r io.Reader) (io.Reader, error)
+}
+
+type namedCompressor struct {
+	Compressor
+	name string
+}
+
+func (c namedCompressor) Name() string {
+	return c.name
+}
+
+// RegisterCompressor registers the compressor with the name, e.g. "snappy" or "lz4".
+// the compressor is registered to the grpc encoding registry, so it is used by both gRPC and HTTP, on the client and the ServerMux.
+// like encoding.RegisterCompressor, it must only be called during initialization time.
+func RegisterCompressor(name string, compressor Compressor) {
+	encoding.RegisterCompressor(namedCompressor{Compressor: compressor, name: name})
+}
+
+// WithCompressor sets the compressor to be used with the request instead of gzip.
+// the HTTP request body is compressed by compressor with Content-Encoding: name, only for this client.
+// gRPC looks up the compressor by grpc-encoding: name on both sides, so the name must be registered with RegisterCompressor at init time,
+// and the registered compressor is used. if compressor is nil, the registered compressor is used for HTTP too.
+// it is not supported with WithProtocol("ws"), NewClient returns an error.
+func WithCompressor(name string, compressor Compressor) ClientOption {
+	return func(o *clientOptions) error {
+		if compressor != nil {
+			if o.compressors == nil {
+				o.compressors = make(map[string]Compressor)
+			}
+			o.compressors[name] = compressor
+		}
+		o.compression = name
+		return nil
+	}
+}
+
+// lookupCompressor returns the compressor of the name, set by WithCompressor for HTTP, or registered by RegisterCompressor.
+func (o *clientOptions) lookupCompressor(name string, grpcProtocol bool) (Compressor, error) {
+	if compressor, ok := o.compressors[name]; ok && !grpcProtocol {
+		return compressor, nil
+	}
+	if compressor := encoding.GetCompressor(name); compressor != nil {
+		return compressor, nil
+	}
+	if grpcProtocol {
+		return nil, fmt.Errorf("compressor %q is not registered, gRPC needs RegisterCompressor at init time", name)
+	}
+	return nil, fmt.Errorf("compressor %q is not registered", name)
+}
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return zstdReader{dec}, nil
+}
+
+// zstdReader releases the decoder when the stream is read to the end.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err != nil {
+		r.Decoder.Close()
+	}
+	return n, err
+}
+
+// decompressRequestBody replaces the request body with the decompressed body by Content-Encoding.
+// if limit is positive, reading more than limit bytes of the decompressed body fails with *http.MaxBytesError.
+func decompressRequestBody(w http.ResponseWriter, r *http.Request, limit int64) error {
+	name := r.Header.Get("Content-Encoding")
+	if name != "" && name != "identity" {
+		compressor := encoding.GetCompressor(name)
+		if compressor == nil {
+			return fmt.Errorf("content encoding %q is not supported", name)
+		}
+		body, err := compressor.Decompress(r.Body)
+		if err != nil {
+			return fmt.Errorf("failed to decompress request body: %w", err)
+		}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, r.Body}
+	}
+	if limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	return nil
+}
+
+func compressBody(compressor Compressor, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := compressor.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+package otlp_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// gRPC needs the compressors registered at init time.
+	otlp.RegisterCompressor("deflate", deflateCompressor{})
+}
+
+type deflateCompressor struct{}
+
+func (deflateCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+func (deflateCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return flate.NewReader(r), nil
+}
+
+func TestClient_Compressor(t *testing.T) {
+	cases := []struct {
+		name     string
+		protocol string
+		option   otlp.ClientOption
+		encoding string
+	}{
+		{name: "http gzip", protocol: "http/protobuf", option: otlp.WithGzip(true), encoding: "gzip"},
+		{name: "http custom", protocol: "http/json", option: otlp.WithCompressor("deflate", deflateCompressor{}), encoding: "deflate"},
+		{name: "grpc registered", protocol: "grpc", option: otlp.WithCompressor("deflate", nil), encoding: "deflate"},
+		{name: "http zstd", protocol: "http/protobuf", option: otlp.WithCompressor("zstd", nil), encoding: "zstd"},
+		{name: "grpc zstd", protocol: "grpc", option: otlp.WithCompressor("zstd", nil), encoding: "zstd"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mux := otlp.NewServerMux()
+			var called bool
+			mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+				called = true
+				// grpc-encoding is not exposed to the metadata, so only HTTP is checked.
+				if c.protocol != "grpc" {
+					headers, _ := otlp.HeadersFromContext(ctx)
+					assert.Equal(t, c.encoding, headers.Get("Content-Encoding"))
+				}
+				assert.Equal(t, 1, otlp.TotalSpans(request.GetResourceSpans()))
+				return &otlp.TraceResponse{}, nil
+			})
+			var url string
+			if c.protocol == "grpc" {
+				server := otlptest.NewServer(mux)
+				defer server.Close()
+				url = server.URL
+			} else {
+				server := httptest.NewServer(mux)
+				defer server.Close()
+				url = server.URL
+			}
+			client, err := otlp.NewClient(url, otlp.WithProtocol(c.protocol), c.option)
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+			require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+			require.True(t, called)
+		})
+	}
+}
+
+func TestClient_Compressor_PerClient(t *testing.T) {
+	var encoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		_, err := io.ReadAll(flate.NewReader(r.Body))
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"), otlp.WithCompressor("x-deflate", deflateCompressor{}))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+	require.Equal(t, "x-deflate", encoding)
+
+	// gRPC uses only the compressors registered at init time.
+	_, err = otlp.NewClient(server.URL, otlp.WithProtocol("grpc"), otlp.WithCompressor("x-deflate", deflateCompressor{}))
+	require.ErrorContains(t, err, `compressor "x-deflate" is not registered`)
+	_, err = otlp.NewClient(server.URL, otlp.WithProtocol("http/json"), otlp.WithCompressor("x-unknown", nil))
+	require.ErrorContains(t, err, `compressor "x-unknown" is not registered`)
+}
+
+func TestServerMux_HTTP_UnsupportedContentEncoding(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/traces", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "br")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestServerMux_HTTP_MaxRequestBodySize(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux(otlp.WithMaxRecvMsgSize(int64(len(traceData) - 1)))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	post := func(encoding string) *http.Response {
+		t.Helper()
+		var body bytes.Buffer
+		switch encoding {
+		case "gzip":
+			w := gzip.NewWriter(&body)
+			w.Write(traceData)
+			require.NoError(t, w.Close())
+		case "zstd":
+			w, err := zstd.NewWriter(&body)
+			require.NoError(t, err)
+			w.Write(traceData)
+			require.NoError(t, w.Close())
+		default:
+			body.Write(traceData)
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/traces", &body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", encoding)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	for _, encoding := range []string{"", "gzip", "zstd"} {
+		resp := post(encoding)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, encoding)
+		require.Equal(t, "1", resp.Header.Get("Retry-After"))
+		require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	}
+	mux.SetMaxRequestBodySize(int64(len(traceData)))
+	for _, encoding := range []string{"", "gzip", "zstd"} {
+		require.Equal(t, http.StatusOK, post(encoding).StatusCode, encoding)
+	}
+	mux.SetMaxRequestBodySize(0)
+	require.Equal(t, http.StatusOK, post("gzip").StatusCode)
+}
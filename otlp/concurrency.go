@@ -0,0 +1,87 @@
+package otlp
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type concurrencyLimitOptions struct {
+	queue int
+}
+
+// ConcurrencyLimitOption is an option for ConcurrencyLimitMiddleware.
+type ConcurrencyLimitOption func(*concurrencyLimitOptions)
+
+// WithConcurrencyLimitQueue sets the number of the requests per signal that wait for a slot when the limit is reached,
+// until one is released or the context of the request is done. default is 0, i.e. the requests over the limit are rejected immediately.
+func WithConcurrencyLimitQueue(n int) ConcurrencyLimitOption {
+	return func(o *concurrencyLimitOptions) {
+		o.queue = n
+	}
+}
+
+// ConcurrencyLimitMiddleware returns a middleware that limits the in-flight export requests to limit per signal,
+// e.g. to protect the databases behind the handlers from overload. the requests beyond the limit and the queue (see WithConcurrencyLimitQueue)
+// are rejected with UNAVAILABLE, which the clients retry with backoff. limit zero or less means unlimited.
+func ConcurrencyLimitMiddleware(limit int, opts ...ConcurrencyLimitOption) MiddlewareFunc {
+	o := concurrencyLimitOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if limit <= 0 {
+		return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+			return next
+		}
+	}
+	semaphores := make(map[string]*semaphore)
+	for _, signalType := range []string{"traces", "metrics", "logs", "profiles"} {
+		semaphores[signalType] = &semaphore{slots: make(chan struct{}, limit), queue: int64(max(o.queue, 0))}
+	}
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			signalType, _ := requestItems(req)
+			sem, ok := semaphores[signalType]
+			if !ok {
+				return next(ctx, req)
+			}
+			if err := sem.acquire(ctx); err != nil {
+				return nil, err
+			}
+			defer sem.release()
+			return next(ctx, req)
+		}
+	}
+}
+
+type semaphore struct {
+	slots   chan struct{}
+	queue   int64
+	waiting atomic.Int64
+}
+
+func (s *semaphore) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if s.waiting.Add(1) > s.queue {
+		s.waiting.Add(-1)
+		return status.Error(codes.Unavailable, "too many in-flight requests")
+	}
+	defer s.waiting.Add(-1)
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (s *semaphore) release() {
+	<-s.slots
+}
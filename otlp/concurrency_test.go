@@ -0,0 +1,66 @@
+package otlp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	h := otlp.ConcurrencyLimitMiddleware(1, otlp.WithConcurrencyLimitQueue(1))(func(_ context.Context, _ proto.Message) (proto.Message, error) {
+		started <- struct{}{}
+		<-release
+		return &otlp.TraceResponse{}, nil
+	})
+	ctx := context.Background()
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := h(ctx, &otlp.TraceRequest{})
+			results <- err
+		}()
+	}
+	<-started
+	require.Eventually(t, func() bool {
+		_, err := h(ctx, &otlp.TraceRequest{})
+		return status.Code(err) == codes.Unavailable
+	}, time.Second, 10*time.Millisecond, "the request beyond the limit and the queue is rejected")
+
+	// the other signals have their own limit.
+	go func() {
+		_, err := h(ctx, &otlp.LogsRequest{})
+		results <- err
+	}()
+	<-started
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, <-results)
+	}
+	require.Len(t, started, 1, "the queued request is handled after the slot is released")
+}
+
+func TestConcurrencyLimitMiddleware_QueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	h := otlp.ConcurrencyLimitMiddleware(1, otlp.WithConcurrencyLimitQueue(1))(func(_ context.Context, _ proto.Message) (proto.Message, error) {
+		close(started)
+		<-release
+		return &otlp.TraceResponse{}, nil
+	})
+	go h(context.Background(), &otlp.TraceRequest{})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := h(ctx, &otlp.TraceRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
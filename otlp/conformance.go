@@ -0,0 +1,340 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxConformanceViolations is the number of the violations in the status details of ConformanceHandler.
+const maxConformanceViolations = 20
+
+// ConformanceErrorReason is the reason of errdetails.ErrorInfo in the status of the requests rejected by ConformanceHandler.
+const ConformanceErrorReason = "OTLP_CONFORMANCE_VIOLATION"
+
+// ConformanceHandler is a handler of traces, metrics and logs that validates the requests against the constraints of OTLP and echoes the summary back,
+// as a self-test endpoint for the conformance of the SDKs and the exporters, e.g. mux.Trace().Handle(otlp.NewConformanceHandler()).
+//
+// the conforming requests are accepted with the summary in the error message of the partial success, with no items rejected.
+// the others are rejected with INVALID_ARGUMENT, with the summary in errdetails.ErrorInfo and the violations in errdetails.BadRequest of the status details.
+// the fields of the violations are the paths in the request, e.g. resource_spans[0].scope_spans[0].spans[1].trace_id.
+type ConformanceHandler struct{}
+
+// NewConformanceHandler returns a new ConformanceHandler.
+func NewConformanceHandler() *ConformanceHandler {
+	return &ConformanceHandler{}
+}
+
+// HandleTrace validates the trace request.
+func (h *ConformanceHandler) HandleTrace(_ context.Context, request *TraceRequest) (*TraceResponse, error) {
+	c := conformance{signal: "traces"}
+	for i, rs := range request.GetResourceSpans() {
+		path := fmt.Sprintf("resource_spans[%d]", i)
+		c.checkResource(path, rs.GetResource())
+		for j, ss := range rs.GetScopeSpans() {
+			path := fmt.Sprintf("%s.scope_spans[%d]", path, j)
+			c.checkScope(path, ss.GetScope())
+			for k, span := range ss.GetSpans() {
+				c.checkSpan(fmt.Sprintf("%s.spans[%d]", path, k), span)
+			}
+		}
+	}
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return NewTracePartialSuccess(0, c.summary()), nil
+}
+
+// HandleMetrics validates the metrics request.
+func (h *ConformanceHandler) HandleMetrics(_ context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+	c := conformance{signal: "metrics"}
+	for i, rm := range request.GetResourceMetrics() {
+		path := fmt.Sprintf("resource_metrics[%d]", i)
+		c.checkResource(path, rm.GetResource())
+		for j, sm := range rm.GetScopeMetrics() {
+			path := fmt.Sprintf("%s.scope_metrics[%d]", path, j)
+			c.checkScope(path, sm.GetScope())
+			for k, metric := range sm.GetMetrics() {
+				c.checkMetric(fmt.Sprintf("%s.metrics[%d]", path, k), metric)
+			}
+		}
+	}
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return NewMetricsPartialSuccess(0, c.summary()), nil
+}
+
+// HandleLogs validates the logs request.
+func (h *ConformanceHandler) HandleLogs(_ context.Context, request *LogsRequest) (*LogsResponse, error) {
+	c := conformance{signal: "logs"}
+	for i, rl := range request.GetResourceLogs() {
+		path := fmt.Sprintf("resource_logs[%d]", i)
+		c.checkResource(path, rl.GetResource())
+		for j, sl := range rl.GetScopeLogs() {
+			path := fmt.Sprintf("%s.scope_logs[%d]", path, j)
+			c.checkScope(path, sl.GetScope())
+			for k, record := range sl.GetLogRecords() {
+				c.checkLogRecord(fmt.Sprintf("%s.log_records[%d]", path, k), record)
+			}
+		}
+	}
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return NewLogsPartialSuccess(0, c.summary()), nil
+}
+
+type conformance struct {
+	signal     string
+	resources  int
+	scopes     int
+	items      int
+	attributes int
+	violations []*errdetails.BadRequest_FieldViolation
+	violated   int
+}
+
+func (c *conformance) violate(field string, format string, args ...any) {
+	c.violated++
+	if len(c.violations) < maxConformanceViolations {
+		c.violations = append(c.violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (c *conformance) summary() string {
+	return fmt.Sprintf("conformance: %d resources, %d scopes, %d %s, %d attributes, %d violations",
+		c.resources, c.scopes, c.items, c.itemName(), c.attributes, c.violated)
+}
+
+func (c *conformance) itemName() string {
+	switch c.signal {
+	case "traces":
+		return "spans"
+	case "metrics":
+		return "data points"
+	}
+	return "log records"
+}
+
+func (c *conformance) err() error {
+	if c.violated == 0 {
+		return nil
+	}
+	first := c.violations[0]
+	st := status.Newf(codes.InvalidArgument, "%s: %s: %s", c.summary(), first.GetField(), first.GetDescription())
+	st, err := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: ConformanceErrorReason,
+			Domain: "github.com/mashiike/go-otlp-helper",
+			Metadata: map[string]string{
+				"signal":     c.signal,
+				"resources":  strconv.Itoa(c.resources),
+				"scopes":     strconv.Itoa(c.scopes),
+				"items":      strconv.Itoa(c.items),
+				"attributes": strconv.Itoa(c.attributes),
+				"violations": strconv.Itoa(c.violated),
+			},
+		},
+		&errdetails.BadRequest{FieldViolations: c.violations},
+	)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, c.summary())
+	}
+	return st.Err()
+}
+
+func (c *conformance) checkResource(path string, resource *resourcepb.Resource) {
+	c.resources++
+	c.checkAttributes(path+".resource.attributes", resource.GetAttributes())
+}
+
+func (c *conformance) checkScope(path string, scope *commonpb.InstrumentationScope) {
+	c.scopes++
+	c.checkAttributes(path+".scope.attributes", scope.GetAttributes())
+}
+
+// checkAttributes requires the keys not empty and unique in the list.
+func (c *conformance) checkAttributes(path string, attrs []*commonpb.KeyValue) {
+	c.attributes += len(attrs)
+	seen := make(map[string]struct{}, len(attrs))
+	for i, attr := range attrs {
+		field := fmt.Sprintf("%s[%d].key", path, i)
+		if attr.GetKey() == "" {
+			c.violate(field, "attribute key is empty")
+			continue
+		}
+		if _, ok := seen[attr.GetKey()]; ok {
+			c.violate(field, "attribute key %q is duplicated", attr.GetKey())
+		}
+		seen[attr.GetKey()] = struct{}{}
+	}
+}
+
+// checkID requires the id of the size, not all zero. optional allows the empty id.
+func (c *conformance) checkID(field string, id []byte, size int, optional bool) {
+	if optional && len(id) == 0 {
+		return
+	}
+	if len(id) != size {
+		c.violate(field, "must be %d bytes, got %d", size, len(id))
+		return
+	}
+	if !validID(id, size) {
+		c.violate(field, "must not be all zero")
+	}
+}
+
+func (c *conformance) checkSpan(path string, span *tracepb.Span) {
+	c.items++
+	c.checkID(path+".trace_id", span.GetTraceId(), 16, false)
+	c.checkID(path+".span_id", span.GetSpanId(), 8, false)
+	c.checkID(path+".parent_span_id", span.GetParentSpanId(), 8, true)
+	if span.GetName() == "" {
+		c.violate(path+".name", "span name is empty")
+	}
+	if _, ok := tracepb.Span_SpanKind_name[int32(span.GetKind())]; !ok {
+		c.violate(path+".kind", "unknown span kind %d", span.GetKind())
+	}
+	if span.GetStartTimeUnixNano() == 0 {
+		c.violate(path+".start_time_unix_nano", "start time is not set")
+	}
+	if span.GetEndTimeUnixNano() < span.GetStartTimeUnixNano() {
+		c.violate(path+".end_time_unix_nano", "end time is before start time")
+	}
+	if _, ok := tracepb.Status_StatusCode_name[int32(span.GetStatus().GetCode())]; !ok {
+		c.violate(path+".status.code", "unknown status code %d", span.GetStatus().GetCode())
+	}
+	c.checkAttributes(path+".attributes", span.GetAttributes())
+	for i, event := range span.GetEvents() {
+		c.checkAttributes(fmt.Sprintf("%s.events[%d].attributes", path, i), event.GetAttributes())
+	}
+	for i, link := range span.GetLinks() {
+		linkPath := fmt.Sprintf("%s.links[%d]", path, i)
+		c.checkID(linkPath+".trace_id", link.GetTraceId(), 16, false)
+		c.checkID(linkPath+".span_id", link.GetSpanId(), 8, false)
+		c.checkAttributes(linkPath+".attributes", link.GetAttributes())
+	}
+}
+
+func (c *conformance) checkMetric(path string, metric *metricspb.Metric) {
+	if metric.GetName() == "" {
+		c.violate(path+".name", "metric name is empty")
+	}
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for i, dp := range data.Gauge.GetDataPoints() {
+			c.checkNumberDataPoint(fmt.Sprintf("%s.gauge.data_points[%d]", path, i), dp)
+		}
+	case *metricspb.Metric_Sum:
+		c.checkTemporality(path+".sum.aggregation_temporality", data.Sum.GetAggregationTemporality())
+		for i, dp := range data.Sum.GetDataPoints() {
+			c.checkNumberDataPoint(fmt.Sprintf("%s.sum.data_points[%d]", path, i), dp)
+		}
+	case *metricspb.Metric_Histogram:
+		c.checkTemporality(path+".histogram.aggregation_temporality", data.Histogram.GetAggregationTemporality())
+		for i, dp := range data.Histogram.GetDataPoints() {
+			c.checkHistogramDataPoint(fmt.Sprintf("%s.histogram.data_points[%d]", path, i), dp)
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		c.checkTemporality(path+".exponential_histogram.aggregation_temporality", data.ExponentialHistogram.GetAggregationTemporality())
+		for i, dp := range data.ExponentialHistogram.GetDataPoints() {
+			c.checkExponentialHistogramDataPoint(fmt.Sprintf("%s.exponential_histogram.data_points[%d]", path, i), dp)
+		}
+	case *metricspb.Metric_Summary:
+		for i, dp := range data.Summary.GetDataPoints() {
+			c.checkSummaryDataPoint(fmt.Sprintf("%s.summary.data_points[%d]", path, i), dp)
+		}
+	default:
+		c.violate(path+".data", "metric data is not set")
+	}
+}
+
+func (c *conformance) checkTemporality(field string, temporality metricspb.AggregationTemporality) {
+	if temporality == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED {
+		c.violate(field, "aggregation temporality is unspecified")
+		return
+	}
+	if _, ok := metricspb.AggregationTemporality_name[int32(temporality)]; !ok {
+		c.violate(field, "unknown aggregation temporality %d", temporality)
+	}
+}
+
+func (c *conformance) checkDataPoint(path string, timeUnixNano uint64, attrs []*commonpb.KeyValue) {
+	c.items++
+	if timeUnixNano == 0 {
+		c.violate(path+".time_unix_nano", "time is not set")
+	}
+	c.checkAttributes(path+".attributes", attrs)
+}
+
+func (c *conformance) checkNumberDataPoint(path string, dp *metricspb.NumberDataPoint) {
+	c.checkDataPoint(path, dp.GetTimeUnixNano(), dp.GetAttributes())
+	if dp.GetValue() == nil {
+		c.violate(path+".value", "value is not set")
+	}
+}
+
+func (c *conformance) checkHistogramDataPoint(path string, dp *metricspb.HistogramDataPoint) {
+	c.checkDataPoint(path, dp.GetTimeUnixNano(), dp.GetAttributes())
+	bounds, counts := dp.GetExplicitBounds(), dp.GetBucketCounts()
+	if len(counts) > 0 && len(counts) != len(bounds)+1 {
+		c.violate(path+".bucket_counts", "must have %d buckets for %d explicit bounds, got %d", len(bounds)+1, len(bounds), len(counts))
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			c.violate(fmt.Sprintf("%s.explicit_bounds[%d]", path, i), "explicit bounds must be strictly increasing")
+			break
+		}
+	}
+	if len(counts) > 0 && sumCounts(counts) != dp.GetCount() {
+		c.violate(path+".count", "count %d is not the sum of the bucket counts %d", dp.GetCount(), sumCounts(counts))
+	}
+}
+
+func (c *conformance) checkExponentialHistogramDataPoint(path string, dp *metricspb.ExponentialHistogramDataPoint) {
+	c.checkDataPoint(path, dp.GetTimeUnixNano(), dp.GetAttributes())
+	total := dp.GetZeroCount() + sumCounts(dp.GetPositive().GetBucketCounts()) + sumCounts(dp.GetNegative().GetBucketCounts())
+	if total != dp.GetCount() {
+		c.violate(path+".count", "count %d is not the sum of the zero count and the bucket counts %d", dp.GetCount(), total)
+	}
+}
+
+func (c *conformance) checkSummaryDataPoint(path string, dp *metricspb.SummaryDataPoint) {
+	c.checkDataPoint(path, dp.GetTimeUnixNano(), dp.GetAttributes())
+	for i, q := range dp.GetQuantileValues() {
+		if q.GetQuantile() < 0 || q.GetQuantile() > 1 {
+			c.violate(fmt.Sprintf("%s.quantile_values[%d].quantile", path, i), "quantile %v is out of [0, 1]", q.GetQuantile())
+		}
+	}
+}
+
+func sumCounts(counts []uint64) uint64 {
+	var sum uint64
+	for _, n := range counts {
+		sum += n
+	}
+	return sum
+}
+
+func (c *conformance) checkLogRecord(path string, record *logspb.LogRecord) {
+	c.items++
+	c.checkID(path+".trace_id", record.GetTraceId(), 16, true)
+	c.checkID(path+".span_id", record.GetSpanId(), 8, true)
+	if len(record.GetSpanId()) > 0 && len(record.GetTraceId()) == 0 {
+		c.violate(path+".trace_id", "trace id is required with span id")
+	}
+	if _, ok := logspb.SeverityNumber_name[int32(record.GetSeverityNumber())]; !ok {
+		c.violate(path+".severity_number", "unknown severity number %d", record.GetSeverityNumber())
+	}
+	c.checkAttributes(path+".attributes", record.GetAttributes())
+}
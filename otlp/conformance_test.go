@@ -0,0 +1,172 @@
+package otlp_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func conformanceDetails(t *testing.T, err error) (*errdetails.ErrorInfo, map[string]string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok, err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	var info *errdetails.ErrorInfo
+	violations := make(map[string]string)
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.BadRequest:
+			for _, v := range detail.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	require.NotNil(t, info)
+	require.Equal(t, otlp.ConformanceErrorReason, info.GetReason())
+	return info, violations
+}
+
+func TestConformanceHandler_Trace(t *testing.T) {
+	h := otlp.NewConformanceHandler()
+	ctx := context.Background()
+	data, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var req otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(data, &req))
+	resp, err := h.HandleTrace(ctx, &req)
+	require.NoError(t, err)
+	require.Zero(t, resp.GetPartialSuccess().GetRejectedSpans())
+	require.Contains(t, resp.GetPartialSuccess().GetErrorMessage(), "0 violations")
+
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{
+		ResourceSpans: []*otlp.ResourceSpans{{
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Spans: []*tracepb.Span{{
+					TraceId:           make([]byte, 16),
+					SpanId:            []byte{1, 2, 3},
+					StartTimeUnixNano: 2,
+					EndTimeUnixNano:   1,
+					Attributes: []*commonpb.KeyValue{
+						{Key: "a", Value: &commonpb.AnyValue{}},
+						{Key: "a", Value: &commonpb.AnyValue{}},
+					},
+				}},
+			}},
+		}},
+	})
+	info, violations := conformanceDetails(t, err)
+	require.Equal(t, "traces", info.GetMetadata()["signal"])
+	require.Equal(t, "1", info.GetMetadata()["items"])
+	require.Equal(t, "5", info.GetMetadata()["violations"])
+	require.Equal(t, map[string]string{
+		"resource_spans[0].scope_spans[0].spans[0].trace_id":           "must not be all zero",
+		"resource_spans[0].scope_spans[0].spans[0].span_id":            "must be 8 bytes, got 3",
+		"resource_spans[0].scope_spans[0].spans[0].name":               "span name is empty",
+		"resource_spans[0].scope_spans[0].spans[0].end_time_unix_nano": "end time is before start time",
+		"resource_spans[0].scope_spans[0].spans[0].attributes[1].key":  `attribute key "a" is duplicated`,
+	}, violations)
+}
+
+func TestConformanceHandler_Metrics(t *testing.T) {
+	h := otlp.NewConformanceHandler()
+	ctx := context.Background()
+	_, err := h.HandleMetrics(ctx, &otlp.MetricsRequest{
+		ResourceMetrics: []*otlp.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{
+					{
+						Name: "requests",
+						Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+							AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+							DataPoints: []*metricspb.NumberDataPoint{{
+								TimeUnixNano: 1,
+								Value:        &metricspb.NumberDataPoint_AsInt{AsInt: 1},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+
+	_, err = h.HandleMetrics(ctx, &otlp.MetricsRequest{
+		ResourceMetrics: []*otlp.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{
+					{Name: "empty"},
+					{
+						Name: "duration",
+						Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+							DataPoints: []*metricspb.HistogramDataPoint{{
+								TimeUnixNano:   1,
+								Count:          3,
+								BucketCounts:   []uint64{1, 1},
+								ExplicitBounds: []float64{10, 5},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	})
+	info, violations := conformanceDetails(t, err)
+	require.Equal(t, "1", info.GetMetadata()["items"])
+	require.Equal(t, map[string]string{
+		"resource_metrics[0].scope_metrics[0].metrics[0].data":                                        "metric data is not set",
+		"resource_metrics[0].scope_metrics[0].metrics[1].histogram.aggregation_temporality":           "aggregation temporality is unspecified",
+		"resource_metrics[0].scope_metrics[0].metrics[1].histogram.data_points[0].bucket_counts":      "must have 3 buckets for 2 explicit bounds, got 2",
+		"resource_metrics[0].scope_metrics[0].metrics[1].histogram.data_points[0].explicit_bounds[1]": "explicit bounds must be strictly increasing",
+		"resource_metrics[0].scope_metrics[0].metrics[1].histogram.data_points[0].count":              "count 3 is not the sum of the bucket counts 2",
+	}, violations)
+}
+
+func TestConformanceHandler_Logs(t *testing.T) {
+	mux := otlp.NewServerMux()
+	h := otlp.NewConformanceHandler()
+	mux.Logs().Handle(h)
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	err = client.UploadLogs(ctx, []*otlp.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{
+			LogRecords: []*logspb.LogRecord{
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_INFO, TraceId: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+				{SeverityNumber: logspb.SeverityNumber(100), SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+			},
+		}},
+	}})
+	info, violations := conformanceDetails(t, err)
+	require.Equal(t, "logs", info.GetMetadata()["signal"])
+	require.Equal(t, "2", info.GetMetadata()["items"])
+	require.Equal(t, map[string]string{
+		"resource_logs[0].scope_logs[0].log_records[1].trace_id":        "trace id is required with span id",
+		"resource_logs[0].scope_logs[0].log_records[1].severity_number": "unknown severity number 100",
+	}, violations)
+
+	// the summary of the conforming requests is echoed back as the message of the partial success.
+	resp, err := h.HandleLogs(ctx, &otlp.LogsRequest{ResourceLogs: []*otlp.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN}}}},
+	}}})
+	require.NoError(t, err)
+	require.Zero(t, resp.GetPartialSuccess().GetRejectedLogRecords())
+	require.Equal(t, "conformance: 1 resources, 1 scopes, 1 log records, 0 attributes, 0 violations", resp.GetPartialSuccess().GetErrorMessage())
+}
@@ -0,0 +1,151 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	connectStreamContentTypePrefix = "application/connect+"
+	connectEndStreamFlag           = 0x02
+)
+
+// isConnect reports whether the request is an export of the Connect protocol, unary (application/proto and application/json)
+// or streaming (application/connect+proto and application/connect+json), to /{service}/Export of the OTLP services.
+func (mux *ServerMux) isConnect(r *http.Request) bool {
+	if r.Method != http.MethodPost || !strings.HasPrefix(strings.TrimPrefix(r.URL.Path, mux.pathPrefix), "/opentelemetry.proto.collector.") {
+		return false
+	}
+	_, ok := connectCodec(r.Header.Get("Content-Type"))
+	return ok
+}
+
+type connectCodecFuncs struct {
+	marshal   func(proto.Message) ([]byte, error)
+	unmarshal func([]byte, proto.Message) error
+}
+
+// connectCodec returns the codec of the content type. the JSON of Connect is the canonical protojson, not the OTLP/JSON with hex ids.
+func connectCodec(contentType string) (connectCodecFuncs, bool) {
+	switch strings.TrimPrefix(contentType, connectStreamContentTypePrefix) {
+	case "proto", "application/proto":
+		return connectCodecFuncs{marshal: proto.Marshal, unmarshal: proto.Unmarshal}, true
+	case "json", "application/json":
+		return connectCodecFuncs{
+			marshal:   protojson.Marshal,
+			unmarshal: protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal,
+		}, true
+	}
+	return connectCodecFuncs{}, false
+}
+
+// serveConnect serves the export of the Connect protocol, e.g. by the clients with the connect-go stubs of the OTLP services.
+func (mux *ServerMux) serveConnect(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	codec, _ := connectCodec(contentType)
+	streaming := strings.HasPrefix(contentType, connectStreamContentTypePrefix)
+	limit := mux.maxBodySize.Load()
+	resp, err := func() (proto.Message, error) {
+		var msg []byte
+		if streaming {
+			body := io.Reader(r.Body)
+			if limit > 0 {
+				body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			var err error
+			if msg, err = readEnvelopedMessage(body, r.Header.Get("Connect-Content-Encoding"), connectEndStreamFlag, limit); err != nil {
+				return nil, connectReadError(w, err)
+			}
+		} else {
+			if err := decompressRequestBody(w, r, limit); err != nil {
+				return nil, status.Error(codes.Unimplemented, err.Error())
+			}
+			var err error
+			if msg, err = io.ReadAll(r.Body); err != nil {
+				return nil, connectReadError(w, err)
+			}
+		}
+		return mux.exportMethod(r.Context(), r.URL.Path, msg, codec.unmarshal)
+	}()
+	var data []byte
+	if err == nil {
+		data, err = codec.marshal(resp)
+	}
+	var st *status.Status
+	if err != nil {
+		st = statusFromError(err)
+		mux.getLogger().WarnContext(r.Context(), "Connect request failed", "code", st.Code().String(), "details", err)
+	}
+	var out []byte
+	switch {
+	case streaming:
+		var buf bytes.Buffer
+		end := []byte("{}")
+		if st != nil {
+			end, _ = json.Marshal(map[string]any{"error": connectErrorBody(st)})
+		} else {
+			writeEnvelope(&buf, 0, data)
+		}
+		writeEnvelope(&buf, connectEndStreamFlag, end)
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		out = buf.Bytes()
+	case st != nil:
+		out, _ = json.Marshal(connectErrorBody(st))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(connectHTTPStatus(st.Code()))
+	default:
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		out = data
+	}
+	if _, err := w.Write(out); err != nil {
+		mux.getLogger().WarnContext(r.Context(), "failed to write response", "details", err)
+	}
+}
+
+func connectReadError(w http.ResponseWriter, err error) error {
+	if st, ok := tooLargeStatus(w, err); ok {
+		return st.Err()
+	}
+	return invalidEnvelopedMessage(err)
+}
+
+func connectErrorBody(st *status.Status) map[string]string {
+	return map[string]string{"code": connectCode(st.Code()), "message": st.Message()}
+}
+
+// connectCode returns the code of the Connect protocol, e.g. "invalid_argument" for codes.InvalidArgument.
+func connectCode(code codes.Code) string {
+	var sb strings.Builder
+	for i, c := range code.String() {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// connectHTTPStatus returns the HTTP status of the unary errors of the Connect protocol, which differs from grpcCodeToHTTPStatus in a few codes.
+func connectHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.Canceled:
+		return 499
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	}
+	return grpcCodeToHTTPStatus(code)
+}
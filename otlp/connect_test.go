@@ -0,0 +1,112 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMux__Connect(t *testing.T) {
+	expected := &otlp.TraceRequest{ResourceSpans: newSpans(2)}
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		assertEqualMessage(t, expected, req)
+		return otlp.NewTracePartialSuccess(1, "rejected"), nil
+	})
+	const tracePath = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	post := func(path, contentType string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Connect-Protocol-Version", "1")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	protoBody, err := proto.Marshal(expected)
+	require.NoError(t, err)
+	jsonBody, err := protojson.Marshal(expected)
+	require.NoError(t, err)
+
+	t.Run("unary proto", func(t *testing.T) {
+		w := post(tracePath, "application/proto", protoBody)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/proto", w.Header().Get("Content-Type"))
+		var resp otlp.TraceResponse
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &resp))
+		require.EqualValues(t, 1, resp.GetPartialSuccess().GetRejectedSpans())
+	})
+	t.Run("unary json", func(t *testing.T) {
+		w := post(tracePath, "application/json", jsonBody)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp otlp.TraceResponse
+		require.NoError(t, protojson.Unmarshal(w.Body.Bytes(), &resp))
+		require.EqualValues(t, 1, resp.GetPartialSuccess().GetRejectedSpans())
+	})
+	t.Run("unary error", func(t *testing.T) {
+		w := post("/opentelemetry.proto.collector.logs.v1.LogsService/Export", "application/proto", nil)
+		require.Equal(t, http.StatusNotImplemented, w.Code)
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, "unimplemented", body["code"])
+	})
+	t.Run("streaming", func(t *testing.T) {
+		for _, c := range []struct {
+			contentType string
+			body        []byte
+			unmarshal   func([]byte, proto.Message) error
+		}{
+			{"application/connect+proto", protoBody, proto.Unmarshal},
+			{"application/connect+json", jsonBody, protojson.Unmarshal},
+		} {
+			w := post(tracePath, c.contentType, grpcWebFrame(0, c.body))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, c.contentType, w.Header().Get("Content-Type"))
+			body := w.Body.Bytes()
+			require.Equal(t, byte(0), body[0])
+			n := binary.BigEndian.Uint32(body[1:5])
+			var resp otlp.TraceResponse
+			require.NoError(t, c.unmarshal(body[5:5+n], &resp))
+			require.EqualValues(t, 1, resp.GetPartialSuccess().GetRejectedSpans())
+			end := body[5+n:]
+			require.Equal(t, byte(0x02), end[0])
+			require.JSONEq(t, "{}", string(end[5:]))
+		}
+	})
+	t.Run("streaming error", func(t *testing.T) {
+		w := post(tracePath, "application/connect+proto", []byte{0})
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.Bytes()
+		require.Equal(t, byte(0x02), body[0])
+		var end struct {
+			Error map[string]string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(body[5:], &end))
+		require.Equal(t, "invalid_argument", end.Error["code"])
+	})
+	t.Run("streaming too large", func(t *testing.T) {
+		mux.SetMaxRequestBodySize(16)
+		defer mux.SetMaxRequestBodySize(0)
+		w := post(tracePath, "application/connect+proto", grpcWebFrame(0, protoBody))
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.Bytes()
+		require.Equal(t, byte(0x02), body[0])
+		var end struct {
+			Error map[string]string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(body[5:], &end))
+		require.Equal(t, "resource_exhausted", end.Error["code"])
+	})
+	t.Run("not connect", func(t *testing.T) {
+		w := post("/unknown", "application/json", jsonBody)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
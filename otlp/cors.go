@@ -0,0 +1,48 @@
+package otlp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsExposedHeaders are the response headers that the browsers expose to the exporters, for the throttling, the request id and the gRPC-Web status.
+var corsExposedHeaders = strings.Join([]string{"Retry-After", RequestIDHeader, "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}, ", ")
+
+// WithCORS enables CORS for the browsers to export to the mux from the origins, e.g. "https://app.example.com", or "*" for any origin.
+// the preflight requests are answered with the requested headers allowed, and the responses expose Retry-After and the gRPC-Web status headers.
+// the requests from the other origins are served without the CORS headers, so that the browsers block them.
+func WithCORS(origins ...string) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.corsOrigin = append(mux.corsOrigin, origins...)
+	}
+}
+
+func (mux *ServerMux) allowOrigin(origin string) bool {
+	return origin != "" && (slices.Contains(mux.corsOrigin, "*") || slices.Contains(mux.corsOrigin, origin))
+}
+
+// serveCORS sets the CORS headers of the request, and reports whether it is a preflight request that has been answered.
+func (mux *ServerMux) serveCORS(w http.ResponseWriter, r *http.Request) bool {
+	if len(mux.corsOrigin) == 0 {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if !mux.allowOrigin(origin) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		return false
+	}
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,102 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServerMux_CORS(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux(otlp.WithCORS("https://app.example.com"))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		w := preflight("https://app.example.com")
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "content-type", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+	t.Run("preflight from other origin", func(t *testing.T) {
+		w := preflight("https://evil.example.com")
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+	t.Run("export", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Retry-After")
+		require.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+}
+
+func TestServerMux_HTTP_MethodNotAllowed(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	serve := func(method, path, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("options", func(t *testing.T) {
+		w := serve(http.MethodOptions, "/v1/traces", "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+	})
+	t.Run("get", func(t *testing.T) {
+		w := serve(http.MethodGet, "/v1/traces", "")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+		require.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	})
+	t.Run("put with protobuf", func(t *testing.T) {
+		w := serve(http.MethodPut, "/v1/traces", "application/x-protobuf")
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+		var st spb.Status
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &st))
+		require.EqualValues(t, codes.Unimplemented, st.GetCode())
+	})
+	t.Run("not found with json", func(t *testing.T) {
+		w := serve(http.MethodPost, "/v1/unknown", "application/json")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var st spb.Status
+		require.NoError(t, otlp.UnmarshalJSON(w.Body.Bytes(), &st))
+		require.EqualValues(t, codes.NotFound, st.GetCode())
+	})
+}
@@ -0,0 +1,137 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxPooledBufferSize is the capacity over which the decode buffers are not returned to the pool,
+// so that a few huge requests do not keep the memory.
+const maxPooledBufferSize = 4 << 20
+
+var decodeBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getDecodeBuffer() *bytes.Buffer {
+	return decodeBufferPool.Get().(*bytes.Buffer)
+}
+
+func putDecodeBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	decodeBufferPool.Put(buf)
+}
+
+// decodeJSONStream decodes OTLP JSON from r to msg as UnmarshalJSON does, but element by element of the repeated message fields of the top level,
+// e.g. resourceSpans, so that the memory is proportional to the largest element instead of 2-3x of the whole body.
+func decodeJSONStream(r io.Reader, msg proto.Message) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	m := msg.ProtoReflect()
+	proto.Reset(msg)
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", token)
+		}
+		fd := m.Descriptor().Fields().ByJSONName(key)
+		if fd == nil {
+			fd = m.Descriptor().Fields().ByName(protoreflect.Name(key))
+		}
+		if fd != nil && fd.IsList() && fd.Message() != nil {
+			if err := decodeJSONList(dec, m.Mutable(fd).List()); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			continue
+		}
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		// the other fields are small, unmarshal them as an object of the single field and merge.
+		field := m.New().Interface()
+		if err := unmarshalJSONValue(map[string]any{key: v}, field); err != nil {
+			return err
+		}
+		proto.Merge(msg, field)
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	// read until EOF, for the errors of the reader such as the body size limit.
+	switch _, err := dec.Token(); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return fmt.Errorf("unexpected data after top-level value")
+	default:
+		return err
+	}
+}
+
+func decodeJSONList(dec *json.Decoder, list protoreflect.List) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("unexpected token %v, expected [", token)
+	}
+	for dec.More() {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		elem := list.NewElement()
+		if err := unmarshalJSONValue(v, elem.Message().Interface()); err != nil {
+			return err
+		}
+		list.Append(elem)
+	}
+	return expectDelim(dec, ']')
+}
+
+func unmarshalJSONValue(v any, msg proto.Message) error {
+	buf := getDecodeBuffer()
+	defer putDecodeBuffer(buf)
+	v, err := convertTraceIDAndSpanIDHexToBase64ForAny(v)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	return defaultUnmarshalOptions.Unmarshal(buf.Bytes(), msg)
+}
+
+func expectDelim(dec *json.Decoder, expected json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("unexpected token %v, expected %v", token, expected)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerMux_HTTP_JSONStreamDecode(t *testing.T) {
+	requests := make(chan *otlp.TraceRequest, 1)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		requests <- req
+		return &otlp.TraceResponse{}, nil
+	})
+	post := func(t *testing.T, body []byte) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("testdata", func(t *testing.T) {
+		for _, name := range []string{"testdata/trace.json", "testdata/trace2.json"} {
+			data, err := os.ReadFile(name)
+			require.NoError(t, err)
+			var expected otlp.TraceRequest
+			require.NoError(t, otlp.UnmarshalJSON(data, &expected))
+			require.Equal(t, http.StatusOK, post(t, data), name)
+			assertEqualMessage(t, &expected, <-requests)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post(t, []byte(`{"resourceSpans":null}`)))
+		require.Empty(t, (<-requests).GetResourceSpans())
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post(t, []byte(`{"resourceSpans":[{"unknown":1}],"unknown":1}`)))
+		require.Len(t, (<-requests).GetResourceSpans(), 1)
+	})
+	for name, body := range map[string]string{
+		"invalid hex":     `{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"not hex"}]}]}]}`,
+		"not an array":    `{"resourceSpans":{}}`,
+		"invalid element": `{"resourceSpans":[{"resource":1}]}`,
+		"trailing data":   `{"resourceSpans":[]} {}`,
+		"truncated":       `{"resourceSpans":[{}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, http.StatusBadRequest, post(t, []byte(body)))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package otlp
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DeduplicationMiddleware returns a middleware that drops the export requests with the same payload as one accepted within window,
+// to guard the at-least-once sinks against double ingestion by the retry storms of the clients.
+// the payloads are compared by SHA-256 of the deterministic marshaling, and the duplicates get the response of the original without calling the handler.
+// a duplicate of an in-flight request waits for it, and is handled as usual if it fails. window zero or less disables the deduplication.
+func DeduplicationMiddleware(window time.Duration) MiddlewareFunc {
+	if window <= 0 {
+		return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+			return next
+		}
+	}
+	d := &deduplicator{window: window, entries: make(map[[sha256.Size]byte]*dedupEntry)}
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+			if err != nil {
+				return next(ctx, req)
+			}
+			signalType, _ := requestItems(req)
+			key := sha256.Sum256(append([]byte(signalType+"\x00"), data...))
+			for {
+				e, owner := d.acquire(key, time.Now())
+				if owner {
+					resp, err := next(ctx, req)
+					d.release(key, e, resp, err, time.Now())
+					return resp, err
+				}
+				select {
+				case <-e.done:
+				case <-ctx.Done():
+					return nil, status.FromContextError(ctx.Err()).Err()
+				}
+				if e.accepted() {
+					return proto.Clone(e.resp), nil
+				}
+				// the original failed and is forgotten, retry as a new request.
+			}
+		}
+	}
+}
+
+type dedupEntry struct {
+	done    chan struct{}
+	resp    proto.Message
+	err     error
+	expires time.Time
+}
+
+func (e *dedupEntry) accepted() bool {
+	return e.err == nil && e.resp != nil
+}
+
+type deduplicator struct {
+	mu        sync.Mutex
+	window    time.Duration
+	entries   map[[sha256.Size]byte]*dedupEntry
+	lastPrune time.Time
+}
+
+// acquire returns the entry of key, and whether the caller owns it, i.e. it is newly created and the caller must release it.
+func (d *deduplicator) acquire(key [sha256.Size]byte, now time.Time) (*dedupEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(now)
+	if e, ok := d.entries[key]; ok {
+		select {
+		case <-e.done:
+			if now.Before(e.expires) {
+				return e, false
+			}
+		default:
+			return e, false
+		}
+	}
+	e := &dedupEntry{done: make(chan struct{})}
+	d.entries[key] = e
+	return e, true
+}
+
+func (d *deduplicator) release(key [sha256.Size]byte, e *dedupEntry, resp proto.Message, err error, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e.resp, e.err = resp, err
+	e.expires = now.Add(d.window)
+	if !e.accepted() && d.entries[key] == e {
+		delete(d.entries, key)
+	}
+	close(e.done)
+}
+
+// prune removes the expired entries at most once per window.
+func (d *deduplicator) prune(now time.Time) {
+	if now.Sub(d.lastPrune) < d.window {
+		return
+	}
+	d.lastPrune = now
+	for key, e := range d.entries {
+		select {
+		case <-e.done:
+			if !now.Before(e.expires) {
+				delete(d.entries, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeduplicationMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	h := otlp.DeduplicationMiddleware(100 * time.Millisecond)(func(_ context.Context, _ proto.Message) (proto.Message, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return nil, errors.New("failed")
+		}
+		return otlp.NewTracePartialSuccess(1, "rejected"), nil
+	})
+	ctx := context.Background()
+
+	_, err := h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+	require.NoError(t, err)
+	resp, err := h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, calls.Load(), "the duplicate is dropped")
+	assertEqualMessage(t, proto.Message(otlp.NewTracePartialSuccess(1, "rejected")), resp)
+
+	_, err = h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(3)})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, calls.Load(), "the other payload is handled")
+
+	fail.Store(true)
+	for i := 0; i < 2; i++ {
+		_, err = h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(4)})
+		require.Error(t, err)
+	}
+	require.EqualValues(t, 4, calls.Load(), "the failed request is not remembered")
+	fail.Store(false)
+
+	time.Sleep(150 * time.Millisecond)
+	_, err = h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+	require.NoError(t, err)
+	require.EqualValues(t, 5, calls.Load(), "the duplicate after the window is handled")
+}
+
+func TestDeduplicationMiddleware_InFlight(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := otlp.DeduplicationMiddleware(time.Minute)(func(_ context.Context, _ proto.Message) (proto.Message, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	ctx := context.Background()
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := h(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+			results <- err
+		}()
+	}
+	<-started
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-results)
+	require.NoError(t, <-results)
+	require.EqualValues(t, 1, calls.Load(), "the duplicate waits for the in-flight request")
+}
@@ -0,0 +1,80 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNoDefaultClient is returned by the package-level upload functions when SetDefaultClient has not been called.
+var ErrNoDefaultClient = errors.New("default client is not set")
+
+var defaultClient atomic.Pointer[Client]
+
+// SetDefaultClient sets the client used by the package-level UploadTraces, UploadMetrics and UploadLogs.
+// the client must be started by the caller. nil unsets the default client.
+func SetDefaultClient(c *Client) {
+	defaultClient.Store(c)
+}
+
+// DefaultClient returns the client set by SetDefaultClient, or nil.
+func DefaultClient() *Client {
+	return defaultClient.Load()
+}
+
+// UploadTraces uploads the spans with the default client.
+func UploadTraces(ctx context.Context, protoSpans []*ResourceSpans) error {
+	c := DefaultClient()
+	if c == nil {
+		return ErrNoDefaultClient
+	}
+	return c.UploadTraces(ctx, protoSpans)
+}
+
+// UploadMetrics uploads the metrics with the default client.
+func UploadMetrics(ctx context.Context, protoMetrics []*ResourceMetrics) error {
+	c := DefaultClient()
+	if c == nil {
+		return ErrNoDefaultClient
+	}
+	return c.UploadMetrics(ctx, protoMetrics)
+}
+
+// UploadLogs uploads the logs with the default client.
+func UploadLogs(ctx context.Context, protoLogs []*ResourceLogs) error {
+	c := DefaultClient()
+	if c == nil {
+		return ErrNoDefaultClient
+	}
+	return c.UploadLogs(ctx, protoLogs)
+}
+
+// HandleTrace registers the trace handler in DefaultServerMux.
+func HandleTrace(handler TraceHandler) {
+	DefaultServerMux.Trace().Handle(handler)
+}
+
+// HandleTraceFunc registers the trace handler function in DefaultServerMux.
+func HandleTraceFunc(handler func(ctx context.Context, request *TraceRequest) (*TraceResponse, error)) {
+	DefaultServerMux.Trace().HandleFunc(handler)
+}
+
+// HandleMetrics registers the metrics handler in DefaultServerMux.
+func HandleMetrics(handler MetricsHandler) {
+	DefaultServerMux.Metrics().Handle(handler)
+}
+
+// HandleMetricsFunc registers the metrics handler function in DefaultServerMux.
+func HandleMetricsFunc(handler func(ctx context.Context, request *MetricsRequest) (*MetricsResponse, error)) {
+	DefaultServerMux.Metrics().HandleFunc(handler)
+}
+
+// HandleLogs registers the logs handler in DefaultServerMux.
+func HandleLogs(handler LogsHandler) {
+	DefaultServerMux.Logs().Handle(handler)
+}
+
+// HandleLogsFunc registers the logs handler function in DefaultServerMux.
+func HandleLogsFunc(handler func(ctx context.Context, request *LogsRequest) (*LogsResponse, error)) {
+	DefaultServerMux.Logs().HandleFunc(handler)
+}
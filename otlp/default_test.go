@@ -0,0 +1,44 @@
+package otlp_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultClientAndServerMux(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.ErrorIs(t, otlp.UploadTraces(ctx, nil), otlp.ErrNoDefaultClient)
+
+	var traces, logs atomic.Int32
+	otlp.HandleTraceFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		traces.Add(1)
+		return &otlp.TraceResponse{}, nil
+	})
+	otlp.HandleLogsFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		logs.Add(1)
+		return &otlp.LogsResponse{}, nil
+	})
+	server := httptest.NewServer(otlp.DefaultServerMux)
+	defer server.Close()
+
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	otlp.SetDefaultClient(client)
+	t.Cleanup(func() { otlp.SetDefaultClient(nil) })
+	require.Same(t, client, otlp.DefaultClient())
+
+	require.NoError(t, otlp.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.NoError(t, otlp.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.Error(t, otlp.UploadMetrics(ctx, []*otlp.ResourceMetrics{}), "no metrics handler is registered")
+	require.EqualValues(t, 1, traces.Load())
+	require.EqualValues(t, 1, logs.Load())
+}
@@ -0,0 +1,173 @@
+package otlp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// FieldDiff is a changed field of a span.
+type FieldDiff struct {
+	Field    string
+	Expected any
+	Actual   any
+}
+
+// SpanDiff is the changed fields of a span that exists in both captures.
+type SpanDiff struct {
+	Key    string
+	Fields []FieldDiff
+}
+
+// TraceDiff is the result of DiffTraces.
+type TraceDiff struct {
+	// Missing is the keys of the spans that are only in expected.
+	Missing []string
+	// Extra is the keys of the spans that are only in actual.
+	Extra []string
+	// Changed is the spans whose fields are changed.
+	Changed []SpanDiff
+}
+
+// Empty reports whether there is no difference.
+func (d *TraceDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// String returns a human-readable report of the difference.
+func (d *TraceDiff) String() string {
+	if d.Empty() {
+		return "no difference"
+	}
+	var b strings.Builder
+	for _, key := range d.Missing {
+		fmt.Fprintf(&b, "- missing span: %s\n", key)
+	}
+	for _, key := range d.Extra {
+		fmt.Fprintf(&b, "+ extra span: %s\n", key)
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(&b, "~ changed span: %s\n", change.Key)
+		for _, f := range change.Fields {
+			fmt.Fprintf(&b, "    %s: %v -> %v\n", f.Field, f.Expected, f.Actual)
+		}
+	}
+	return b.String()
+}
+
+// DiffTraces compares two telemetry captures, e.g. for contract tests that compare service telemetry between releases.
+// trace ids, span ids and timestamps differ between captures, so spans are matched by service.name, scope name, span name and the order of appearance,
+// and the parent is compared by the name of the parent span.
+// the compared fields are kind, status_code, status_message, parent_name, scope_version, events (names), attributes.<key> and resource_attributes.<key>.
+// ignoreFields skips the fields, a field is also skipped by its prefix, e.g. "resource_attributes" skips all resource attributes.
+func DiffTraces(expected, actual []*tracepb.ResourceSpans, ignoreFields ...string) *TraceDiff {
+	expectedSpans, expectedKeys := diffableSpans(expected)
+	actualSpans, actualKeys := diffableSpans(actual)
+	diff := &TraceDiff{}
+	for _, key := range expectedKeys {
+		a, ok := actualSpans[key]
+		if !ok {
+			diff.Missing = append(diff.Missing, key)
+			continue
+		}
+		var fields []FieldDiff
+		e := expectedSpans[key]
+		for _, name := range unionKeys(e, a) {
+			if ignoredField(name, ignoreFields) {
+				continue
+			}
+			ev, eok := e[name]
+			av, aok := a[name]
+			if eok && aok && reflect.DeepEqual(ev, av) {
+				continue
+			}
+			fields = append(fields, FieldDiff{Field: name, Expected: ev, Actual: av})
+		}
+		if len(fields) > 0 {
+			diff.Changed = append(diff.Changed, SpanDiff{Key: key, Fields: fields})
+		}
+	}
+	for _, key := range actualKeys {
+		if _, ok := expectedSpans[key]; !ok {
+			diff.Extra = append(diff.Extra, key)
+		}
+	}
+	return diff
+}
+
+func ignoredField(name string, ignoreFields []string) bool {
+	for _, ignore := range ignoreFields {
+		if name == ignore || strings.HasPrefix(name, ignore+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// diffableSpans returns the comparable fields of the spans by key, and the keys in the order of appearance.
+func diffableSpans(src []*tracepb.ResourceSpans) (map[string]map[string]any, []string) {
+	flat := FlattenResourceSpans(src)
+	names := make(map[string]string, len(flat))
+	for _, span := range flat {
+		names[span.TraceID+span.SpanID] = span.Name
+	}
+	spans := make(map[string]map[string]any, len(flat))
+	keys := make([]string, 0, len(flat))
+	seen := make(map[string]int, len(flat))
+	for _, span := range flat {
+		service, _ := span.ResourceAttributes["service.name"].(string)
+		base := fmt.Sprintf("%s/%s/%s", service, span.ScopeName, span.Name)
+		key := fmt.Sprintf("%s#%d", base, seen[base])
+		seen[base]++
+		fields := map[string]any{
+			"kind":        span.Kind,
+			"status_code": span.StatusCode,
+		}
+		if span.StatusMessage != "" {
+			fields["status_message"] = span.StatusMessage
+		}
+		if span.ParentSpanID != "" {
+			parent, ok := names[span.TraceID+span.ParentSpanID]
+			if !ok {
+				parent = PlaceholderSpanName
+			}
+			fields["parent_name"] = parent
+		}
+		if span.ScopeVersion != "" {
+			fields["scope_version"] = span.ScopeVersion
+		}
+		if len(span.Events) > 0 {
+			events := make([]string, 0, len(span.Events))
+			for _, event := range span.Events {
+				events = append(events, event.Name)
+			}
+			fields["events"] = events
+		}
+		for k, v := range span.Attributes {
+			fields["attributes."+k] = v
+		}
+		for k, v := range span.ResourceAttributes {
+			fields["resource_attributes."+k] = v
+		}
+		spans[key] = fields
+		keys = append(keys, key)
+	}
+	return spans, keys
+}
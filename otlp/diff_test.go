@@ -0,0 +1,81 @@
+package otlp_test
+
+import (
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func newDiffCapture(traceID byte, httpRoute string, extra bool) []*tracepb.ResourceSpans {
+	tid := []byte{traceID, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	spans := []*tracepb.Span{
+		{
+			TraceId: tid,
+			SpanId:  []byte{traceID, 0, 0, 0, 0, 0, 0, 1},
+			Name:    "GET /users",
+			Kind:    tracepb.Span_SPAN_KIND_SERVER,
+			Attributes: []*commonpb.KeyValue{
+				{Key: "http.route", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: httpRoute}}},
+			},
+		},
+		{
+			TraceId:      tid,
+			SpanId:       []byte{traceID, 0, 0, 0, 0, 0, 0, 2},
+			ParentSpanId: []byte{traceID, 0, 0, 0, 0, 0, 0, 1},
+			Name:         "SELECT users",
+			Kind:         tracepb.Span_SPAN_KIND_CLIENT,
+		},
+	}
+	if extra {
+		spans = append(spans, &tracepb.Span{
+			TraceId:      tid,
+			SpanId:       []byte{traceID, 0, 0, 0, 0, 0, 0, 3},
+			ParentSpanId: []byte{traceID, 0, 0, 0, 0, 0, 0, 1},
+			Name:         "GET cache",
+			Kind:         tracepb.Span_SPAN_KIND_CLIENT,
+		})
+	}
+	return []*tracepb.ResourceSpans{
+		{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{
+					{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "api"}}},
+				},
+			},
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{Scope: &commonpb.InstrumentationScope{Name: "net/http"}, Spans: spans},
+			},
+		},
+	}
+}
+
+func TestDiffTraces(t *testing.T) {
+	// the trace ids are different, but the spans are matched by name.
+	diff := otlp.DiffTraces(newDiffCapture(1, "/users", false), newDiffCapture(2, "/users", false))
+	require.True(t, diff.Empty(), diff.String())
+
+	diff = otlp.DiffTraces(newDiffCapture(1, "/users", false), newDiffCapture(2, "/v2/users", true))
+	require.False(t, diff.Empty())
+	require.Empty(t, diff.Missing)
+	require.Equal(t, []string{"api/net/http/GET cache#0"}, diff.Extra)
+	require.Equal(t, []otlp.SpanDiff{
+		{
+			Key: "api/net/http/GET /users#0",
+			Fields: []otlp.FieldDiff{
+				{Field: "attributes.http.route", Expected: "/users", Actual: "/v2/users"},
+			},
+		},
+	}, diff.Changed)
+	require.Equal(t, `+ extra span: api/net/http/GET cache#0
+~ changed span: api/net/http/GET /users#0
+    attributes.http.route: /users -> /v2/users
+`, diff.String())
+
+	diff = otlp.DiffTraces(newDiffCapture(1, "/users", true), newDiffCapture(2, "/v2/users", false), "attributes")
+	require.Equal(t, []string{"api/net/http/GET cache#0"}, diff.Missing)
+	require.Empty(t, diff.Changed)
+}
@@ -0,0 +1,241 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// FileExporterRecord is a record read from the file written by the OpenTelemetry Collector fileexporter.
+// exactly one of Traces, Metrics and Logs is set.
+type FileExporterRecord struct {
+	Traces  *TraceRequest
+	Metrics *MetricsRequest
+	Logs    *LogsRequest
+}
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// FileExporterReader reads OTLP data exported by the OpenTelemetry Collector fileexporter.
+// it understands the JSON lines format, and the length prefixed format used with format: proto or compression: zstd.
+// gzip compressed rotated files are also supported.
+type FileExporterReader struct {
+	r      *bufio.Reader
+	closer io.Closer
+	signal string
+	zstd   *zstd.Decoder
+	lines  *bool
+}
+
+// NewFileExporterReader returns a new FileExporterReader that reads from r.
+func NewFileExporterReader(r io.Reader) *FileExporterReader {
+	return &FileExporterReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// SetSignal sets the signal type of the file, "traces", "metrics" or "logs".
+// it is required for format: proto, because the signal type can not be detected from protobuf payloads.
+func (r *FileExporterReader) SetSignal(signal string) error {
+	switch signal {
+	case "traces", "metrics", "logs":
+		r.signal = signal
+		return nil
+	}
+	return fmt.Errorf("signal %q is not supported", signal)
+}
+
+// Close releases the resources used by the reader. it does not close the underlying reader.
+func (r *FileExporterReader) Close() error {
+	if r.zstd != nil {
+		r.zstd.Close()
+	}
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+func (r *FileExporterReader) init() error {
+	if r.lines != nil {
+		return nil
+	}
+	head, err := r.r.Peek(len(gzipMagic))
+	if err != nil && len(head) == 0 {
+		return err
+	}
+	if bytes.Equal(head, gzipMagic) {
+		gr, err := gzip.NewReader(r.r)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip: %w", err)
+		}
+		r.closer = gr
+		r.r = bufio.NewReader(gr)
+	}
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		// skip blank lines
+		if b[0] == '\n' || b[0] == '\r' {
+			if _, err := r.r.Discard(1); err != nil {
+				return err
+			}
+			continue
+		}
+		r.lines = ptr(b[0] == '{')
+		return nil
+	}
+}
+
+// Read reads the next record. it returns io.EOF when no more records are available.
+func (r *FileExporterReader) Read() (*FileExporterRecord, error) {
+	if err := r.init(); err != nil {
+		return nil, err
+	}
+	if *r.lines {
+		return r.readLine()
+	}
+	return r.readBuffer()
+}
+
+func (r *FileExporterReader) readLine() (*FileExporterRecord, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return r.decode(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *FileExporterReader) readBuffer() (*FileExporterRecord, error) {
+	var size uint32
+	if err := binary.Read(r.r, binary.BigEndian, &size); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated message size: %w", err)
+		}
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		return nil, fmt.Errorf("truncated message: %w", err)
+	}
+	if bytes.HasPrefix(buf, zstdMagic) {
+		if r.zstd == nil {
+			dec, err := zstd.NewReader(nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+			}
+			r.zstd = dec
+		}
+		decoded, err := r.zstd.DecodeAll(buf, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd message: %w", err)
+		}
+		buf = decoded
+	}
+	return r.decode(buf)
+}
+
+func (r *FileExporterReader) decode(data []byte) (*FileExporterRecord, error) {
+	if len(data) > 0 && data[0] == '{' {
+		return decodeFileExporterJSON(data)
+	}
+	record := &FileExporterRecord{}
+	var msg proto.Message
+	switch r.signal {
+	case "traces":
+		record.Traces = &TraceRequest{}
+		msg = record.Traces
+	case "metrics":
+		record.Metrics = &MetricsRequest{}
+		msg = record.Metrics
+	case "logs":
+		record.Logs = &LogsRequest{}
+		msg = record.Logs
+	default:
+		return nil, errors.New("signal is required to read protobuf format, see SetSignal")
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", r.signal, err)
+	}
+	return record, nil
+}
+
+func decodeFileExporterJSON(data []byte) (*FileExporterRecord, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	record := &FileExporterRecord{}
+	var msg proto.Message
+	switch {
+	case keys["resourceSpans"] != nil || keys["resource_spans"] != nil:
+		record.Traces = &TraceRequest{}
+		msg = record.Traces
+	case keys["resourceMetrics"] != nil || keys["resource_metrics"] != nil:
+		record.Metrics = &MetricsRequest{}
+		msg = record.Metrics
+	case keys["resourceLogs"] != nil || keys["resource_logs"] != nil:
+		record.Logs = &LogsRequest{}
+		msg = record.Logs
+	default:
+		return nil, errors.New("unknown signal type in json")
+	}
+	if err := UnmarshalJSON(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+	}
+	return record, nil
+}
+
+// FileExporterFiles returns the file exported by the fileexporter and its rotated backups, oldest first.
+// rotated backups are named like traces-2006-01-02T15-04-05.000.json, and optionally have .gz suffix.
+func FileExporterFiles(path string) ([]string, error) {
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !strings.HasSuffix(name, ext) && !strings.HasSuffix(name, ext+".gz") {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	// the timestamp format of the backups is lexically sortable.
+	slices.Sort(backups)
+	if _, err := os.Stat(path); err == nil {
+		backups = append(backups, path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return backups, nil
+}
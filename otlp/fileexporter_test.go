@@ -0,0 +1,109 @@
+package otlp_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func readFileExporterRecords(t *testing.T, r *otlp.FileExporterReader) []*otlp.FileExporterRecord {
+	t.Helper()
+	defer r.Close()
+	var records []*otlp.FileExporterRecord
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestFileExporterReader_JSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	for _, name := range []string{"testdata/trace.json", "testdata/metrics.json", "testdata/logs.json"} {
+		bs, err := os.ReadFile(name)
+		require.NoError(t, err)
+		var compacted bytes.Buffer
+		require.NoError(t, json.Compact(&compacted, bs))
+		buf.Write(compacted.Bytes())
+		buf.WriteString("\n")
+	}
+	records := readFileExporterRecords(t, otlp.NewFileExporterReader(&buf))
+	require.Len(t, records, 3)
+	require.NotNil(t, records[0].Traces)
+	require.Equal(t, 1, otlp.TotalSpans(records[0].Traces.GetResourceSpans()))
+	require.NotNil(t, records[1].Metrics)
+	require.NotNil(t, records[2].Logs)
+	require.Equal(t, 1, otlp.TotalLogRecords(records[2].Logs.GetResourceLogs()))
+}
+
+func TestFileExporterReader_ZstdProto(t *testing.T) {
+	bs, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var req otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(bs, &req))
+	payload, err := proto.Marshal(&req)
+	require.NoError(t, err)
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	compressed := enc.EncodeAll(payload, nil)
+
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		require.NoError(t, binary.Write(&buf, binary.BigEndian, uint32(len(compressed))))
+		buf.Write(compressed)
+	}
+	r := otlp.NewFileExporterReader(&buf)
+	require.NoError(t, r.SetSignal("traces"))
+	records := readFileExporterRecords(t, r)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assertEqualMessage(t, &req, record.Traces)
+	}
+}
+
+func TestFileExporterFiles(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := os.ReadFile("testdata/logs.json")
+	require.NoError(t, err)
+	var line bytes.Buffer
+	require.NoError(t, json.Compact(&line, bs))
+	line.WriteString("\n")
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err = gw.Write(line.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logs.json"), line.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logs-2024-01-02T00-00-00.000.json.gz"), gz.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logs-2024-01-01T00-00-00.000.json"), line.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "traces.json"), line.Bytes(), 0o644))
+
+	files, err := otlp.FileExporterFiles(filepath.Join(dir, "logs.json"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "logs-2024-01-01T00-00-00.000.json"),
+		filepath.Join(dir, "logs-2024-01-02T00-00-00.000.json.gz"),
+		filepath.Join(dir, "logs.json"),
+	}, files)
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		records := readFileExporterRecords(t, otlp.NewFileExporterReader(f))
+		require.NoError(t, f.Close())
+		require.Len(t, records, 1)
+		require.NotNil(t, records[0].Logs)
+	}
+}
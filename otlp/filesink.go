@@ -0,0 +1,310 @@
+package otlp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// FileSinkFormat is the format of the files written by FileSinkHandler.
+type FileSinkFormat int
+
+const (
+	// FileSinkJSONLines writes a request per line in OTLP JSON.
+	FileSinkJSONLines FileSinkFormat = iota
+	// FileSinkProtobuf writes requests in protobuf, each prefixed by its size as a big endian uint32,
+	// the same format as the fileexporter of the OpenTelemetry Collector with format: proto.
+	FileSinkProtobuf
+)
+
+type fileSinkOptions struct {
+	format           FileSinkFormat
+	gzip             bool
+	maxSize          int64
+	maxAge           time.Duration
+	tracesPartition  func(*ResourceSpans) string
+	metricsPartition func(*ResourceMetrics) string
+	logsPartition    func(*ResourceLogs) string
+}
+
+// FileSinkOption is the option for NewFileSinkHandler.
+type FileSinkOption func(*fileSinkOptions)
+
+// WithFileSinkFormat sets the format of the files. default is FileSinkJSONLines.
+func WithFileSinkFormat(format FileSinkFormat) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.format = format
+	}
+}
+
+// WithFileSinkGzip compresses the files with gzip. the gzip trailer is written when the file is rotated or the handler is closed.
+func WithFileSinkGzip(enabled bool) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.gzip = enabled
+	}
+}
+
+// WithFileSinkMaxSize rotates the file when its size before compression reaches maxSize bytes. zero means no limit.
+func WithFileSinkMaxSize(maxSize int64) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.maxSize = maxSize
+	}
+}
+
+// WithFileSinkMaxAge rotates the file when it is older than maxAge at the next write. zero means no limit.
+func WithFileSinkMaxAge(maxAge time.Duration) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithFileSinkTracesPartition writes the spans into the subdirectories named by the partition key, e.g. PartitionBySpanStartTime("2006/01/02", time.UTC).
+func WithFileSinkTracesPartition(getPartitionKey func(*ResourceSpans) string) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.tracesPartition = getPartitionKey
+	}
+}
+
+// WithFileSinkMetricsPartition writes the data points into the subdirectories named by the partition key, e.g. PartitionByMetricTime("2006/01/02", time.UTC).
+func WithFileSinkMetricsPartition(getPartitionKey func(*ResourceMetrics) string) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.metricsPartition = getPartitionKey
+	}
+}
+
+// WithFileSinkLogsPartition writes the log records into the subdirectories named by the partition key, e.g. PartitionByLogTime("2006/01/02", time.UTC).
+func WithFileSinkLogsPartition(getPartitionKey func(*ResourceLogs) string) FileSinkOption {
+	return func(o *fileSinkOptions) {
+		o.logsPartition = getPartitionKey
+	}
+}
+
+// FileSinkHandler is a handler of all signals that writes the requests into files under a directory.
+// the files are named like traces-2006-01-02T15-04-05.000.json, the naming of the rotated files of the fileexporter,
+// so they can be read with FileExporterFiles and FileExporterReader.
+type FileSinkHandler struct {
+	dir string
+	o   fileSinkOptions
+
+	mu     sync.Mutex
+	files  map[string]*fileSink
+	closed bool
+}
+
+var (
+	_ TraceHandler   = (*FileSinkHandler)(nil)
+	_ MetricsHandler = (*FileSinkHandler)(nil)
+	_ LogsHandler    = (*FileSinkHandler)(nil)
+)
+
+// NewFileSinkHandler returns a new FileSinkHandler writing into dir. the directory is created if it does not exist.
+func NewFileSinkHandler(dir string, opts ...FileSinkOption) (*FileSinkHandler, error) {
+	o := fileSinkOptions{
+		format: FileSinkJSONLines,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.format {
+	case FileSinkJSONLines, FileSinkProtobuf:
+	default:
+		return nil, fmt.Errorf("unknown file sink format %d", o.format)
+	}
+	if o.maxSize < 0 || o.maxAge < 0 {
+		return nil, errors.New("file sink rotation limits must not be negative")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSinkHandler{
+		dir:   dir,
+		o:     o,
+		files: make(map[string]*fileSink),
+	}, nil
+}
+
+// HandleTrace writes the trace request.
+func (h *FileSinkHandler) HandleTrace(_ context.Context, request *TraceRequest) (*TraceResponse, error) {
+	if h.o.tracesPartition == nil {
+		return &TraceResponse{}, h.write("traces", "", request)
+	}
+	partitions := PartitionResourceSpans(request.GetResourceSpans(), h.o.tracesPartition)
+	return &TraceResponse{}, ForEachPartition(context.Background(), partitions, func(_ context.Context, key string, partition []*ResourceSpans) error {
+		return h.write("traces", key, &TraceRequest{ResourceSpans: partition})
+	})
+}
+
+// HandleMetrics writes the metrics request.
+func (h *FileSinkHandler) HandleMetrics(_ context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+	if h.o.metricsPartition == nil {
+		return &MetricsResponse{}, h.write("metrics", "", request)
+	}
+	partitions := PartitionResourceMetrics(request.GetResourceMetrics(), h.o.metricsPartition)
+	return &MetricsResponse{}, ForEachPartition(context.Background(), partitions, func(_ context.Context, key string, partition []*ResourceMetrics) error {
+		return h.write("metrics", key, &MetricsRequest{ResourceMetrics: partition})
+	})
+}
+
+// HandleLogs writes the logs request.
+func (h *FileSinkHandler) HandleLogs(_ context.Context, request *LogsRequest) (*LogsResponse, error) {
+	if h.o.logsPartition == nil {
+		return &LogsResponse{}, h.write("logs", "", request)
+	}
+	partitions := PartitionResourceLogs(request.GetResourceLogs(), h.o.logsPartition)
+	return &LogsResponse{}, ForEachPartition(context.Background(), partitions, func(_ context.Context, key string, partition []*ResourceLogs) error {
+		return h.write("logs", key, &LogsRequest{ResourceLogs: partition})
+	})
+}
+
+// Close closes the files being written.
+func (h *FileSinkHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	var errs []error
+	for key, f := range h.files {
+		if err := f.close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(h.files, key)
+	}
+	return errors.Join(errs...)
+}
+
+// encodeSinkRecord encodes msg as a record of the format, a line of JSON or the size-prefixed protobuf.
+func encodeSinkRecord(format FileSinkFormat, msg proto.Message) ([]byte, error) {
+	if format == FileSinkJSONLines {
+		data, err := MarshalJSON(msg)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	return append(buf, data...), nil
+}
+
+func (h *FileSinkHandler) write(signalType string, partitionKey string, msg proto.Message) error {
+	data, err := encodeSinkRecord(h.o.format, msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", signalType, err)
+	}
+	subdir := filepath.FromSlash(partitionKey)
+	if partitionKey != "" && !filepath.IsLocal(subdir) {
+		return fmt.Errorf("partition key %q is not a local path", partitionKey)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errors.New("file sink handler is closed")
+	}
+	key := filepath.Join(subdir, signalType)
+	f, ok := h.files[key]
+	if ok && f.expired(h.o.maxSize, h.o.maxAge) {
+		delete(h.files, key)
+		if err := f.close(); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if !ok {
+		f, err = h.open(filepath.Join(h.dir, subdir), signalType)
+		if err != nil {
+			return err
+		}
+		h.files[key] = f
+	}
+	return f.write(data)
+}
+
+func (h *FileSinkHandler) open(dir string, signalType string) (*fileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	ext := ".json"
+	if h.o.format == FileSinkProtobuf {
+		ext = ".pb"
+	}
+	if h.o.gzip {
+		ext += ".gz"
+	}
+	opened := time.Now()
+	for {
+		name := filepath.Join(dir, signalType+"-"+opened.UTC().Format("2006-01-02T15-04-05.000")+ext)
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			// the name has the millisecond precision, so shift it to keep the names sorted by time.
+			opened = opened.Add(time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		f := &fileSink{
+			file:   file,
+			opened: time.Now(),
+		}
+		f.buf = bufio.NewWriter(file)
+		f.w = f.buf
+		if h.o.gzip {
+			f.gzip = gzip.NewWriter(f.buf)
+			f.w = f.gzip
+		}
+		return f, nil
+	}
+}
+
+type fileSink struct {
+	file   *os.File
+	buf    *bufio.Writer
+	gzip   *gzip.Writer
+	w      io.Writer
+	size   int64
+	opened time.Time
+}
+
+func (f *fileSink) expired(maxSize int64, maxAge time.Duration) bool {
+	if maxSize > 0 && f.size >= maxSize {
+		return true
+	}
+	return maxAge > 0 && time.Since(f.opened) >= maxAge
+}
+
+func (f *fileSink) write(data []byte) error {
+	n, err := f.w.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if f.gzip != nil {
+		// flush the compressed block, so that the written records survive a crash as far as possible.
+		if err := f.gzip.Flush(); err != nil {
+			return err
+		}
+	}
+	return f.buf.Flush()
+}
+
+func (f *fileSink) close() error {
+	var errs []error
+	if f.gzip != nil {
+		errs = append(errs, f.gzip.Close())
+	}
+	errs = append(errs, f.buf.Flush(), f.file.Close())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func readFileSinkSpans(t *testing.T, path string, signal string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	reader := otlp.NewFileExporterReader(f)
+	defer reader.Close()
+	if signal != "" {
+		require.NoError(t, reader.SetSignal(signal))
+	}
+	total := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return total
+		}
+		require.NoError(t, err)
+		total += otlp.TotalSpans(record.Traces.GetResourceSpans())
+	}
+}
+
+func TestFileSinkHandler_JSONLinesRotation(t *testing.T) {
+	dir := t.TempDir()
+	h, err := otlp.NewFileSinkHandler(dir, otlp.WithFileSinkMaxSize(1))
+	require.NoError(t, err)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+		require.NoError(t, err)
+	}
+	_, err = h.HandleLogs(ctx, &otlp.LogsRequest{})
+	require.NoError(t, err)
+	require.NoError(t, h.Close())
+
+	files, err := otlp.FileExporterFiles(filepath.Join(dir, "traces.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, file := range files {
+		require.Equal(t, 2, readFileSinkSpans(t, file, ""))
+	}
+	logs, err := otlp.FileExporterFiles(filepath.Join(dir, "logs.json"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{})
+	require.Error(t, err)
+}
+
+func TestFileSinkHandler_ProtobufGzipPartition(t *testing.T) {
+	dir := t.TempDir()
+	h, err := otlp.NewFileSinkHandler(dir,
+		otlp.WithFileSinkFormat(otlp.FileSinkProtobuf),
+		otlp.WithFileSinkGzip(true),
+		otlp.WithFileSinkTracesPartition(otlp.PartitionBySpanStartTime("2006/01/02", time.UTC)),
+	)
+	require.NoError(t, err)
+	_, err = h.HandleTrace(context.Background(), &otlp.TraceRequest{ResourceSpans: newSpansAt(
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC),
+	)})
+	require.NoError(t, err)
+	require.NoError(t, h.Close())
+
+	for day, expected := range map[string]int{"01": 1, "02": 2} {
+		files, err := otlp.FileExporterFiles(filepath.Join(dir, "2024", "01", day, "traces.pb"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		require.Equal(t, ".gz", filepath.Ext(files[0]))
+		require.Equal(t, expected, readFileSinkSpans(t, files[0], "traces"))
+	}
+}
+
+func TestFileSinkHandler_InvalidPartitionKey(t *testing.T) {
+	h, err := otlp.NewFileSinkHandler(t.TempDir(), otlp.WithFileSinkTracesPartition(func(*otlp.ResourceSpans) string {
+		return "../escape"
+	}))
+	require.NoError(t, err)
+	defer h.Close()
+	_, err = h.HandleTrace(context.Background(), &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.Error(t, err)
+}
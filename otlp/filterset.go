@@ -0,0 +1,310 @@
+package otlp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// FilterConfig is the configuration of a filter of FilterSet. the conditions set are combined with AND, the values of a condition with OR.
+type FilterConfig struct {
+	// ResourceAttributes keeps the records whose resource has the attributes, with one of the values if not empty, see ResourceAttributeFilter.
+	ResourceAttributes map[string][]string `yaml:"resource_attributes"`
+	// ScopeAttributes keeps the records whose instrumentation scope has the attributes, with one of the values if not empty, see ScopeAttributeFilter.
+	ScopeAttributes map[string][]string `yaml:"scope_attributes"`
+
+	// SpanName keeps the spans whose name matches one of the patterns, see SpanNameFilter.
+	SpanName []string `yaml:"span_name"`
+	// SpanKind keeps the spans of the kinds, e.g. SPAN_KIND_SERVER or server.
+	SpanKind []string `yaml:"span_kind"`
+	// SpanStatus keeps the spans of the status codes, e.g. STATUS_CODE_ERROR or error.
+	SpanStatus []string `yaml:"span_status"`
+	// SpanHasError keeps the spans with errors if true, or without errors if false, see SpanHasErrorFilter.
+	SpanHasError *bool `yaml:"span_has_error"`
+
+	// MetricName keeps the metrics whose name matches one of the patterns, * matches any characters.
+	MetricName []string `yaml:"metric_name"`
+
+	// LogSeverityMin and LogSeverityMax keep the log records of the severity range, e.g. SEVERITY_NUMBER_INFO, info or 9.
+	// either of them may be empty for the open range.
+	LogSeverityMin string `yaml:"log_severity_min"`
+	LogSeverityMax string `yaml:"log_severity_max"`
+	// LogSeverityText keeps the log records of the severity texts, see LogSeverityTextFilter.
+	LogSeverityText []string `yaml:"log_severity_text"`
+	// LogBody keeps the log records whose body matches the regular expression, see LogBodyMatchFilter.
+	LogBody string `yaml:"log_body"`
+
+	// Any keeps the records matching any of the filters.
+	Any []FilterConfig `yaml:"any"`
+	// Not keeps the records not matching the filter.
+	Not *FilterConfig `yaml:"not"`
+}
+
+// FilterSet is the named filters built from the configuration, e.g. the YAML or JSON file read by LoadFilterSet:
+//
+//	drop_health_checks:
+//	  not:
+//	    span_name: ["GET /health*", "GET /ready*"]
+//	errors_or_slow_services:
+//	  any:
+//	    - span_has_error: true
+//	    - resource_attributes:
+//	        service.name: [checkout, payment]
+//	warn_and_above:
+//	  log_severity_min: warn
+//
+// the filters are built for the signal by SpanFilter, MetricFilter or LogFilter, and the conditions of the other signals are errors.
+type FilterSet map[string]FilterConfig
+
+// LoadFilterSet reads the FilterSet from the YAML or JSON file. unknown keys are errors, and the filters are validated for the signals they are used for.
+func LoadFilterSet(path string) (FilterSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("filter set: %w", err)
+	}
+	defer f.Close()
+	var fs FilterSet
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&fs); err != nil {
+		if errors.Is(err, io.EOF) {
+			return FilterSet{}, nil
+		}
+		return nil, fmt.Errorf("filter set %s: %w", path, err)
+	}
+	return fs, nil
+}
+
+// SpanFilter builds the named filter for FilterResourceSpans.
+func (fs FilterSet) SpanFilter(name string) (func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool, error) {
+	cfg, ok := fs[name]
+	if !ok {
+		return nil, fmt.Errorf("filter %q is not defined", name)
+	}
+	f, err := buildFilter(&cfg, "traces", spanFilters)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", name, err)
+	}
+	return f, nil
+}
+
+// MetricFilter builds the named filter for FilterResourceMetrics.
+func (fs FilterSet) MetricFilter(name string) (func(*resourcepb.Resource, *commonpb.InstrumentationScope, *metricspb.Metric) bool, error) {
+	cfg, ok := fs[name]
+	if !ok {
+		return nil, fmt.Errorf("filter %q is not defined", name)
+	}
+	f, err := buildFilter(&cfg, "metrics", metricFilters)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", name, err)
+	}
+	return f, nil
+}
+
+// LogFilter builds the named filter for FilterResourceLogs.
+func (fs FilterSet) LogFilter(name string) (func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool, error) {
+	cfg, ok := fs[name]
+	if !ok {
+		return nil, fmt.Errorf("filter %q is not defined", name)
+	}
+	f, err := buildFilter(&cfg, "logs", logFilters)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", name, err)
+	}
+	return f, nil
+}
+
+// buildFilter builds the filter of the conditions common to the signals, and of the signal specific ones by signalFilters.
+func buildFilter[T any](
+	cfg *FilterConfig,
+	signalType string,
+	signalFilters func(*FilterConfig) ([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool, error),
+) (func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool, error) {
+	if key, ok := cfg.otherSignalCondition(signalType); ok {
+		return nil, fmt.Errorf("%s is not applicable to %s", key, signalType)
+	}
+	var filters []func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool
+	for _, key := range sortedKeys(cfg.ResourceAttributes) {
+		filters = append(filters, ResourceAttributeFilter[T](key, cfg.ResourceAttributes[key]...))
+	}
+	for _, key := range sortedKeys(cfg.ScopeAttributes) {
+		filters = append(filters, ScopeAttributeFilter[T](key, cfg.ScopeAttributes[key]...))
+	}
+	specific, err := signalFilters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	filters = append(filters, specific...)
+	if len(cfg.Any) > 0 {
+		anyFilters := make([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool, 0, len(cfg.Any))
+		for i := range cfg.Any {
+			f, err := buildFilter(&cfg.Any[i], signalType, signalFilters)
+			if err != nil {
+				return nil, fmt.Errorf("any[%d]: %w", i, err)
+			}
+			anyFilters = append(anyFilters, f)
+		}
+		filters = append(filters, OrFilter(anyFilters...))
+	}
+	if cfg.Not != nil {
+		f, err := buildFilter(cfg.Not, signalType, signalFilters)
+		if err != nil {
+			return nil, fmt.Errorf("not: %w", err)
+		}
+		filters = append(filters, NotFilter(f))
+	}
+	return AndFilter(filters...), nil
+}
+
+// otherSignalCondition returns the key of the condition set for the signals other than signalType.
+func (cfg *FilterConfig) otherSignalCondition(signalType string) (string, bool) {
+	conditions := []struct {
+		signalType string
+		key        string
+		set        bool
+	}{
+		{"traces", "span_name", len(cfg.SpanName) > 0},
+		{"traces", "span_kind", len(cfg.SpanKind) > 0},
+		{"traces", "span_status", len(cfg.SpanStatus) > 0},
+		{"traces", "span_has_error", cfg.SpanHasError != nil},
+		{"metrics", "metric_name", len(cfg.MetricName) > 0},
+		{"logs", "log_severity_min", cfg.LogSeverityMin != ""},
+		{"logs", "log_severity_max", cfg.LogSeverityMax != ""},
+		{"logs", "log_severity_text", len(cfg.LogSeverityText) > 0},
+		{"logs", "log_body", cfg.LogBody != ""},
+	}
+	for _, c := range conditions {
+		if c.set && c.signalType != signalType {
+			return c.key, true
+		}
+	}
+	return "", false
+}
+
+func spanFilters(cfg *FilterConfig) ([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool, error) {
+	var filters []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool
+	if len(cfg.SpanName) > 0 {
+		nameFilters := make([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool, 0, len(cfg.SpanName))
+		for _, pattern := range cfg.SpanName {
+			nameFilters = append(nameFilters, SpanNameFilter(pattern))
+		}
+		filters = append(filters, OrFilter(nameFilters...))
+	}
+	if len(cfg.SpanKind) > 0 {
+		kinds := make([]tracepb.Span_SpanKind, 0, len(cfg.SpanKind))
+		for _, s := range cfg.SpanKind {
+			kind, err := parseEnum(s, "SPAN_KIND_", tracepb.Span_SpanKind_value)
+			if err != nil {
+				return nil, fmt.Errorf("span_kind: %w", err)
+			}
+			kinds = append(kinds, tracepb.Span_SpanKind(kind))
+		}
+		filters = append(filters, SpanKindFilter(kinds...))
+	}
+	if len(cfg.SpanStatus) > 0 {
+		codes := make([]tracepb.Status_StatusCode, 0, len(cfg.SpanStatus))
+		for _, s := range cfg.SpanStatus {
+			code, err := parseEnum(s, "STATUS_CODE_", tracepb.Status_StatusCode_value)
+			if err != nil {
+				return nil, fmt.Errorf("span_status: %w", err)
+			}
+			codes = append(codes, tracepb.Status_StatusCode(code))
+		}
+		filters = append(filters, SpanStatusFilter(codes...))
+	}
+	if cfg.SpanHasError != nil {
+		if *cfg.SpanHasError {
+			filters = append(filters, SpanHasErrorFilter())
+		} else {
+			filters = append(filters, NotFilter(SpanHasErrorFilter()))
+		}
+	}
+	return filters, nil
+}
+
+func metricFilters(cfg *FilterConfig) ([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, *metricspb.Metric) bool, error) {
+	if len(cfg.MetricName) == 0 {
+		return nil, nil
+	}
+	patterns := cfg.MetricName
+	return []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *metricspb.Metric) bool{
+		func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, metric *metricspb.Metric) bool {
+			return slices.ContainsFunc(patterns, func(pattern string) bool {
+				return matchWildcard(pattern, metric.GetName())
+			})
+		},
+	}, nil
+}
+
+func logFilters(cfg *FilterConfig) ([]func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool, error) {
+	var filters []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool
+	if cfg.LogSeverityMin != "" || cfg.LogSeverityMax != "" {
+		minNumber, maxNumber := logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4
+		if cfg.LogSeverityMin != "" {
+			n, err := parseEnum(cfg.LogSeverityMin, "SEVERITY_NUMBER_", logspb.SeverityNumber_value)
+			if err != nil {
+				return nil, fmt.Errorf("log_severity_min: %w", err)
+			}
+			minNumber = logspb.SeverityNumber(n)
+		}
+		if cfg.LogSeverityMax != "" {
+			n, err := parseEnum(cfg.LogSeverityMax, "SEVERITY_NUMBER_", logspb.SeverityNumber_value)
+			if err != nil {
+				return nil, fmt.Errorf("log_severity_max: %w", err)
+			}
+			maxNumber = logspb.SeverityNumber(n)
+		}
+		filters = append(filters, LogSeverityRangeFilter(minNumber, maxNumber))
+	}
+	if len(cfg.LogSeverityText) > 0 {
+		filters = append(filters, LogSeverityTextFilter(cfg.LogSeverityText...))
+	}
+	if cfg.LogBody != "" {
+		re, err := regexp.Compile(cfg.LogBody)
+		if err != nil {
+			return nil, fmt.Errorf("log_body: %w", err)
+		}
+		filters = append(filters, LogBodyMatchFilter(re))
+	}
+	return filters, nil
+}
+
+// parseEnum parses the enum value by the full name, the name without the prefix case-insensitively, or the number.
+func parseEnum(s string, prefix string, values map[string]int32) (int32, error) {
+	name := strings.ToUpper(s)
+	if n, ok := values[name]; ok {
+		return n, nil
+	}
+	if n, ok := values[prefix+name]; ok {
+		return n, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+		for _, v := range values {
+			if v == int32(n) {
+				return v, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown value %q", s)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,187 @@
+package otlp_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func writeFilterSet(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "filters.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFilterSet(t *testing.T) {
+	path := writeFilterSet(t, `
+drop_health_checks:
+  not:
+    span_name: ["GET /health*", "GET /ready*"]
+errors_or_checkout:
+  any:
+    - span_has_error: true
+    - resource_attributes:
+        service.name: [checkout]
+      span_kind: [server]
+client_spans:
+  span_kind: [SPAN_KIND_CLIENT]
+  span_status: [unset, ok]
+warn_and_above:
+  log_severity_min: warn
+checkout_debug:
+  resource_attributes:
+    service.name: [checkout]
+  log_severity_max: debug4
+  log_body: "^cache"
+http_metrics:
+  metric_name: ["http.server.*"]
+  scope_attributes:
+    team: []
+`)
+	fs, err := otlp.LoadFilterSet(path)
+	require.NoError(t, err)
+
+	resource := func(service string) *resourcepb.Resource {
+		return &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: service}}},
+			},
+		}
+	}
+	spans := []*tracepb.ResourceSpans{
+		{
+			Resource: resource("checkout"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{
+				{Name: "GET /healthz", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "POST /orders", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "SELECT orders", Kind: tracepb.Span_SPAN_KIND_CLIENT},
+			}}},
+		},
+		{
+			Resource: resource("search"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{
+				{Name: "GET /readyz", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "GET /search", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "query", Kind: tracepb.Span_SPAN_KIND_CLIENT, Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}},
+			}}},
+		},
+	}
+	spanNames := func(src []*tracepb.ResourceSpans) []string {
+		var names []string
+		for _, rs := range src {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					names = append(names, span.GetName())
+				}
+			}
+		}
+		return names
+	}
+	for name, expected := range map[string][]string{
+		"drop_health_checks": {"POST /orders", "SELECT orders", "GET /search", "query"},
+		"errors_or_checkout": {"GET /healthz", "POST /orders", "query"},
+		"client_spans":       {"SELECT orders"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			filter, err := fs.SpanFilter(name)
+			require.NoError(t, err)
+			require.Equal(t, expected, spanNames(otlp.FilterResourceSpans(spans, filter)))
+		})
+	}
+
+	logs := []*logspb.ResourceLogs{
+		{
+			Resource: resource("checkout"),
+			ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "cache hit"}}},
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "request"}}},
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN3},
+			}}},
+		},
+		{
+			Resource: resource("search"),
+			ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL},
+			}}},
+		},
+	}
+	filter, err := fs.LogFilter("warn_and_above")
+	require.NoError(t, err)
+	require.Equal(t, 2, otlp.TotalLogRecords(otlp.FilterResourceLogs(logs, filter)))
+	filter, err = fs.LogFilter("checkout_debug")
+	require.NoError(t, err)
+	require.Equal(t, 1, otlp.TotalLogRecords(otlp.FilterResourceLogs(logs, filter)))
+
+	metrics := []*metricspb.ResourceMetrics{
+		{
+			ScopeMetrics: []*metricspb.ScopeMetrics{
+				{
+					Scope: &commonpb.InstrumentationScope{Attributes: []*commonpb.KeyValue{
+						{Key: "team", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "web"}}},
+					}},
+					Metrics: []*metricspb.Metric{
+						{Name: "http.server.request.duration", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}}}}},
+						{Name: "process.cpu.time", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}}}}},
+					},
+				},
+				{
+					Metrics: []*metricspb.Metric{
+						{Name: "http.server.active_requests", Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}}}}},
+					},
+				},
+			},
+		},
+	}
+	metricFilter, err := fs.MetricFilter("http_metrics")
+	require.NoError(t, err)
+	filtered := otlp.FilterResourceMetrics(metrics, metricFilter)
+	require.Equal(t, 1, otlp.TotalDataPoints(filtered))
+	require.Equal(t, "http.server.request.duration", filtered[0].GetScopeMetrics()[0].GetMetrics()[0].GetName())
+}
+
+func TestFilterSet__Errors(t *testing.T) {
+	fs, err := otlp.LoadFilterSet(writeFilterSet(t, `
+spans:
+  span_kind: [server]
+nested:
+  any:
+    - log_severity_min: info
+unknown_kind:
+  span_kind: [backend]
+invalid_body:
+  log_body: "("
+`))
+	require.NoError(t, err)
+
+	_, err = fs.LogFilter("spans")
+	require.EqualError(t, err, `filter "spans": span_kind is not applicable to logs`)
+	_, err = fs.MetricFilter("spans")
+	require.EqualError(t, err, `filter "spans": span_kind is not applicable to metrics`)
+	_, err = fs.SpanFilter("nested")
+	require.EqualError(t, err, `filter "nested": any[0]: log_severity_min is not applicable to traces`)
+	_, err = fs.SpanFilter("unknown_kind")
+	require.EqualError(t, err, `filter "unknown_kind": span_kind: unknown value "backend"`)
+	_, err = fs.LogFilter("invalid_body")
+	require.ErrorContains(t, err, `filter "invalid_body": log_body:`)
+	_, err = fs.SpanFilter("undefined")
+	require.EqualError(t, err, `filter "undefined" is not defined`)
+
+	_, err = otlp.LoadFilterSet(writeFilterSet(t, `
+typo:
+  span_names: [a]
+`))
+	require.ErrorContains(t, err, "field span_names not found")
+
+	fs, err = otlp.LoadFilterSet(writeFilterSet(t, ``))
+	require.NoError(t, err)
+	require.Empty(t, fs)
+}
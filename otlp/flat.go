@@ -0,0 +1,336 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// FlatResourceScope is the resource and instrumentation scope part of the flattened records.
+type FlatResourceScope struct {
+	ResourceAttributes map[string]any `json:"resource_attributes,omitempty"`
+	ResourceSchemaURL  string         `json:"resource_schema_url,omitempty"`
+	ScopeName          string         `json:"scope_name,omitempty"`
+	ScopeVersion       string         `json:"scope_version,omitempty"`
+	ScopeAttributes    map[string]any `json:"scope_attributes,omitempty"`
+	ScopeSchemaURL     string         `json:"scope_schema_url,omitempty"`
+}
+
+// FlatSpan is a Span flattened with its resource and instrumentation scope.
+type FlatSpan struct {
+	FlatResourceScope
+	TraceID       string          `json:"trace_id"`
+	SpanID        string          `json:"span_id"`
+	ParentSpanID  string          `json:"parent_span_id,omitempty"`
+	TraceState    string          `json:"trace_state,omitempty"`
+	Name          string          `json:"name"`
+	Kind          string          `json:"kind"`
+	StartTime     time.Time       `json:"start_time"`
+	EndTime       time.Time       `json:"end_time"`
+	Attributes    map[string]any  `json:"attributes,omitempty"`
+	StatusCode    string          `json:"status_code"`
+	StatusMessage string          `json:"status_message,omitempty"`
+	Events        []FlatSpanEvent `json:"events,omitempty"`
+	Links         []FlatSpanLink  `json:"links,omitempty"`
+}
+
+// Duration returns the duration of the span.
+func (s FlatSpan) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// FlatSpanEvent is a Span event in FlatSpan.
+type FlatSpanEvent struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// FlatSpanLink is a Span link in FlatSpan.
+type FlatSpanLink struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	TraceState string         `json:"trace_state,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// FlatMetricPoint is a metric data point flattened with its metric, resource and instrumentation scope.
+type FlatMetricPoint struct {
+	FlatResourceScope
+	MetricName             string         `json:"metric_name"`
+	MetricDescription      string         `json:"metric_description,omitempty"`
+	MetricUnit             string         `json:"metric_unit,omitempty"`
+	MetricType             string         `json:"metric_type"`
+	AggregationTemporality string         `json:"aggregation_temporality,omitempty"`
+	IsMonotonic            bool           `json:"is_monotonic,omitempty"`
+	StartTime              time.Time      `json:"start_time"`
+	Time                   time.Time      `json:"time"`
+	Attributes             map[string]any `json:"attributes,omitempty"`
+	// Value is the value of Gauge and Sum data points.
+	Value float64 `json:"value,omitempty"`
+	// Count and Sum are the count and sum of Histogram, ExponentialHistogram and Summary data points.
+	Count uint64  `json:"count,omitempty"`
+	Sum   float64 `json:"sum,omitempty"`
+	// BucketCounts and ExplicitBounds are the buckets of Histogram data points.
+	BucketCounts   []uint64  `json:"bucket_counts,omitempty"`
+	ExplicitBounds []float64 `json:"explicit_bounds,omitempty"`
+}
+
+// FlatLogRecord is a LogRecord flattened with its resource and instrumentation scope.
+type FlatLogRecord struct {
+	FlatResourceScope
+	Time           time.Time      `json:"time"`
+	ObservedTime   time.Time      `json:"observed_time"`
+	SeverityNumber int32          `json:"severity_number,omitempty"`
+	SeverityText   string         `json:"severity_text,omitempty"`
+	Body           any            `json:"body,omitempty"`
+	Attributes     map[string]any `json:"attributes,omitempty"`
+	TraceID        string         `json:"trace_id,omitempty"`
+	SpanID         string         `json:"span_id,omitempty"`
+	Flags          uint32         `json:"flags,omitempty"`
+}
+
+// FlattenResourceSpans converts the given ResourceSpans slice into FlatSpans.
+func FlattenResourceSpans(src []*tracepb.ResourceSpans) []FlatSpan {
+	dst := make([]FlatSpan, 0, TotalSpans(src))
+	for _, elem := range src {
+		for _, elemScopeSpan := range elem.GetScopeSpans() {
+			rs := newFlatResourceScope(elem.GetResource(), elem.GetSchemaUrl(), elemScopeSpan.GetScope(), elemScopeSpan.GetSchemaUrl())
+			for _, span := range elemScopeSpan.GetSpans() {
+				dst = append(dst, newFlatSpan(rs, span))
+			}
+		}
+	}
+	return dst
+}
+
+func newFlatSpan(rs FlatResourceScope, span *tracepb.Span) FlatSpan {
+	fs := FlatSpan{
+		FlatResourceScope: rs,
+		TraceID:           hexID(span.GetTraceId()),
+		SpanID:            hexID(span.GetSpanId()),
+		ParentSpanID:      hexID(span.GetParentSpanId()),
+		TraceState:        span.GetTraceState(),
+		Name:              span.GetName(),
+		Kind:              span.GetKind().String(),
+		StartTime:         unixNanoToTime(span.GetStartTimeUnixNano()),
+		EndTime:           unixNanoToTime(span.GetEndTimeUnixNano()),
+		Attributes:        AttributesToMap(span.GetAttributes()),
+		StatusCode:        span.GetStatus().GetCode().String(),
+		StatusMessage:     span.GetStatus().GetMessage(),
+	}
+	for _, event := range span.GetEvents() {
+		fs.Events = append(fs.Events, FlatSpanEvent{
+			Name:       event.GetName(),
+			Time:       unixNanoToTime(event.GetTimeUnixNano()),
+			Attributes: AttributesToMap(event.GetAttributes()),
+		})
+	}
+	for _, link := range span.GetLinks() {
+		fs.Links = append(fs.Links, FlatSpanLink{
+			TraceID:    hexID(link.GetTraceId()),
+			SpanID:     hexID(link.GetSpanId()),
+			TraceState: link.GetTraceState(),
+			Attributes: AttributesToMap(link.GetAttributes()),
+		})
+	}
+	return fs
+}
+
+// FlattenResourceMetrics converts the given ResourceMetrics slice into FlatMetricPoints, one per data point.
+func FlattenResourceMetrics(src []*metricspb.ResourceMetrics) []FlatMetricPoint {
+	dst := make([]FlatMetricPoint, 0, TotalDataPoints(src))
+	for _, elem := range src {
+		for _, elemScopeMetric := range elem.GetScopeMetrics() {
+			rs := newFlatResourceScope(elem.GetResource(), elem.GetSchemaUrl(), elemScopeMetric.GetScope(), elemScopeMetric.GetSchemaUrl())
+			for _, metric := range elemScopeMetric.GetMetrics() {
+				dst = appendFlatMetricPoints(dst, rs, metric)
+			}
+		}
+	}
+	return dst
+}
+
+func appendFlatMetricPoints(dst []FlatMetricPoint, rs FlatResourceScope, metric *metricspb.Metric) []FlatMetricPoint {
+	base := FlatMetricPoint{
+		FlatResourceScope: rs,
+		MetricName:        metric.GetName(),
+		MetricDescription: metric.GetDescription(),
+		MetricUnit:        metric.GetUnit(),
+	}
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		base.MetricType = "Gauge"
+		for _, dp := range data.Gauge.GetDataPoints() {
+			dst = append(dst, newFlatNumberDataPoint(base, dp))
+		}
+	case *metricspb.Metric_Sum:
+		base.MetricType = "Sum"
+		base.AggregationTemporality = aggregationTemporalityString(data.Sum.GetAggregationTemporality())
+		base.IsMonotonic = data.Sum.GetIsMonotonic()
+		for _, dp := range data.Sum.GetDataPoints() {
+			dst = append(dst, newFlatNumberDataPoint(base, dp))
+		}
+	case *metricspb.Metric_Summary:
+		base.MetricType = "Summary"
+		for _, dp := range data.Summary.GetDataPoints() {
+			p := base
+			p.StartTime = unixNanoToTime(dp.GetStartTimeUnixNano())
+			p.Time = unixNanoToTime(dp.GetTimeUnixNano())
+			p.Attributes = AttributesToMap(dp.GetAttributes())
+			p.Count = dp.GetCount()
+			p.Sum = dp.GetSum()
+			dst = append(dst, p)
+		}
+	case *metricspb.Metric_Histogram:
+		base.MetricType = "Histogram"
+		base.AggregationTemporality = aggregationTemporalityString(data.Histogram.GetAggregationTemporality())
+		for _, dp := range data.Histogram.GetDataPoints() {
+			p := base
+			p.StartTime = unixNanoToTime(dp.GetStartTimeUnixNano())
+			p.Time = unixNanoToTime(dp.GetTimeUnixNano())
+			p.Attributes = AttributesToMap(dp.GetAttributes())
+			p.Count = dp.GetCount()
+			p.Sum = dp.GetSum()
+			p.BucketCounts = dp.GetBucketCounts()
+			p.ExplicitBounds = dp.GetExplicitBounds()
+			dst = append(dst, p)
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		base.MetricType = "ExponentialHistogram"
+		base.AggregationTemporality = aggregationTemporalityString(data.ExponentialHistogram.GetAggregationTemporality())
+		for _, dp := range data.ExponentialHistogram.GetDataPoints() {
+			p := base
+			p.StartTime = unixNanoToTime(dp.GetStartTimeUnixNano())
+			p.Time = unixNanoToTime(dp.GetTimeUnixNano())
+			p.Attributes = AttributesToMap(dp.GetAttributes())
+			p.Count = dp.GetCount()
+			p.Sum = dp.GetSum()
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
+func newFlatNumberDataPoint(base FlatMetricPoint, dp *metricspb.NumberDataPoint) FlatMetricPoint {
+	p := base
+	p.StartTime = unixNanoToTime(dp.GetStartTimeUnixNano())
+	p.Time = unixNanoToTime(dp.GetTimeUnixNano())
+	p.Attributes = AttributesToMap(dp.GetAttributes())
+	switch v := dp.GetValue().(type) {
+	case *metricspb.NumberDataPoint_AsDouble:
+		p.Value = v.AsDouble
+	case *metricspb.NumberDataPoint_AsInt:
+		p.Value = float64(v.AsInt)
+	}
+	return p
+}
+
+func aggregationTemporalityString(t metricspb.AggregationTemporality) string {
+	switch t {
+	case metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE:
+		return "Cumulative"
+	case metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA:
+		return "Delta"
+	}
+	return ""
+}
+
+// FlattenResourceLogs converts the given ResourceLogs slice into FlatLogRecords.
+func FlattenResourceLogs(src []*logspb.ResourceLogs) []FlatLogRecord {
+	dst := make([]FlatLogRecord, 0, TotalLogRecords(src))
+	for _, elem := range src {
+		for _, elemScopeLogs := range elem.GetScopeLogs() {
+			rs := newFlatResourceScope(elem.GetResource(), elem.GetSchemaUrl(), elemScopeLogs.GetScope(), elemScopeLogs.GetSchemaUrl())
+			for _, logRecord := range elemScopeLogs.GetLogRecords() {
+				dst = append(dst, FlatLogRecord{
+					FlatResourceScope: rs,
+					Time:              unixNanoToTime(logRecord.GetTimeUnixNano()),
+					ObservedTime:      unixNanoToTime(logRecord.GetObservedTimeUnixNano()),
+					SeverityNumber:    int32(logRecord.GetSeverityNumber()),
+					SeverityText:      logRecord.GetSeverityText(),
+					Body:              AnyValueToInterface(logRecord.GetBody()),
+					Attributes:        AttributesToMap(logRecord.GetAttributes()),
+					TraceID:           hexID(logRecord.GetTraceId()),
+					SpanID:            hexID(logRecord.GetSpanId()),
+					Flags:             logRecord.GetFlags(),
+				})
+			}
+		}
+	}
+	return dst
+}
+
+func newFlatResourceScope(resource *resourcepb.Resource, resourceSchemaURL string, scope *commonpb.InstrumentationScope, scopeSchemaURL string) FlatResourceScope {
+	return FlatResourceScope{
+		ResourceAttributes: AttributesToMap(resource.GetAttributes()),
+		ResourceSchemaURL:  resourceSchemaURL,
+		ScopeName:          scope.GetName(),
+		ScopeVersion:       scope.GetVersion(),
+		ScopeAttributes:    AttributesToMap(scope.GetAttributes()),
+		ScopeSchemaURL:     scopeSchemaURL,
+	}
+}
+
+// AttributesToMap converts the given attributes into a map of Go values. see AnyValueToInterface.
+func AttributesToMap(attrs []*commonpb.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		m[attr.GetKey()] = AnyValueToInterface(attr.GetValue())
+	}
+	return m
+}
+
+// AnyValueToInterface converts the given AnyValue into a Go value.
+// string, bool, int64, float64, []byte, []any and map[string]any are returned.
+func AnyValueToInterface(v *commonpb.AnyValue) any {
+	switch value := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return value.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return value.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return value.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return value.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return value.BytesValue
+	case *commonpb.AnyValue_ArrayValue:
+		values := value.ArrayValue.GetValues()
+		arr := make([]any, 0, len(values))
+		for _, elem := range values {
+			arr = append(arr, AnyValueToInterface(elem))
+		}
+		return arr
+	case *commonpb.AnyValue_KvlistValue:
+		m := AttributesToMap(value.KvlistValue.GetValues())
+		if m == nil {
+			m = map[string]any{}
+		}
+		return m
+	}
+	return nil
+}
+
+func hexID(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	return strings.ToUpper(hex.EncodeToString(id))
+}
+
+func unixNanoToTime(ns uint64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ns)).UTC()
+}
@@ -0,0 +1,68 @@
+package otlp_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestFlattenResourceSpans(t *testing.T) {
+	bs, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var data tracepb.TracesData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	spans := otlp.FlattenResourceSpans(data.GetResourceSpans())
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, map[string]any{"service.name": "my.service"}, span.ResourceAttributes)
+	require.Equal(t, "my.library", span.ScopeName)
+	require.Equal(t, "1.0.0", span.ScopeVersion)
+	require.Equal(t, "5B8EFFF798038103D269B633813FC60C", span.TraceID)
+	require.Equal(t, "EEE19B7EC3C1B174", span.SpanID)
+	require.Equal(t, "EEE19B7EC3C1B173", span.ParentSpanID)
+	require.Equal(t, "I'm a server span", span.Name)
+	require.Equal(t, "SPAN_KIND_SERVER", span.Kind)
+	require.Equal(t, time.Second, span.Duration())
+	require.Equal(t, map[string]any{"my.span.attr": "some value"}, span.Attributes)
+}
+
+func TestFlattenResourceMetrics(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_metrics.json")
+	require.NoError(t, err)
+	var data metricspb.MetricsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	points := otlp.FlattenResourceMetrics(data.GetResourceMetrics())
+	require.Len(t, points, otlp.TotalDataPoints(data.GetResourceMetrics()))
+	require.Equal(t, "my.counter", points[0].MetricName)
+	require.Equal(t, "Sum", points[0].MetricType)
+	require.Equal(t, "Delta", points[0].AggregationTemporality)
+	require.True(t, points[0].IsMonotonic)
+	require.InDelta(t, 5.0, points[0].Value, 0)
+}
+
+func TestFlattenResourceLogs(t *testing.T) {
+	bs, err := os.ReadFile("testdata/logs.json")
+	require.NoError(t, err)
+	var data logspb.LogsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	records := otlp.FlattenResourceLogs(data.GetResourceLogs())
+	require.Len(t, records, 1)
+	record := records[0]
+	require.Equal(t, "Example log record", record.Body)
+	require.EqualValues(t, 10, record.SeverityNumber)
+	require.Equal(t, "Information", record.SeverityText)
+	require.Equal(t, map[string]any{
+		"string.attribute":  "some string",
+		"boolean.attribute": true,
+		"int.attribute":     int64(10),
+		"double.attribute":  637.704,
+		"array.attribute":   []any{"many", "values"},
+		"map.attribute":     map[string]any{"some.map.key": "some value"},
+	}, record.Attributes)
+}
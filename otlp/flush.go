@@ -0,0 +1,122 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DroppedError is returned by Stop when the uploads in flight are aborted because the context is done before they finish.
+type DroppedError struct {
+	// Spans, DataPoints and LogRecords are the number of items of the aborted uploads.
+	Spans      int
+	DataPoints int
+	LogRecords int
+	Err        error
+}
+
+func (e *DroppedError) Error() string {
+	return fmt.Sprintf("dropped %d spans, %d data points and %d log records: %v", e.Spans, e.DataPoints, e.LogRecords, e.Err)
+}
+
+func (e *DroppedError) Unwrap() error {
+	return e.Err
+}
+
+// ForceFlush waits for the uploads in flight to finish, including their retries.
+// the client does not buffer, so it returns when all uploads started before the call are done, or ctx is done.
+// new uploads are not blocked while ForceFlush waits.
+func (c *Client) ForceFlush(ctx context.Context) error {
+	for _, done := range c.inflight.snapshot() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// inflightUploads tracks the uploads in flight, for ForceFlush to wait for them without the lock of the client.
+type inflightUploads struct {
+	mu   sync.Mutex
+	next uint64
+	done map[uint64]chan struct{}
+}
+
+// begin registers an upload, and returns the function to call when it finishes.
+func (u *inflightUploads) begin() func() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.done == nil {
+		u.done = make(map[uint64]chan struct{})
+	}
+	id := u.next
+	u.next++
+	done := make(chan struct{})
+	u.done[id] = done
+	return func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		delete(u.done, id)
+		close(done)
+	}
+}
+
+// snapshot returns the channels closed when the uploads in flight finish.
+func (u *inflightUploads) snapshot() []chan struct{} {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	done := make([]chan struct{}, 0, len(u.done))
+	for _, ch := range u.done {
+		done = append(done, ch)
+	}
+	return done
+}
+
+func (c *Client) abortContext() context.Context {
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	if c.abortCtx == nil {
+		c.abortCtx, c.abort = context.WithCancel(context.Background())
+	}
+	return c.abortCtx
+}
+
+// abortExports cancels the uploads in flight.
+func (c *Client) abortExports() {
+	c.abortContext()
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	c.abort()
+}
+
+// takeDropped returns the items dropped by abortExports and resets the state for the next Start.
+func (c *Client) takeDropped() *DroppedError {
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	dropped := c.dropped
+	c.dropped = DroppedError{}
+	if c.abort != nil {
+		c.abort()
+	}
+	c.abortCtx, c.abort = nil, nil
+	if dropped.Spans == 0 && dropped.DataPoints == 0 && dropped.LogRecords == 0 {
+		return nil
+	}
+	return &dropped
+}
+
+func (c *Client) recordDropped(signalType string, items int) {
+	c.stats.signal(signalType).dropped.Add(int64(items))
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	switch signalType {
+	case "traces":
+		c.dropped.Spans += items
+	case "metrics":
+		c.dropped.DataPoints += items
+	case "logs":
+		c.dropped.LogRecords += items
+	}
+}
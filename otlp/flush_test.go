@@ -0,0 +1,91 @@
+package otlp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlockingServer(t *testing.T) (*httptest.Server, chan struct{}, chan struct{}) {
+	t.Helper()
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(io.Discard, r.Body)
+			received <- struct{}{}
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	t.Cleanup(server.Close)
+	return server, received, release
+}
+
+func TestClient_Stop_Dropped(t *testing.T) {
+	server, received, _ := newBlockingServer(t)
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"))
+	require.NoError(t, err)
+	uploadErr := make(chan error, 1)
+	go func() {
+		uploadErr <- client.UploadTraces(context.Background(), newSpans(3))
+	}()
+	<-received
+	require.EqualValues(t, 3, client.Stats()["traces"].QueueDepth)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = client.Stop(ctx)
+	var dropped *otlp.DroppedError
+	require.ErrorAs(t, err, &dropped)
+	require.Equal(t, 3, dropped.Spans)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Error(t, <-uploadErr)
+	stats := client.Stats()["traces"]
+	require.EqualValues(t, 3, stats.Dropped)
+	require.EqualValues(t, 0, stats.QueueDepth)
+}
+
+func TestClient_ForceFlush(t *testing.T) {
+	server, received, release := newBlockingServer(t)
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"))
+	require.NoError(t, err)
+	uploadErr := make(chan error, 1)
+	go func() {
+		uploadErr <- client.UploadTraces(context.Background(), newSpans(1))
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, client.ForceFlush(ctx), context.DeadlineExceeded)
+
+	// the timed out ForceFlush does not block the new uploads.
+	go func() {
+		uploadErr <- client.UploadTraces(context.Background(), newSpans(1))
+	}()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the new upload is blocked")
+	}
+
+	close(release)
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.ForceFlush(ctx))
+	require.NoError(t, <-uploadErr)
+	require.NoError(t, <-uploadErr)
+	require.NoError(t, client.Stop(ctx))
+}
@@ -0,0 +1,137 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ForwardHandler re-exports the incoming requests to the upstream with the client, to build an OTLP proxy with ServerMux,
+// e.g. filtering the telemetry or adding the resource attributes before the backend.
+// it implements TraceHandler, MetricsHandler and LogsHandler:
+//
+//	forward := otlp.NewForwardHandler(client, otlp.WithForwardHeaders("X-Scope-OrgID"))
+//	mux.Trace().Handle(forward)
+//	mux.Metrics().Handle(forward)
+//	mux.Logs().Handle(forward)
+//
+// the partial success of the upstream is returned as is. the upstream errors are returned as the status of gRPC,
+// the retryable ones as UNAVAILABLE or RESOURCE_EXHAUSTED with the delay requested by the upstream, so that the exporters retry.
+type ForwardHandler struct {
+	client    *Client
+	headers   []string
+	transform func(ctx context.Context, req proto.Message) error
+}
+
+// ForwardOption is an option for NewForwardHandler.
+type ForwardOption func(*ForwardHandler)
+
+// WithForwardHeaders passes through the incoming headers of the names to the upstream, e.g. the tenant ids.
+// the headers of the client options take precedence.
+func WithForwardHeaders(names ...string) ForwardOption {
+	return func(h *ForwardHandler) {
+		h.headers = append(h.headers, names...)
+	}
+}
+
+// WithForwardTransform sets the hook called before forwarding, with *TraceRequest, *MetricsRequest or *LogsRequest.
+// it mutates the request in place, e.g. to drop the items; the request is not forwarded if it returns an error, which is returned to the exporter.
+func WithForwardTransform(f func(ctx context.Context, req proto.Message) error) ForwardOption {
+	return func(h *ForwardHandler) {
+		h.transform = f
+	}
+}
+
+// NewForwardHandler returns a new ForwardHandler uploading with the client, which must be started.
+func NewForwardHandler(client *Client, opts ...ForwardOption) *ForwardHandler {
+	h := &ForwardHandler{
+		client: client,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *ForwardHandler) HandleTrace(ctx context.Context, req *TraceRequest) (*TraceResponse, error) {
+	return forward[*TraceResponse](ctx, h, req)
+}
+
+func (h *ForwardHandler) HandleMetrics(ctx context.Context, req *MetricsRequest) (*MetricsResponse, error) {
+	return forward[*MetricsResponse](ctx, h, req)
+}
+
+func (h *ForwardHandler) HandleLogs(ctx context.Context, req *LogsRequest) (*LogsResponse, error) {
+	return forward[*LogsResponse](ctx, h, req)
+}
+
+// Export forwards the request of any signal, e.g. as the handler of WithTenantRouter.
+func (h *ForwardHandler) Export(ctx context.Context, req proto.Message) (proto.Message, error) {
+	var (
+		resp proto.Message
+		err  error
+	)
+	switch req := req.(type) {
+	case *TraceRequest:
+		resp, err = h.HandleTrace(ctx, req)
+	case *MetricsRequest:
+		resp, err = h.HandleMetrics(ctx, req)
+	case *LogsRequest:
+		resp, err = h.HandleLogs(ctx, req)
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "%T is not forwarded", req)
+	}
+	if err != nil {
+		// not a typed nil of the response.
+		return nil, err
+	}
+	return resp, nil
+}
+
+func forward[Resp proto.Message](ctx context.Context, h *ForwardHandler, req proto.Message) (Resp, error) {
+	var zero Resp
+	if h.transform != nil {
+		if err := h.transform(ctx, req); err != nil {
+			return zero, err
+		}
+	}
+	if len(h.headers) > 0 {
+		incoming, _ := HeadersFromContext(ctx)
+		headers := make(http.Header, len(h.headers))
+		for _, name := range h.headers {
+			if values := incoming.Values(name); len(values) > 0 {
+				headers[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		ctx = withOutgoingHeaders(ctx, headers)
+	}
+	resp, err := h.client.Export(ctx, req)
+	if resp != nil {
+		// the partial success is returned with the error.
+		return resp.(Resp), nil
+	}
+	return zero, forwardError(err)
+}
+
+// forwardError returns the status of the upstream error for the exporters.
+func forwardError(err error) error {
+	if _, ok := status.FromError(err); ok || contextCause(err) != nil {
+		return err
+	}
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		retryable, delay, hasDelay := retryableError(err)
+		if !retryable {
+			return status.Errorf(codes.Internal, "upstream: %v", err)
+		}
+		if hasDelay {
+			return throttleStatus("upstream: "+err.Error(), delay).Err()
+		}
+	}
+	// the failures to reach the upstream are retryable.
+	return status.Errorf(codes.Unavailable, "upstream: %v", err)
+}
@@ -0,0 +1,111 @@
+package otlp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestForwardHandler(t *testing.T) {
+	type received struct {
+		tenant string
+		apiKey string
+		spans  int
+	}
+	requests := make(chan received, 1)
+	upstream := otlp.NewServerMux()
+	upstream.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		headers, _ := otlp.HeadersFromContext(ctx)
+		requests <- received{
+			tenant: headers.Get("X-Scope-OrgID"),
+			apiKey: headers.Get("Api-Key"),
+			spans:  otlp.TotalSpans(req.GetResourceSpans()),
+		}
+		return otlp.NewTracePartialSuccess(1, "rejected"), nil
+	})
+	grpcUpstream := otlptest.NewServer(upstream)
+	defer grpcUpstream.Close()
+	httpUpstream := httptest.NewServer(upstream)
+	defer httpUpstream.Close()
+
+	for _, c := range []struct {
+		protocol string
+		endpoint string
+	}{
+		{protocol: "grpc", endpoint: grpcUpstream.URL},
+		{protocol: "http/protobuf", endpoint: httpUpstream.URL},
+	} {
+		t.Run(c.protocol, func(t *testing.T) {
+			ctx := context.Background()
+			upstreamClient, err := otlp.NewClient(c.endpoint, otlp.WithProtocol(c.protocol), otlp.WithHeaders(map[string]string{"Api-Key": "proxy"}))
+			require.NoError(t, err)
+			require.NoError(t, upstreamClient.Start(ctx))
+			defer upstreamClient.Stop(ctx)
+
+			proxy := otlp.NewServerMux()
+			proxy.Trace().Handle(otlp.NewForwardHandler(
+				upstreamClient,
+				otlp.WithForwardHeaders("X-Scope-OrgID", "Api-Key"),
+				otlp.WithForwardTransform(func(_ context.Context, req proto.Message) error {
+					// drop the first span.
+					scopeSpans := req.(*otlp.TraceRequest).GetResourceSpans()[0].GetScopeSpans()[0]
+					scopeSpans.Spans = scopeSpans.GetSpans()[1:]
+					return nil
+				}),
+			))
+			server := httptest.NewServer(proxy)
+			defer server.Close()
+			client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"), otlp.WithHeaders(map[string]string{
+				"X-Scope-OrgID": "tenant-1",
+				"Api-Key":       "client",
+			}))
+			require.NoError(t, err)
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+
+			err = client.UploadTraces(ctx, newSpans(3))
+			var psErr *otlp.UploadTracesPartialSuccessError
+			require.ErrorAs(t, err, &psErr, "the partial success of the upstream is returned")
+			require.EqualValues(t, 1, psErr.Response().GetPartialSuccess().GetRejectedSpans())
+			require.Equal(t, received{tenant: "tenant-1", apiKey: "proxy", spans: 2}, <-requests)
+		})
+	}
+}
+
+func TestForwardHandler_Error(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+	ctx := context.Background()
+	client, err := otlp.NewClient(upstream.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	forward := otlp.NewForwardHandler(client)
+	_, err = forward.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	upstream.Close()
+	_, err = forward.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.Equal(t, codes.Unavailable, status.Code(err), "the upstream is not reachable")
+
+	forward = otlp.NewForwardHandler(client, otlp.WithForwardTransform(func(context.Context, proto.Message) error {
+		return status.Error(codes.PermissionDenied, "denied")
+	}))
+	_, err = forward.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
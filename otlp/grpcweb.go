@@ -0,0 +1,199 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	logspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	profilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
+	tracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	envelopeCompressedFlag = 0x01
+	grpcWebTrailerFlag     = 0x80
+
+	// defaultMaxEnvelopedMessageSize limits the message frames without SetMaxRequestBodySize, the same as the default of the gRPC server.
+	defaultMaxEnvelopedMessageSize = 4 << 20
+)
+
+// isGRPCWeb reports whether the request is gRPC-Web, in the binary or the base64 text format.
+func isGRPCWeb(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+// serveGRPCWeb serves the unary export of gRPC-Web, sent by the browsers to /{service}/Export, e.g. the OTel JS SDKs with a gRPC-Web transport.
+// the request message is unframed, exported by the entry of the service, and the response is framed with the trailer.
+// the browsers need CORS, see WithCORS.
+func (mux *ServerMux) serveGRPCWeb(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	var body io.Reader = r.Body
+	if limit := mux.maxBodySize.Load(); limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	w.Header().Set("Content-Type", contentType)
+	resp, err := func() (proto.Message, error) {
+		msg, err := readEnvelopedMessage(body, r.Header.Get("Grpc-Encoding"), grpcWebTrailerFlag, mux.maxBodySize.Load())
+		if err != nil {
+			if st, ok := tooLargeStatus(w, err); ok {
+				return nil, st.Err()
+			}
+			return nil, err
+		}
+		return mux.exportMethod(r.Context(), r.URL.Path, msg, proto.Unmarshal)
+	}()
+	if err != nil {
+		st := statusFromError(err)
+		mux.getLogger().WarnContext(r.Context(), "gRPC-Web request failed", "code", st.Code().String(), "details", err)
+		// trailers-only response.
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code())))
+		w.Header().Set("Grpc-Message", encodeGRPCMessage(st.Message()))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(codes.Internal)))
+		w.Header().Set("Grpc-Message", encodeGRPCMessage("Unable to marshal response"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var buf bytes.Buffer
+	writeEnvelope(&buf, 0, data)
+	writeEnvelope(&buf, grpcWebTrailerFlag, []byte("grpc-status: 0\r\ngrpc-message: \r\n"))
+	out := buf.Bytes()
+	if text {
+		out = []byte(base64.StdEncoding.EncodeToString(out))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(out); err != nil {
+		mux.getLogger().WarnContext(r.Context(), "failed to write response", "details", err)
+	}
+}
+
+// exportMethod unmarshals msg and exports it by the entry of the gRPC method of path, /{service}/Export.
+func (mux *ServerMux) exportMethod(ctx context.Context, path string, msg []byte, unmarshal func([]byte, proto.Message) error) (proto.Message, error) {
+	switch strings.TrimPrefix(path, mux.pathPrefix) {
+	case "/" + tracepb.TraceService_ServiceDesc.ServiceName + "/Export":
+		if e, ok := mux.getTraceEntry(); ok {
+			return unmarshalAndExport(ctx, msg, unmarshal, &TraceRequest{}, e.Export)
+		}
+	case "/" + metricspb.MetricsService_ServiceDesc.ServiceName + "/Export":
+		if e, ok := mux.getMetricsEntry(); ok {
+			return unmarshalAndExport(ctx, msg, unmarshal, &MetricsRequest{}, e.Export)
+		}
+	case "/" + logspb.LogsService_ServiceDesc.ServiceName + "/Export":
+		if e, ok := mux.getLogsEntry(); ok {
+			return unmarshalAndExport(ctx, msg, unmarshal, &LogsRequest{}, e.Export)
+		}
+	case "/" + profilespb.ProfilesService_ServiceDesc.ServiceName + "/Export":
+		if e, ok := mux.getProfilesEntry(); ok {
+			return unmarshalAndExport(ctx, msg, unmarshal, &ProfilesRequest{}, e.Export)
+		}
+	}
+	return nil, status.Errorf(codes.Unimplemented, "unknown method %s", path)
+}
+
+func unmarshalAndExport[Req, Resp proto.Message](ctx context.Context, msg []byte, unmarshal func([]byte, proto.Message) error, req Req, export func(context.Context, Req) (Resp, error)) (proto.Message, error) {
+	if err := unmarshal(msg, req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Unable to unmarshal request body")
+	}
+	resp, err := export(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readEnvelopedMessage reads the first message frame of the body, decompressed with the compressor of the encoding if flagged.
+// the frames are the same in gRPC-Web and Connect, except for endFlag, the flag of the trailer or the end of the stream.
+// the declared and the decompressed sizes are limited by limit, or defaultMaxEnvelopedMessageSize if zero, before reading them.
+func readEnvelopedMessage(r io.Reader, encodingName string, endFlag byte, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = defaultMaxEnvelopedMessageSize
+	}
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, invalidEnvelopedMessage(err)
+	}
+	if header[0]&endFlag != 0 {
+		return nil, status.Error(codes.InvalidArgument, "request message is missing")
+	}
+	size := int64(binary.BigEndian.Uint32(header[1:]))
+	if size > limit {
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, invalidEnvelopedMessage(err)
+	}
+	if header[0]&envelopeCompressedFlag == 0 {
+		return msg, nil
+	}
+	compressor := encoding.GetCompressor(encodingName)
+	if compressor == nil {
+		return nil, status.Errorf(codes.Unimplemented, "encoding %q is not supported", encodingName)
+	}
+	dr, err := compressor.Decompress(bytes.NewReader(msg))
+	if err != nil {
+		return nil, invalidEnvelopedMessage(err)
+	}
+	decompressed, err := io.ReadAll(io.LimitReader(dr, limit+1))
+	if err != nil {
+		return nil, invalidEnvelopedMessage(err)
+	}
+	if int64(len(decompressed)) > limit {
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+	return decompressed, nil
+}
+
+func invalidEnvelopedMessage(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return status.Errorf(codes.InvalidArgument, "Unable to read request message: %v", err)
+}
+
+func writeEnvelope(buf *bytes.Buffer, flag byte, data []byte) {
+	var header [5]byte
+	header[0] = flag
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	buf.Write(header[:])
+	buf.Write(data)
+}
+
+// encodeGRPCMessage percent-encodes the message for grpc-message, as the gRPC over HTTP/2 protocol defines.
+func encodeGRPCMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
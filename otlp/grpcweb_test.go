@@ -0,0 +1,106 @@
+package otlp_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func grpcWebFrame(flag byte, data []byte) []byte {
+	frame := make([]byte, 5, 5+len(data))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+func TestMux__GRPCWeb(t *testing.T) {
+	expected := &otlp.TraceRequest{ResourceSpans: newSpans(2)}
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		assertEqualMessage(t, expected, req)
+		headers, ok := otlp.HeadersFromContext(ctx)
+		require.True(t, ok)
+		require.Equal(t, "browser", headers.Get("X-Client"))
+		return otlp.NewTracePartialSuccess(1, "rejected"), nil
+	})
+	msg, err := proto.Marshal(expected)
+	require.NoError(t, err)
+
+	for _, contentType := range []string{"application/grpc-web+proto", "application/grpc-web-text"} {
+		t.Run(contentType, func(t *testing.T) {
+			body := grpcWebFrame(0, msg)
+			text := contentType == "application/grpc-web-text"
+			if text {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+			}
+			req := httptest.NewRequest(http.MethodPost, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("X-Client", "browser")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, contentType, w.Header().Get("Content-Type"))
+			require.Empty(t, w.Header().Get("Grpc-Status"))
+
+			respBody := w.Body.Bytes()
+			if text {
+				respBody, err = base64.StdEncoding.DecodeString(w.Body.String())
+				require.NoError(t, err)
+			}
+			require.Equal(t, byte(0), respBody[0])
+			n := binary.BigEndian.Uint32(respBody[1:5])
+			var resp otlp.TraceResponse
+			require.NoError(t, proto.Unmarshal(respBody[5:5+n], &resp))
+			require.EqualValues(t, 1, resp.GetPartialSuccess().GetRejectedSpans())
+			trailer := respBody[5+n:]
+			require.Equal(t, byte(0x80), trailer[0])
+			require.Contains(t, string(trailer[5:]), "grpc-status: 0")
+		})
+	}
+	t.Run("unimplemented", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/opentelemetry.proto.collector.logs.v1.LogsService/Export", bytes.NewReader(grpcWebFrame(0, nil)))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "12", w.Header().Get("Grpc-Status"))
+	})
+	t.Run("invalid frame", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", bytes.NewReader([]byte{0, 0}))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, "3", w.Header().Get("Grpc-Status"))
+	})
+	t.Run("declared size too large", func(t *testing.T) {
+		frame := []byte{0, 0xff, 0xff, 0xff, 0xff}
+		req := httptest.NewRequest(http.MethodPost, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", bytes.NewReader(frame))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, "8", w.Header().Get("Grpc-Status"))
+		require.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+	t.Run("decompressed size too large", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write(make([]byte, 5<<20))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		req := httptest.NewRequest(http.MethodPost, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", bytes.NewReader(grpcWebFrame(0x01, buf.Bytes())))
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Grpc-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, "8", w.Header().Get("Grpc-Status"))
+	})
+}
@@ -0,0 +1,88 @@
+package otlp
+
+import (
+	"context"
+	"net/http"
+
+	logspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	profilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
+	tracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// WithHealthCheck exposes /healthz and /readyz over HTTP, under the prefix set by WithHTTPPathPrefix, and registers grpc.health.v1.Health on the gRPC side.
+// /healthz always responds 200 while the server is up. /readyz and the gRPC health check call ready, e.g. to report the availability of the downstream sink,
+// and respond 503 and NOT_SERVING when it returns an error. nil ready means always ready.
+// the gRPC health check accepts the empty service name and the names of the registered OTLP services.
+func WithHealthCheck(ready func(ctx context.Context) error) ServerMuxOption {
+	return func(mux *ServerMux) {
+		if ready == nil {
+			ready = func(context.Context) error { return nil }
+		}
+		mux.readiness = ready
+	}
+}
+
+func (mux *ServerMux) registerHealthCheck() {
+	mux.httpMux.HandleFunc(mux.pathPrefix+"/healthz", func(w http.ResponseWriter, r *http.Request) {
+		mux.writeHealth(w, r, nil)
+	})
+	mux.httpMux.HandleFunc(mux.pathPrefix+"/readyz", func(w http.ResponseWriter, r *http.Request) {
+		mux.writeHealth(w, r, mux.readiness(r.Context()))
+	})
+}
+
+func (mux *ServerMux) writeHealth(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		mux.getLogger().DebugContext(r.Context(), "not ready", "details", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok\n")); err != nil {
+		mux.getLogger().DebugContext(r.Context(), "failed to write health", "details", err)
+	}
+}
+
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	mux *ServerMux
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if service := req.GetService(); service != "" && !s.mux.hasService(service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", service)
+	}
+	if err := s.mux.readiness(ctx); err != nil {
+		s.mux.getLogger().DebugContext(ctx, "not ready", "details", err)
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// hasService reports whether the gRPC service of the name is registered by the mux.
+func (mux *ServerMux) hasService(name string) bool {
+	switch name {
+	case tracepb.TraceService_ServiceDesc.ServiceName:
+		_, ok := mux.getTraceEntry()
+		return ok
+	case metricspb.MetricsService_ServiceDesc.ServiceName:
+		_, ok := mux.getMetricsEntry()
+		return ok
+	case logspb.LogsService_ServiceDesc.ServiceName:
+		_, ok := mux.getLogsEntry()
+		return ok
+	case profilespb.ProfilesService_ServiceDesc.ServiceName:
+		_, ok := mux.getProfilesEntry()
+		return ok
+	}
+	return false
+}
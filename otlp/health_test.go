@@ -0,0 +1,75 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestMux__HealthCheck(t *testing.T) {
+	var notReady atomic.Bool
+	mux := otlp.NewServerMux(
+		otlp.WithHTTPPathPrefix("/otlp"),
+		otlp.WithHealthCheck(func(context.Context) error {
+			if notReady.Load() {
+				return errors.New("sink unavailable")
+			}
+			return nil
+		}),
+	)
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	conn, err := grpc.NewClient(server.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	health := healthpb.NewHealthClient(conn)
+	check := func(service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		resp, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	require.Equal(t, http.StatusOK, get("/otlp/healthz"))
+	require.Equal(t, http.StatusOK, get("/otlp/readyz"))
+	st, err := check("")
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, st)
+	st, err = check("opentelemetry.proto.collector.trace.v1.TraceService")
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, st)
+	_, err = check("opentelemetry.proto.collector.logs.v1.LogsService")
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	notReady.Store(true)
+	require.Equal(t, http.StatusOK, get("/otlp/healthz"))
+	require.Equal(t, http.StatusServiceUnavailable, get("/otlp/readyz"))
+	st, err = check("")
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, st)
+}
+
+func TestMux__HealthCheck_Disabled(t *testing.T) {
+	mux := otlp.NewServerMux()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
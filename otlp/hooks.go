@@ -0,0 +1,93 @@
+package otlp
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExportInfo describes an export request handled by ServerMux, passed to the hooks of WithOnRequest, WithOnResponse and WithOnError.
+type ExportInfo struct {
+	// Signal is "traces", "metrics", "logs" or "profiles".
+	Signal string
+	// Items is the number of the spans, data points, log records or profiles in the request.
+	Items int
+	// Rejected is the number of the items rejected by the partial success of the response, set on OnResponse.
+	Rejected int64
+	// Duration is the latency of the handler including the middlewares, zero on OnRequest.
+	Duration time.Duration
+	// Code is the status code of the response, codes.OK except on OnError.
+	Code codes.Code
+}
+
+type exportHooks struct {
+	onRequest  []func(ctx context.Context, info ExportInfo)
+	onResponse []func(ctx context.Context, info ExportInfo)
+	onError    []func(ctx context.Context, info ExportInfo, err error)
+}
+
+// WithOnRequest adds the hook called before the middlewares and the handler of every export, over HTTP, gRPC and WebSocket alike.
+// the hooks are lighter than the middlewares for metrics and auditing, they observe the exports without changing them.
+// they are called synchronously in the order added, so keep them fast. ctx is the context of the request, see RequestIDFromContext.
+func WithOnRequest(hook func(ctx context.Context, info ExportInfo)) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.hooks.onRequest = append(mux.hooks.onRequest, hook)
+	}
+}
+
+// WithOnResponse adds the hook called after every successful export, including the partial successes, see ExportInfo.Rejected.
+func WithOnResponse(hook func(ctx context.Context, info ExportInfo)) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.hooks.onResponse = append(mux.hooks.onResponse, hook)
+	}
+}
+
+// WithOnError adds the hook called after every failed export with the error of the handler.
+func WithOnError(hook func(ctx context.Context, info ExportInfo, err error)) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.hooks.onError = append(mux.hooks.onError, hook)
+	}
+}
+
+// begin calls the OnRequest hooks, and returns the function to call the OnResponse or OnError hooks at the end of the export.
+func (h *exportHooks) begin(ctx context.Context, signalType string, items int) func(resp proto.Message, err error) {
+	if len(h.onRequest) == 0 && len(h.onResponse) == 0 && len(h.onError) == 0 {
+		return func(proto.Message, error) {}
+	}
+	info := ExportInfo{Signal: signalType, Items: items, Code: codes.OK}
+	for _, hook := range h.onRequest {
+		hook(ctx, info)
+	}
+	start := time.Now()
+	return func(resp proto.Message, err error) {
+		info.Duration = time.Since(start)
+		if err != nil {
+			info.Code = statusFromError(err).Code()
+			for _, hook := range h.onError {
+				hook(ctx, info, err)
+			}
+			return
+		}
+		info.Rejected = rejectedItems(resp)
+		for _, hook := range h.onResponse {
+			hook(ctx, info)
+		}
+	}
+}
+
+// rejectedItems returns the number of the rejected items in the partial success of the export response.
+func rejectedItems(resp proto.Message) int64 {
+	switch resp := resp.(type) {
+	case *TraceResponse:
+		return resp.GetPartialSuccess().GetRejectedSpans()
+	case *MetricsResponse:
+		return resp.GetPartialSuccess().GetRejectedDataPoints()
+	case *LogsResponse:
+		return resp.GetPartialSuccess().GetRejectedLogRecords()
+	case *ProfilesResponse:
+		return resp.GetPartialSuccess().GetRejectedProfiles()
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMux__Hooks(t *testing.T) {
+	var events []string
+	var infos []otlp.ExportInfo
+	var errs []error
+	mux := otlp.NewServerMux(
+		otlp.WithOnRequest(func(ctx context.Context, info otlp.ExportInfo) {
+			_, ok := otlp.RequestIDFromContext(ctx)
+			require.True(t, ok)
+			events = append(events, "request")
+			infos = append(infos, info)
+		}),
+		otlp.WithOnResponse(func(_ context.Context, info otlp.ExportInfo) {
+			events = append(events, "response")
+			infos = append(infos, info)
+		}),
+		otlp.WithOnError(func(_ context.Context, info otlp.ExportInfo, err error) {
+			events = append(events, "error")
+			infos = append(infos, info)
+			errs = append(errs, err)
+		}),
+		otlp.WithOnResponse(func(_ context.Context, _ otlp.ExportInfo) {
+			events = append(events, "response2")
+		}),
+	)
+	errDenied := status.Error(codes.PermissionDenied, "denied")
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		events = append(events, "handler")
+		switch otlp.TotalSpans(req.GetResourceSpans()) {
+		case 2:
+			return otlp.NewTracePartialSuccess(1, "rejected"), nil
+		case 3:
+			return nil, errDenied
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	post := func(n int) int {
+		body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(n)})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, post(1))
+	require.Equal(t, []string{"request", "handler", "response", "response2"}, events)
+	require.Equal(t, otlp.ExportInfo{Signal: "traces", Items: 1, Code: codes.OK}, infos[0])
+	require.Equal(t, 1, infos[1].Items)
+	require.Zero(t, infos[1].Rejected)
+	require.Positive(t, infos[1].Duration)
+
+	events, infos = nil, nil
+	require.Equal(t, http.StatusOK, post(2))
+	require.Equal(t, []string{"request", "handler", "response", "response2"}, events)
+	require.EqualValues(t, 1, infos[1].Rejected)
+	require.Equal(t, codes.OK, infos[1].Code)
+
+	events, infos = nil, nil
+	require.Equal(t, http.StatusForbidden, post(3))
+	require.Equal(t, []string{"request", "handler", "error"}, events)
+	require.Equal(t, 3, infos[1].Items)
+	require.Equal(t, codes.PermissionDenied, infos[1].Code)
+	require.Len(t, errs, 1)
+	require.True(t, errors.Is(errs[0], errDenied))
+}
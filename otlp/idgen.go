@@ -0,0 +1,92 @@
+package otlp
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IDGenerator generates trace IDs and span IDs.
+// it is the same interface as IDGenerator of go.opentelemetry.io/otel/sdk/trace, so the generators can be used with trace.WithIDGenerator,
+// e.g. for otlptest TraceService.Provider with otlptest.WithIDGenerator.
+type IDGenerator interface {
+	NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID)
+	NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID
+}
+
+// NewRandomIDGenerator returns an IDGenerator that generates random IDs.
+func NewRandomIDGenerator() IDGenerator {
+	return randomIDGenerator{}
+}
+
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	for !tid.IsValid() {
+		binary.BigEndian.PutUint64(tid[0:8], rand.Uint64())
+		binary.BigEndian.PutUint64(tid[8:16], rand.Uint64())
+	}
+	return tid, randomSpanID()
+}
+
+func (randomIDGenerator) NewSpanID(_ context.Context, _ trace.TraceID) trace.SpanID {
+	return randomSpanID()
+}
+
+func randomSpanID() trace.SpanID {
+	var sid trace.SpanID
+	for !sid.IsValid() {
+		binary.BigEndian.PutUint64(sid[:], rand.Uint64())
+	}
+	return sid
+}
+
+// NewXRayIDGenerator returns an IDGenerator that generates AWS X-Ray compatible trace IDs,
+// the first 4 bytes of the trace ID are the epoch time in seconds and the rest are random.
+func NewXRayIDGenerator() IDGenerator {
+	return xrayIDGenerator{now: time.Now}
+}
+
+type xrayIDGenerator struct {
+	now func() time.Time
+}
+
+func (g xrayIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	binary.BigEndian.PutUint32(tid[0:4], uint32(g.now().Unix()))
+	binary.BigEndian.PutUint32(tid[4:8], rand.Uint32())
+	binary.BigEndian.PutUint64(tid[8:16], rand.Uint64())
+	return tid, randomSpanID()
+}
+
+func (xrayIDGenerator) NewSpanID(_ context.Context, _ trace.TraceID) trace.SpanID {
+	return randomSpanID()
+}
+
+// NewCounterIDGenerator returns an IDGenerator that generates sequential IDs starting from 1.
+// it is useful for tests that need deterministic IDs.
+func NewCounterIDGenerator() IDGenerator {
+	return &counterIDGenerator{}
+}
+
+type counterIDGenerator struct {
+	traces atomic.Uint64
+	spans  atomic.Uint64
+}
+
+func (g *counterIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	binary.BigEndian.PutUint64(tid[8:16], g.traces.Add(1))
+	return tid, g.NewSpanID(ctx, tid)
+}
+
+func (g *counterIDGenerator) NewSpanID(_ context.Context, _ trace.TraceID) trace.SpanID {
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(sid[:], g.spans.Add(1))
+	return sid
+}
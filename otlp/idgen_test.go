@@ -0,0 +1,37 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var _ sdktrace.IDGenerator = otlp.NewRandomIDGenerator()
+
+func TestIDGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	tid, sid := otlp.NewRandomIDGenerator().NewIDs(ctx)
+	require.True(t, tid.IsValid())
+	require.True(t, sid.IsValid())
+
+	before := time.Now().Unix()
+	tid, sid = otlp.NewXRayIDGenerator().NewIDs(ctx)
+	require.True(t, tid.IsValid())
+	require.True(t, sid.IsValid())
+	require.GreaterOrEqual(t, int64(binary.BigEndian.Uint32(tid[0:4])), before)
+	require.LessOrEqual(t, int64(binary.BigEndian.Uint32(tid[0:4])), time.Now().Unix())
+
+	gen := otlp.NewCounterIDGenerator()
+	tid, sid = gen.NewIDs(ctx)
+	require.Equal(t, "00000000000000000000000000000001", tid.String())
+	require.Equal(t, "0000000000000001", sid.String())
+	require.Equal(t, "0000000000000002", gen.NewSpanID(ctx, tid).String())
+	tid, _ = gen.NewIDs(ctx)
+	require.Equal(t, "00000000000000000000000000000002", tid.String())
+}
@@ -1,6 +1,7 @@
 package otlp
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -18,6 +19,11 @@ var defaultMarshalOptions = protojson.MarshalOptions{
 	EmitUnpopulated: false,
 }
 
+// defaultUnmarshalOptions follows the OTLP/JSON receivers, that MUST ignore the unknown fields.
+var defaultUnmarshalOptions = protojson.UnmarshalOptions{
+	DiscardUnknown: true,
+}
+
 // MarshalJSON marshals a proto.Message to JSON bytes. for OTLP, traceID and spanID are converted from base64 to hex.
 func MarshalJSON(msg proto.Message) ([]byte, error) {
 	data, err := defaultMarshalOptions.Marshal(msg)
@@ -71,7 +77,7 @@ func (e *JSONEncoder) Encode(msg proto.Message) error {
 
 func convertTraceIDAndSpanIDBase64ToHex(data []byte, indent string) []byte {
 	var m any
-	if err := json.Unmarshal(data, &m); err != nil {
+	if err := unmarshalJSONNumber(data, &m); err != nil {
 		slog.Warn("failed to convert traceID and spanID from base64 to hex", "error", err.Error())
 		return data
 	}
@@ -132,17 +138,34 @@ func convertTraceIDAndSpanIDBase64ToHexForMap(data map[string]interface{}) map[s
 }
 
 // UnmarshalJSON unmarshals JSON bytes to a proto.Message. for OTLP, traceID and spanID are converted from hex to base64.
+// as the OTLP/JSON receivers, enums are accepted as numbers or names, int64 as strings or numbers, and the unknown fields are ignored.
 func UnmarshalJSON(data []byte, msg proto.Message) error {
 	var m any
-	if err := json.Unmarshal(data, &m); err != nil {
+	if err := unmarshalJSONNumber(data, &m); err != nil {
 		return err
 	}
-	m = convertTraceIDAndSpanIDHexToBase64ForAny(m)
-	data, err := json.Marshal(m)
+	m, err := convertTraceIDAndSpanIDHexToBase64ForAny(m)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(m)
 	if err != nil {
 		return err
 	}
-	return protojson.Unmarshal(data, msg)
+	return defaultUnmarshalOptions.Unmarshal(data, msg)
+}
+
+// unmarshalJSONNumber unmarshals with json.Number, so that int64 and uint64 sent as numbers keep their precision.
+func unmarshalJSONNumber(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after top-level value")
+	}
+	return nil
 }
 
 type JSONDecoder struct {
@@ -151,9 +174,11 @@ type JSONDecoder struct {
 }
 
 func NewJSONDecoder(reader io.Reader) *JSONDecoder {
+	dec := json.NewDecoder(reader)
+	dec.UseNumber()
 	return &JSONDecoder{
-		dec:  json.NewDecoder(reader),
-		opts: protojson.UnmarshalOptions{},
+		dec:  dec,
+		opts: defaultUnmarshalOptions,
 	}
 }
 
@@ -166,7 +191,10 @@ func (d *JSONDecoder) Decode(msg proto.Message) error {
 	if err := d.dec.Decode(&m); err != nil {
 		return err
 	}
-	m = convertTraceIDAndSpanIDHexToBase64ForAny(m)
+	m, err := convertTraceIDAndSpanIDHexToBase64ForAny(m)
+	if err != nil {
+		return err
+	}
 	data, err := json.Marshal(m)
 	if err != nil {
 		return err
@@ -174,33 +202,42 @@ func (d *JSONDecoder) Decode(msg proto.Message) error {
 	return d.opts.Unmarshal(data, msg)
 }
 
-func convertTraceIDAndSpanIDHexToBase64ForAny(data any) any {
+func convertTraceIDAndSpanIDHexToBase64ForAny(data any) (any, error) {
 	switch data := data.(type) {
 	case map[string]interface{}:
 		return convertTraceIDAndSpanIDHexToBase64ForMap(data)
 	case []interface{}:
 		for i, v := range data {
-			data[i] = convertTraceIDAndSpanIDHexToBase64ForAny(v)
+			converted, err := convertTraceIDAndSpanIDHexToBase64ForAny(v)
+			if err != nil {
+				return nil, err
+			}
+			data[i] = converted
 		}
 	}
-	return data
+	return data, nil
 }
 
-func convertTraceIDAndSpanIDHexToBase64ForMap(data map[string]interface{}) map[string]interface{} {
+// convertTraceIDAndSpanIDHexToBase64ForMap converts the hex of traceID and spanID, case-insensitively.
+// the invalid hex is an error, because passing it through would be decoded as base64 silently.
+func convertTraceIDAndSpanIDHexToBase64ForMap(data map[string]interface{}) (map[string]interface{}, error) {
 	for k, v := range data {
 		if keyIsTraceIDOrSpanID(k) {
 			if s, ok := v.(string); ok {
 				bs, err := hex.DecodeString(s)
 				if err != nil {
-					slog.Warn("failed to convert traceID and spanID from hex to base64", "error", err.Error())
-					continue
+					return nil, fmt.Errorf("%s: invalid hex: %w", k, err)
 				}
 				data[k] = base64.StdEncoding.EncodeToString(bs)
 				continue
 			}
 			slog.Warn("unexpected type of traceID and spanID", "key", k, "value_type", fmt.Sprintf("%T", v))
 		}
-		data[k] = convertTraceIDAndSpanIDHexToBase64ForAny(v)
+		converted, err := convertTraceIDAndSpanIDHexToBase64ForAny(v)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = converted
 	}
-	return data
+	return data, nil
 }
@@ -2,11 +2,18 @@ package otlp_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/mashiike/go-otlp-helper/otlp"
 	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
 
 func TestJSONEncoding_Trace(t *testing.T) {
@@ -39,3 +46,128 @@ func TestJSONEncoding_Trace(t *testing.T) {
 	require.NoError(t, enc.Encode(&req))
 	require.JSONEq(t, string(bs), buf.String())
 }
+
+// the payloads of testdata/conformance follow the OTLP/JSON exported by the JS and the Python SDKs,
+// with lower and upper case hex ids, enums as numbers and names, int64 as strings and numbers, and unknown fields.
+func TestServerMux_HTTP_JSONConformance(t *testing.T) {
+	traces := make(chan *otlp.TraceRequest, 1)
+	metrics := make(chan *otlp.MetricsRequest, 1)
+	logs := make(chan *otlp.LogsRequest, 1)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		traces <- req
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Metrics().HandleFunc(func(_ context.Context, req *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
+		metrics <- req
+		return &otlp.MetricsResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, req *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		logs <- req
+		return &otlp.LogsResponse{}, nil
+	})
+	post := func(t *testing.T, path, name string) {
+		t.Helper()
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.True(t, json.Valid(w.Body.Bytes()))
+	}
+	mustHex := func(s string) []byte {
+		bs, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return bs
+	}
+
+	t.Run("js trace", func(t *testing.T) {
+		post(t, "/v1/traces", "testdata/conformance/js_trace.json")
+		req := <-traces
+		rs := req.GetResourceSpans()[0]
+		require.Equal(t, int64(4242), rs.GetResource().GetAttributes()[2].GetValue().GetIntValue())
+		span := rs.GetScopeSpans()[0].GetSpans()[0]
+		require.Equal(t, mustHex("5b8efff798038103d269b633813fc60c"), span.GetTraceId())
+		require.Equal(t, mustHex("eee19b7ec3c1b174"), span.GetSpanId())
+		require.Equal(t, mustHex("eee19b7ec3c1b173"), span.GetParentSpanId())
+		require.Equal(t, tracepb.Span_SPAN_KIND_SERVER, span.GetKind())
+		require.Equal(t, uint64(1544712660000000000), span.GetStartTimeUnixNano())
+		require.Equal(t, int64(9007199254740993), span.GetAttributes()[1].GetValue().GetIntValue())
+		require.Equal(t, tracepb.Status_STATUS_CODE_OK, span.GetStatus().GetCode())
+		require.Equal(t, mustHex("0af7651916cd43dd8448eb211c80319c"), span.GetLinks()[0].GetTraceId())
+
+		// emitted as OTLP/JSON: hex ids, enums as numbers and int64 as strings.
+		data, err := otlp.MarshalJSON(req)
+		require.NoError(t, err)
+		var emitted struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.Unmarshal(data, &emitted))
+		emittedSpan := emitted.ResourceSpans[0].ScopeSpans[0].Spans[0]
+		require.Equal(t, span.GetTraceId(), mustHex(emittedSpan["traceId"].(string)))
+		require.Equal(t, span.GetSpanId(), mustHex(emittedSpan["spanId"].(string)))
+		require.Equal(t, float64(2), emittedSpan["kind"])
+		require.Equal(t, "1544712660000000000", emittedSpan["startTimeUnixNano"])
+		var decoded otlp.TraceRequest
+		require.NoError(t, otlp.UnmarshalJSON(data, &decoded))
+		assertEqualMessage(t, req, &decoded)
+	})
+	t.Run("js metrics", func(t *testing.T) {
+		post(t, "/v1/metrics", "testdata/conformance/js_metrics.json")
+		ms := (<-metrics).GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics()
+		require.Len(t, ms, 2)
+		sum := ms[0].GetSum()
+		require.True(t, sum.GetIsMonotonic())
+		require.Equal(t, int64(10), sum.GetDataPoints()[0].GetAsInt())
+		require.Equal(t, uint64(1544712661000000000), sum.GetDataPoints()[0].GetTimeUnixNano())
+		histogram := ms[1].GetHistogram().GetDataPoints()[0]
+		require.Equal(t, uint64(3), histogram.GetCount())
+		require.Equal(t, []uint64{1, 2, 0}, histogram.GetBucketCounts())
+		require.Equal(t, []float64{5, 10}, histogram.GetExplicitBounds())
+	})
+	t.Run("python logs", func(t *testing.T) {
+		post(t, "/v1/logs", "testdata/conformance/python_logs.json")
+		rl := (<-logs).GetResourceLogs()[0]
+		require.Equal(t, "https://opentelemetry.io/schemas/1.11.0", rl.GetSchemaUrl())
+		record := rl.GetScopeLogs()[0].GetLogRecords()[0]
+		require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, record.GetSeverityNumber())
+		require.Equal(t, uint64(1544712660300000001), record.GetObservedTimeUnixNano())
+		require.Equal(t, int64(42), record.GetAttributes()[0].GetValue().GetIntValue())
+		require.Equal(t, mustHex("5b8efff798038103d269b633813fc60c"), record.GetTraceId())
+		require.Equal(t, mustHex("eee19b7ec3c1b174"), record.GetSpanId())
+	})
+}
+
+func TestUnmarshalJSON_Conformance(t *testing.T) {
+	t.Run("int64 as number keeps the precision", func(t *testing.T) {
+		var req otlp.LogsRequest
+		require.NoError(t, otlp.UnmarshalJSON([]byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"timeUnixNano":1544712660300000001}]}]}]}`), &req))
+		require.Equal(t, uint64(1544712660300000001), req.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()[0].GetTimeUnixNano())
+	})
+	t.Run("unknown fields are ignored", func(t *testing.T) {
+		var req otlp.TraceRequest
+		require.NoError(t, otlp.UnmarshalJSON([]byte(`{"resourceSpans":[{"unknown":{"nested":[1]}}],"unknown":true}`), &req))
+		require.Len(t, req.GetResourceSpans(), 1)
+	})
+	t.Run("invalid hex is an error", func(t *testing.T) {
+		var req otlp.TraceRequest
+		// 32 characters of base64, that must not be decoded as base64 silently.
+		require.Error(t, otlp.UnmarshalJSON([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"W47/95gDgQPSabYzgT/GDA=========="}]}]}]}`), &req))
+	})
+	t.Run("decoder", func(t *testing.T) {
+		dec := otlp.NewJSONDecoder(bytes.NewReader([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"5B8EFFF798038103D269B633813FC60C","kind":"SPAN_KIND_CLIENT","endTimeUnixNano":1544712661000000001}]}]}]}`)))
+		var req otlp.TraceRequest
+		require.NoError(t, dec.Decode(&req))
+		span := req.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0]
+		require.Len(t, span.GetTraceId(), 16)
+		require.Equal(t, tracepb.Span_SPAN_KIND_CLIENT, span.GetKind())
+		require.Equal(t, uint64(1544712661000000001), span.GetEndTimeUnixNano())
+	})
+}
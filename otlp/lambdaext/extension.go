@@ -0,0 +1,287 @@
+// Package lambdaext implements a Lambda extension that forwards the telemetry of the function to an OTLP endpoint.
+// it registers itself to the Extensions API, subscribes to the Telemetry API, converts the received events with ConvertEvents,
+// and uploads them with otlp.Client.
+package lambdaext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const (
+	extensionAPIVersion = "2020-01-01"
+	telemetryAPIVersion = "2022-07-01"
+	telemetrySchema     = "2022-12-13"
+
+	// DefaultListenAddr is the address the telemetry listener listens on. the Telemetry API only sends to sandbox.localdomain.
+	DefaultListenAddr = "sandbox.localdomain:4243"
+)
+
+type options struct {
+	name       string
+	runtimeAPI string
+	listenAddr string
+	types      []string
+	resource   *resourcepb.Resource
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// Option is the option for Run.
+type Option func(*options)
+
+// WithName sets the name of the extension. default is the base name of the executable, as Lambda requires for external extensions.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithRuntimeAPI sets the host of the Lambda Runtime API. default is $AWS_LAMBDA_RUNTIME_API.
+func WithRuntimeAPI(host string) Option {
+	return func(o *options) {
+		o.runtimeAPI = host
+	}
+}
+
+// WithListenAddr sets the address of the telemetry listener. default is DefaultListenAddr.
+func WithListenAddr(addr string) Option {
+	return func(o *options) {
+		o.listenAddr = addr
+	}
+}
+
+// WithTypes sets the telemetry types to subscribe, "platform", "function" and "extension". default is all of them.
+func WithTypes(types ...string) Option {
+	return func(o *options) {
+		o.types = types
+	}
+}
+
+// WithResource sets the resource of the converted telemetry. default is DefaultResource().
+func WithResource(resource *resourcepb.Resource) Option {
+	return func(o *options) {
+		o.resource = resource
+	}
+}
+
+// WithLogger sets the logger of the extension.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// NewListener returns an http.Handler that receives the batches of the Telemetry API, converts them and uploads them with the client.
+// Run serves it; use it directly to serve the telemetry with your own server.
+func NewListener(client *otlp.Client, resource *resourcepb.Resource, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := r.Context()
+		var events []Event
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			logger.WarnContext(ctx, "failed to decode telemetry events", "details", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		spans, logs, err := ConvertEvents(events, resource)
+		if err != nil {
+			logger.WarnContext(ctx, "failed to convert telemetry events", "details", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// the Telemetry API does not retry, so failed uploads are only logged.
+		if len(spans) > 0 {
+			if err := client.UploadTraces(ctx, spans); err != nil {
+				logger.ErrorContext(ctx, "failed to upload traces", "details", err)
+			}
+		}
+		if len(logs) > 0 {
+			if err := client.UploadLogs(ctx, logs); err != nil {
+				logger.ErrorContext(ctx, "failed to upload logs", "details", err)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// Run runs the extension until the SHUTDOWN event is received or ctx is done.
+// client must be started; Run flushes it before returning, but does not stop it.
+func Run(ctx context.Context, client *otlp.Client, opts ...Option) error {
+	o := &options{
+		runtimeAPI: os.Getenv("AWS_LAMBDA_RUNTIME_API"),
+		listenAddr: DefaultListenAddr,
+		types:      []string{"platform", "function", "extension"},
+		httpClient: http.DefaultClient,
+	}
+	if exe, err := os.Executable(); err == nil {
+		o.name = filepath.Base(exe)
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.runtimeAPI == "" {
+		return errors.New("lambda runtime api is not set, AWS_LAMBDA_RUNTIME_API is required")
+	}
+	if o.resource == nil {
+		o.resource = DefaultResource()
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	ext := &extension{options: o}
+	if err := ext.register(ctx); err != nil {
+		return fmt.Errorf("register extension: %w", err)
+	}
+	host, _, err := net.SplitHostPort(o.listenAddr)
+	if err != nil {
+		return fmt.Errorf("listen addr: %w", err)
+	}
+	ln, err := net.Listen("tcp", o.listenAddr)
+	if err != nil {
+		return fmt.Errorf("listen telemetry: %w", err)
+	}
+	server := &http.Server{Handler: NewListener(client, o.resource, o.logger)}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	if err := ext.subscribe(ctx, "http://"+net.JoinHostPort(host, port)); err != nil {
+		server.Close()
+		return fmt.Errorf("subscribe telemetry: %w", err)
+	}
+
+	deadline, err := ext.loop(ctx)
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	// wait for the batches in flight, then for their uploads.
+	if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+		err = errors.Join(err, shutdownErr)
+	}
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	if flushErr := client.ForceFlush(shutdownCtx); flushErr != nil {
+		err = errors.Join(err, flushErr)
+	}
+	return err
+}
+
+type extension struct {
+	*options
+	id string
+}
+
+type nextEvent struct {
+	EventType  string `json:"eventType"`
+	DeadlineMs int64  `json:"deadlineMs"`
+}
+
+// loop waits for the next events until SHUTDOWN, and returns the deadline to finish the shutdown.
+func (ext *extension) loop(ctx context.Context) (time.Time, error) {
+	for {
+		var event nextEvent
+		_, err := ext.do(ctx, http.MethodGet, extensionAPIVersion+"/extension/event/next", nil, nil, &event)
+		if err != nil {
+			if ctx.Err() != nil {
+				return time.Now().Add(time.Second), nil
+			}
+			return time.Now().Add(time.Second), fmt.Errorf("next event: %w", err)
+		}
+		ext.logger.DebugContext(ctx, "received event", "event_type", event.EventType)
+		if event.EventType == "SHUTDOWN" {
+			return time.UnixMilli(event.DeadlineMs), nil
+		}
+	}
+}
+
+func (ext *extension) register(ctx context.Context) error {
+	body := map[string]any{"events": []string{"INVOKE", "SHUTDOWN"}}
+	header := http.Header{}
+	header.Set("Lambda-Extension-Name", ext.name)
+	respHeader, err := ext.do(ctx, http.MethodPost, extensionAPIVersion+"/extension/register", header, body, nil)
+	if err != nil {
+		return err
+	}
+	ext.id = respHeader.Get("Lambda-Extension-Identifier")
+	if ext.id == "" {
+		return errors.New("extension identifier is not returned")
+	}
+	return nil
+}
+
+func (ext *extension) subscribe(ctx context.Context, destination string) error {
+	body := map[string]any{
+		"schemaVersion": telemetrySchema,
+		"types":         ext.types,
+		"buffering": map[string]any{
+			"maxItems":  1000,
+			"maxBytes":  256 * 1024,
+			"timeoutMs": 100,
+		},
+		"destination": map[string]any{
+			"protocol": "HTTP",
+			"URI":      destination,
+		},
+	}
+	_, err := ext.do(ctx, http.MethodPut, telemetryAPIVersion+"/telemetry", nil, body, nil)
+	return err
+}
+
+// do calls the Runtime API, and decodes the JSON response into out if it is not nil.
+func (ext *extension) do(ctx context.Context, method, path string, header http.Header, body, out any) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(bs)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+ext.runtimeAPI+"/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ext.id != "" {
+		req.Header.Set("Lambda-Extension-Identifier", ext.id)
+	}
+	resp, err := ext.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bs, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(bs))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
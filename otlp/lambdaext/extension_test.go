@@ -0,0 +1,98 @@
+package lambdaext_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/lambdaext"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var spans, logRecords atomic.Int32
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		spans.Add(int32(otlp.TotalSpans(req.GetResourceSpans())))
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, req *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		logRecords.Add(int32(otlp.TotalLogRecords(req.GetResourceLogs())))
+		return &otlp.LogsResponse{}, nil
+	})
+	collector := otlptest.NewHTTPServer(mux)
+	defer collector.Close()
+
+	delivered := make(chan struct{})
+	var nextCalls atomic.Int32
+	runtimeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2020-01-01/extension/register":
+			assert.Equal(t, "test-extension", r.Header.Get("Lambda-Extension-Name"))
+			w.Header().Set("Lambda-Extension-Identifier", "test-id")
+			w.Write([]byte(`{}`))
+		case "/2022-07-01/telemetry":
+			assert.Equal(t, "test-id", r.Header.Get("Lambda-Extension-Identifier"))
+			var body struct {
+				Destination struct {
+					URI string `json:"URI"`
+				} `json:"destination"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.True(t, strings.HasPrefix(body.Destination.URI, "http://127.0.0.1:"))
+			w.Write([]byte(`"OK"`))
+			go func() {
+				defer close(delivered)
+				resp, err := http.Post(body.Destination.URI, "application/json", bytes.NewReader([]byte(testEvents)))
+				if assert.NoError(t, err) {
+					resp.Body.Close()
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+				}
+			}()
+		case "/2020-01-01/extension/event/next":
+			if nextCalls.Add(1) == 1 {
+				<-delivered
+				w.Write([]byte(`{"eventType": "INVOKE", "deadlineMs": 0}`))
+				return
+			}
+			deadline := time.Now().Add(5 * time.Second).UnixMilli()
+			w.Write([]byte(`{"eventType": "SHUTDOWN", "deadlineMs": ` + strconv.FormatInt(deadline, 10) + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer runtimeAPI.Close()
+
+	client, err := otlp.NewClient(collector.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	err = lambdaext.Run(ctx, client,
+		lambdaext.WithName("test-extension"),
+		lambdaext.WithRuntimeAPI(strings.TrimPrefix(runtimeAPI.URL, "http://")),
+		lambdaext.WithListenAddr("127.0.0.1:0"),
+	)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, nextCalls.Load())
+	require.EqualValues(t, 2, spans.Load())
+	require.EqualValues(t, 4, logRecords.Load())
+}
+
+func TestRun_NoRuntimeAPI(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	err := lambdaext.Run(context.Background(), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,302 @@
+package lambdaext
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// ScopeName is the instrumentation scope name of the converted spans and log records.
+const ScopeName = "github.com/mashiike/go-otlp-helper/otlp/lambdaext"
+
+// Event is an event sent by the Lambda Telemetry API.
+// see https://docs.aws.amazon.com/lambda/latest/dg/telemetry-schema-reference.html
+type Event struct {
+	Time   time.Time       `json:"time"`
+	Type   string          `json:"type"`
+	Record json.RawMessage `json:"record"`
+}
+
+type platformRecord struct {
+	RequestID string `json:"requestId"`
+	Status    string `json:"status"`
+	Metrics   struct {
+		DurationMs float64 `json:"durationMs"`
+	} `json:"metrics"`
+	Tracing *struct {
+		SpanID string `json:"spanId"`
+		Type   string `json:"type"`
+		Value  string `json:"value"`
+	} `json:"tracing"`
+	Spans []struct {
+		Name       string    `json:"name"`
+		Start      time.Time `json:"start"`
+		DurationMs float64   `json:"durationMs"`
+	} `json:"spans"`
+}
+
+// DefaultResource returns the resource of the function, built from the environment variables of the Lambda execution environment.
+func DefaultResource() *resourcepb.Resource {
+	var attrs []*commonpb.KeyValue
+	add := func(key, env string) {
+		if v := os.Getenv(env); v != "" {
+			attrs = append(attrs, stringAttribute(key, v))
+		}
+	}
+	add(string(semconv.ServiceNameKey), "AWS_LAMBDA_FUNCTION_NAME")
+	add(string(semconv.FaaSNameKey), "AWS_LAMBDA_FUNCTION_NAME")
+	add(string(semconv.FaaSVersionKey), "AWS_LAMBDA_FUNCTION_VERSION")
+	add(string(semconv.FaaSInstanceKey), "AWS_LAMBDA_LOG_STREAM_NAME")
+	add(string(semconv.CloudRegionKey), "AWS_REGION")
+	attrs = append(attrs,
+		stringAttribute(string(semconv.CloudProviderKey), semconv.CloudProviderAWS.Value.AsString()),
+		stringAttribute(string(semconv.CloudPlatformKey), semconv.CloudPlatformAWSLambda.Value.AsString()),
+	)
+	return &resourcepb.Resource{Attributes: attrs}
+}
+
+// ConvertEvents converts the telemetry events into OTLP spans and log records of the resource.
+// every event becomes a log record; function and extension logs keep their text or JSON body, and platform events carry the record as the body.
+// platform.runtimeDone events become an invocation span, with the spans reported in the record as its children.
+// the trace context is taken from the X-Ray trace header of the record when present.
+func ConvertEvents(events []Event, resource *resourcepb.Resource) ([]*otlp.ResourceSpans, []*otlp.ResourceLogs, error) {
+	scope := &commonpb.InstrumentationScope{Name: ScopeName}
+	scopeSpans := &tracepb.ScopeSpans{Scope: scope}
+	scopeLogs := &logspb.ScopeLogs{Scope: scope}
+	for _, event := range events {
+		logRecord, err := convertLogRecord(event)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s event at %s: %w", event.Type, event.Time.Format(time.RFC3339Nano), err)
+		}
+		scopeLogs.LogRecords = append(scopeLogs.LogRecords, logRecord)
+		if event.Type != "platform.runtimeDone" {
+			continue
+		}
+		var record platformRecord
+		if err := json.Unmarshal(event.Record, &record); err != nil {
+			return nil, nil, fmt.Errorf("%s event at %s: %w", event.Type, event.Time.Format(time.RFC3339Nano), err)
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, convertInvokeSpans(event, record)...)
+	}
+	var (
+		resourceSpans []*otlp.ResourceSpans
+		resourceLogs  []*otlp.ResourceLogs
+	)
+	if len(scopeSpans.Spans) > 0 {
+		resourceSpans = append(resourceSpans, &otlp.ResourceSpans{
+			Resource:   resource,
+			ScopeSpans: []*tracepb.ScopeSpans{scopeSpans},
+			SchemaUrl:  semconv.SchemaURL,
+		})
+	}
+	if len(scopeLogs.LogRecords) > 0 {
+		resourceLogs = append(resourceLogs, &otlp.ResourceLogs{
+			Resource:  resource,
+			ScopeLogs: []*logspb.ScopeLogs{scopeLogs},
+			SchemaUrl: semconv.SchemaURL,
+		})
+	}
+	return resourceSpans, resourceLogs, nil
+}
+
+func convertLogRecord(event Event) (*logspb.LogRecord, error) {
+	var value any
+	if len(event.Record) > 0 {
+		if err := json.Unmarshal(event.Record, &value); err != nil {
+			return nil, err
+		}
+	}
+	logRecord := &logspb.LogRecord{
+		TimeUnixNano:         uint64(event.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(event.Time.UnixNano()),
+		Body:                 anyValue(value),
+		Attributes: []*commonpb.KeyValue{
+			stringAttribute("lambda.event.type", event.Type),
+		},
+	}
+	switch event.Type {
+	case "function", "extension":
+		if fields, ok := value.(map[string]any); ok {
+			// JSON log format: {"timestamp": ..., "level": ..., "requestId": ..., "message": ...}
+			if level, ok := fields["level"].(string); ok {
+				logRecord.SeverityText = level
+				logRecord.SeverityNumber = severityNumber(level)
+			}
+			if message, ok := fields["message"]; ok {
+				logRecord.Body = anyValue(message)
+			}
+			if requestID, ok := fields["requestId"].(string); ok {
+				logRecord.Attributes = append(logRecord.Attributes, stringAttribute(string(semconv.FaaSInvocationIDKey), requestID))
+			}
+		}
+	default:
+		logRecord.SeverityNumber = logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+		logRecord.SeverityText = "INFO"
+		if fields, ok := value.(map[string]any); ok {
+			if requestID, ok := fields["requestId"].(string); ok {
+				logRecord.Attributes = append(logRecord.Attributes, stringAttribute(string(semconv.FaaSInvocationIDKey), requestID))
+			}
+			if status, ok := fields["status"].(string); ok && status != "success" {
+				logRecord.SeverityNumber = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+				logRecord.SeverityText = "ERROR"
+			}
+		}
+	}
+	return logRecord, nil
+}
+
+func severityNumber(level string) logspb.SeverityNumber {
+	switch strings.ToUpper(level) {
+	case "TRACE":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE
+	case "DEBUG":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case "INFO":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case "WARN", "WARNING":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case "ERROR":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case "FATAL":
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	}
+	return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+}
+
+var idGenerator = otlp.NewXRayIDGenerator()
+
+func convertInvokeSpans(event Event, record platformRecord) []*tracepb.Span {
+	traceID, spanID := idGenerator.NewIDs(context.Background())
+	var parentSpanID []byte
+	if record.Tracing != nil {
+		if tid, parent, ok := parseXRayTraceHeader(record.Tracing.Value); ok {
+			traceID = tid
+			if parent.IsValid() {
+				parentSpanID = parent[:]
+			}
+		}
+		if sid, err := trace.SpanIDFromHex(record.Tracing.SpanID); err == nil {
+			spanID = sid
+		}
+	}
+	duration := time.Duration(record.Metrics.DurationMs * float64(time.Millisecond))
+	invoke := &tracepb.Span{
+		TraceId:           traceID[:],
+		SpanId:            spanID[:],
+		ParentSpanId:      parentSpanID,
+		Name:              "invoke",
+		Kind:              tracepb.Span_SPAN_KIND_SERVER,
+		StartTimeUnixNano: uint64(event.Time.Add(-duration).UnixNano()),
+		EndTimeUnixNano:   uint64(event.Time.UnixNano()),
+		Attributes: []*commonpb.KeyValue{
+			stringAttribute(string(semconv.FaaSInvocationIDKey), record.RequestID),
+			stringAttribute("lambda.status", record.Status),
+		},
+	}
+	if record.Status != "" && record.Status != "success" {
+		invoke.Status = &tracepb.Status{
+			Code:    tracepb.Status_STATUS_CODE_ERROR,
+			Message: record.Status,
+		}
+	}
+	spans := []*tracepb.Span{invoke}
+	for _, child := range record.Spans {
+		childID := idGenerator.NewSpanID(context.Background(), traceID)
+		spans = append(spans, &tracepb.Span{
+			TraceId:           traceID[:],
+			SpanId:            childID[:],
+			ParentSpanId:      spanID[:],
+			Name:              child.Name,
+			Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: uint64(child.Start.UnixNano()),
+			EndTimeUnixNano:   uint64(child.Start.Add(time.Duration(child.DurationMs * float64(time.Millisecond))).UnixNano()),
+		})
+	}
+	return spans
+}
+
+// parseXRayTraceHeader parses the X-Ray trace header, e.g. Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+func parseXRayTraceHeader(header string) (trace.TraceID, trace.SpanID, bool) {
+	var (
+		traceID trace.TraceID
+		spanID  trace.SpanID
+		ok      bool
+	)
+	for _, part := range strings.Split(header, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Root":
+			fields := strings.Split(value, "-")
+			if len(fields) != 3 || fields[0] != "1" {
+				return traceID, spanID, false
+			}
+			b, err := hex.DecodeString(fields[1] + fields[2])
+			if err != nil || len(b) != len(traceID) {
+				return traceID, spanID, false
+			}
+			copy(traceID[:], b)
+			ok = traceID.IsValid()
+		case "Parent":
+			if sid, err := trace.SpanIDFromHex(value); err == nil {
+				spanID = sid
+			}
+		}
+	}
+	return traceID, spanID, ok
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+// anyValue converts the decoded JSON value into AnyValue.
+func anyValue(v any) *commonpb.AnyValue {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []any:
+		values := make([]*commonpb.AnyValue, 0, len(v))
+		for _, elem := range v {
+			values = append(values, anyValue(elem))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		kvs := make([]*commonpb.KeyValue, 0, len(keys))
+		for _, key := range keys {
+			kvs = append(kvs, &commonpb.KeyValue{Key: key, Value: anyValue(v[key])})
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvs}}}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+}
@@ -0,0 +1,70 @@
+package lambdaext_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/lambdaext"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+const testEvents = `[
+  {"time": "2022-10-12T00:00:00.000Z", "type": "platform.start", "record": {"requestId": "6d68ca91-49c9-448d-89b8-7ca3e6dc66aa", "version": "$LATEST"}},
+  {"time": "2022-10-12T00:00:00.010Z", "type": "function", "record": "plain text log\n"},
+  {"time": "2022-10-12T00:00:00.020Z", "type": "function", "record": {"timestamp": "2022-10-12T00:00:00.020Z", "level": "ERROR", "requestId": "6d68ca91-49c9-448d-89b8-7ca3e6dc66aa", "message": "something failed"}},
+  {"time": "2022-10-12T00:00:00.500Z", "type": "platform.runtimeDone", "record": {
+    "requestId": "6d68ca91-49c9-448d-89b8-7ca3e6dc66aa",
+    "status": "error",
+    "metrics": {"durationMs": 500.0, "producedBytes": 42},
+    "tracing": {"spanId": "54565fb41ac79632", "type": "X-Amzn-Trace-Id", "value": "Root=1-62e900b2-710d76f009d6e7785905449a;Parent=0efbd19962d95b05;Sampled=1"},
+    "spans": [{"name": "responseLatency", "start": "2022-10-12T00:00:00.400Z", "durationMs": 50.0}]
+  }}
+]`
+
+func TestConvertEvents(t *testing.T) {
+	var events []lambdaext.Event
+	require.NoError(t, json.Unmarshal([]byte(testEvents), &events))
+	resource := &resourcepb.Resource{}
+	spans, logs, err := lambdaext.ConvertEvents(events, resource)
+	require.NoError(t, err)
+
+	require.Equal(t, 4, otlp.TotalLogRecords(logs))
+	records := logs[0].GetScopeLogs()[0].GetLogRecords()
+	require.Equal(t, "plain text log\n", records[1].GetBody().GetStringValue())
+	require.Equal(t, "something failed", records[2].GetBody().GetStringValue())
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[2].GetSeverityNumber())
+	require.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[3].GetSeverityNumber())
+	require.NotNil(t, records[0].GetBody().GetKvlistValue())
+
+	require.Equal(t, 2, otlp.TotalSpans(spans))
+	require.Same(t, resource, spans[0].GetResource())
+	invoke := spans[0].GetScopeSpans()[0].GetSpans()[0]
+	child := spans[0].GetScopeSpans()[0].GetSpans()[1]
+	require.Equal(t, "invoke", invoke.GetName())
+	require.Equal(t, "62e900b2710d76f009d6e7785905449a", hex.EncodeToString(invoke.GetTraceId()))
+	require.Equal(t, "54565fb41ac79632", hex.EncodeToString(invoke.GetSpanId()))
+	require.Equal(t, "0efbd19962d95b05", hex.EncodeToString(invoke.GetParentSpanId()))
+	require.Equal(t, tracepb.Status_STATUS_CODE_ERROR, invoke.GetStatus().GetCode())
+	require.EqualValues(t, 500_000_000, invoke.GetEndTimeUnixNano()-invoke.GetStartTimeUnixNano())
+	require.Equal(t, "responseLatency", child.GetName())
+	require.Equal(t, invoke.GetTraceId(), child.GetTraceId())
+	require.Equal(t, invoke.GetSpanId(), child.GetParentSpanId())
+	require.EqualValues(t, 50_000_000, child.GetEndTimeUnixNano()-child.GetStartTimeUnixNano())
+}
+
+func TestConvertEvents_WithoutTracing(t *testing.T) {
+	events := []lambdaext.Event{
+		{Type: "platform.runtimeDone", Record: json.RawMessage(`{"requestId": "r", "status": "success", "metrics": {"durationMs": 1}}`)},
+	}
+	spans, _, err := lambdaext.ConvertEvents(events, nil)
+	require.NoError(t, err)
+	invoke := spans[0].GetScopeSpans()[0].GetSpans()[0]
+	require.Len(t, invoke.GetTraceId(), 16)
+	require.Empty(t, invoke.GetParentSpanId())
+	require.Nil(t, invoke.GetStatus())
+}
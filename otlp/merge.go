@@ -0,0 +1,224 @@
+package otlp
+
+import (
+	"slices"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// MergeResourceSpans is the inverse of SplitResourceSpans, it groups the elements by the identical resource and schema url,
+// and the scope spans of them by the identical scope and schema url, keeping the order of the first appearance.
+// e.g. to compact the outputs of PartitionResourceSpans before the export, instead of sending a ResourceSpans per span.
+// unlike AppendResourceSpans, src is not modified, the spans are shared with the result.
+func MergeResourceSpans(src []*tracepb.ResourceSpans) []*tracepb.ResourceSpans {
+	var m merger[*tracepb.ResourceSpans]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(resourceKey(elem.GetResource(), elem.GetSchemaUrl()), func() *tracepb.ResourceSpans {
+			return &tracepb.ResourceSpans{Resource: elem.GetResource(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.ScopeSpans = append(dst.ScopeSpans, elem.GetScopeSpans()...)
+	}
+	for _, dst := range m.values {
+		dst.ScopeSpans = mergeScopeSpans(dst.GetScopeSpans())
+	}
+	return m.values
+}
+
+func mergeScopeSpans(src []*tracepb.ScopeSpans) []*tracepb.ScopeSpans {
+	var m merger[*tracepb.ScopeSpans]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(scopeKey(elem.GetScope(), elem.GetSchemaUrl()), func() *tracepb.ScopeSpans {
+			return &tracepb.ScopeSpans{Scope: elem.GetScope(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.Spans = append(dst.Spans, elem.GetSpans()...)
+	}
+	return m.values
+}
+
+// MergeResourceMetrics is the inverse of SplitResourceMetrics, it groups the elements by the identical resource and schema url,
+// the scope metrics by the identical scope and schema url, and the data points by the metric of the same name, description, unit and type, see EqualMetric.
+// src is not modified, the data points are shared with the result.
+func MergeResourceMetrics(src []*metricspb.ResourceMetrics) []*metricspb.ResourceMetrics {
+	var m merger[*metricspb.ResourceMetrics]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(resourceKey(elem.GetResource(), elem.GetSchemaUrl()), func() *metricspb.ResourceMetrics {
+			return &metricspb.ResourceMetrics{Resource: elem.GetResource(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.ScopeMetrics = append(dst.ScopeMetrics, elem.GetScopeMetrics()...)
+	}
+	for _, dst := range m.values {
+		dst.ScopeMetrics = mergeScopeMetrics(dst.GetScopeMetrics())
+	}
+	return m.values
+}
+
+func mergeScopeMetrics(src []*metricspb.ScopeMetrics) []*metricspb.ScopeMetrics {
+	var m merger[*metricspb.ScopeMetrics]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(scopeKey(elem.GetScope(), elem.GetSchemaUrl()), func() *metricspb.ScopeMetrics {
+			return &metricspb.ScopeMetrics{Scope: elem.GetScope(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.Metrics = append(dst.Metrics, elem.GetMetrics()...)
+	}
+	for _, dst := range m.values {
+		dst.Metrics = mergeMetrics(dst.GetMetrics())
+	}
+	return m.values
+}
+
+func mergeMetrics(src []*metricspb.Metric) []*metricspb.Metric {
+	var m merger[*metricspb.Metric]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		key := strings.Join([]string{elem.GetName(), elem.GetDescription(), elem.GetUnit(), metricTypeString(elem)}, "\x00")
+		dst := m.get(key, func() *metricspb.Metric {
+			return newMetricWithoutDataPoints(elem)
+		})
+		// the new metric has its own containers of the data points, so AppendMetricData does not modify src.
+		AppendMetricData(dst, elem)
+	}
+	return m.values
+}
+
+// newMetricWithoutDataPoints returns a copy of the metric with the empty data points.
+func newMetricWithoutDataPoints(metric *metricspb.Metric) *metricspb.Metric {
+	dst := &metricspb.Metric{
+		Name:        metric.GetName(),
+		Description: metric.GetDescription(),
+		Unit:        metric.GetUnit(),
+		Metadata:    metric.GetMetadata(),
+	}
+	switch data := metric.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		dst.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}
+	case *metricspb.Metric_Sum:
+		dst.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: data.Sum.GetAggregationTemporality(),
+			IsMonotonic:            data.Sum.GetIsMonotonic(),
+		}}
+	case *metricspb.Metric_Summary:
+		dst.Data = &metricspb.Metric_Summary{Summary: &metricspb.Summary{}}
+	case *metricspb.Metric_Histogram:
+		dst.Data = &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			AggregationTemporality: data.Histogram.GetAggregationTemporality(),
+		}}
+	case *metricspb.Metric_ExponentialHistogram:
+		dst.Data = &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+			AggregationTemporality: data.ExponentialHistogram.GetAggregationTemporality(),
+		}}
+	}
+	return dst
+}
+
+// MergeResourceLogs is the inverse of SplitResourceLogs, it groups the elements by the identical resource and schema url,
+// and the scope logs of them by the identical scope and schema url, keeping the order of the first appearance.
+// src is not modified, the log records are shared with the result.
+func MergeResourceLogs(src []*logspb.ResourceLogs) []*logspb.ResourceLogs {
+	var m merger[*logspb.ResourceLogs]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(resourceKey(elem.GetResource(), elem.GetSchemaUrl()), func() *logspb.ResourceLogs {
+			return &logspb.ResourceLogs{Resource: elem.GetResource(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.ScopeLogs = append(dst.ScopeLogs, elem.GetScopeLogs()...)
+	}
+	for _, dst := range m.values {
+		dst.ScopeLogs = mergeScopeLogs(dst.GetScopeLogs())
+	}
+	return m.values
+}
+
+func mergeScopeLogs(src []*logspb.ScopeLogs) []*logspb.ScopeLogs {
+	var m merger[*logspb.ScopeLogs]
+	for _, elem := range src {
+		if elem == nil {
+			continue
+		}
+		dst := m.get(scopeKey(elem.GetScope(), elem.GetSchemaUrl()), func() *logspb.ScopeLogs {
+			return &logspb.ScopeLogs{Scope: elem.GetScope(), SchemaUrl: elem.GetSchemaUrl()}
+		})
+		dst.LogRecords = append(dst.LogRecords, elem.GetLogRecords()...)
+	}
+	return m.values
+}
+
+// merger groups the values by the keys in the order of the first appearance.
+type merger[T any] struct {
+	index  map[string]int
+	values []T
+}
+
+func (m *merger[T]) get(key string, newValue func() T) T {
+	if m.index == nil {
+		m.index = make(map[string]int)
+	}
+	if i, ok := m.index[key]; ok {
+		return m.values[i]
+	}
+	m.index[key] = len(m.values)
+	m.values = append(m.values, newValue())
+	return m.values[len(m.values)-1]
+}
+
+// resourceKey returns the key of the identical resources and schema url, the order of the attributes is ignored as EqualResource does.
+func resourceKey(resource *resourcepb.Resource, schemaURL string) string {
+	if resource == nil {
+		return "\x00" + schemaURL
+	}
+	return marshalKey(&resourcepb.Resource{
+		Attributes:             sortedAttributes(resource.GetAttributes()),
+		DroppedAttributesCount: resource.GetDroppedAttributesCount(),
+	}) + "\x00" + schemaURL
+}
+
+// scopeKey returns the key of the identical scopes and schema url, the order of the attributes is ignored as EqualScope does.
+func scopeKey(scope *commonpb.InstrumentationScope, schemaURL string) string {
+	if scope == nil {
+		return "\x00" + schemaURL
+	}
+	return marshalKey(&commonpb.InstrumentationScope{
+		Name:                   scope.GetName(),
+		Version:                scope.GetVersion(),
+		Attributes:             sortedAttributes(scope.GetAttributes()),
+		DroppedAttributesCount: scope.GetDroppedAttributesCount(),
+	}) + "\x00" + schemaURL
+}
+
+func sortedAttributes(attrs []*commonpb.KeyValue) []*commonpb.KeyValue {
+	sorted := slices.Clone(attrs)
+	slices.SortStableFunc(sorted, func(a, b *commonpb.KeyValue) int {
+		return strings.Compare(a.GetKey(), b.GetKey())
+	})
+	return sorted
+}
+
+func marshalKey(msg proto.Message) string {
+	bs, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return msg.(interface{ String() string }).String()
+	}
+	// the marshaled message is never empty with the prefix, distinguishing the empty resource from nil.
+	return "\x01" + string(bs)
+}
@@ -0,0 +1,111 @@
+package otlp_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMergeResourceSpans(t *testing.T) {
+	bs, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var data tracepb.TracesData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	original := proto.Clone(&data).(*tracepb.TracesData)
+
+	split := otlp.SplitResourceSpans(data.GetResourceSpans())
+	require.Len(t, split, otlp.TotalSpans(data.GetResourceSpans()))
+	splitClone := &tracepb.TracesData{ResourceSpans: split}
+	splitClone = proto.Clone(splitClone).(*tracepb.TracesData)
+
+	merged := otlp.MergeResourceSpans(split)
+	assertEqualMessage(t, original, &tracepb.TracesData{ResourceSpans: merged})
+	assertEqualMessage(t, splitClone, &tracepb.TracesData{ResourceSpans: split})
+}
+
+func TestMergeResourceSpans__DistinctResourcesAndScopes(t *testing.T) {
+	resource := func(attrs ...*commonpb.KeyValue) *resourcepb.Resource {
+		return &resourcepb.Resource{Attributes: attrs}
+	}
+	kv := func(k, v string) *commonpb.KeyValue {
+		return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+	}
+	rs := func(r *resourcepb.Resource, schemaURL string, scope string, span string) *tracepb.ResourceSpans {
+		return &tracepb.ResourceSpans{
+			Resource:  r,
+			SchemaUrl: schemaURL,
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Scope: &commonpb.InstrumentationScope{Name: scope},
+					Spans: []*tracepb.Span{{Name: span}},
+				},
+			},
+		}
+	}
+	merged := otlp.MergeResourceSpans([]*tracepb.ResourceSpans{
+		rs(resource(kv("service.name", "a"), kv("host.name", "h")), "", "s1", "1"),
+		rs(resource(kv("service.name", "b")), "", "s1", "2"),
+		rs(resource(kv("host.name", "h"), kv("service.name", "a")), "", "s2", "3"),
+		rs(resource(kv("service.name", "a"), kv("host.name", "h")), "https://opentelemetry.io/schemas/1.21.0", "s1", "4"),
+		rs(resource(kv("service.name", "a"), kv("host.name", "h")), "", "s1", "5"),
+		nil,
+	})
+	expected := []*tracepb.ResourceSpans{
+		{
+			Resource: resource(kv("service.name", "a"), kv("host.name", "h")),
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Scope: &commonpb.InstrumentationScope{Name: "s1"},
+					Spans: []*tracepb.Span{{Name: "1"}, {Name: "5"}},
+				},
+				{
+					Scope: &commonpb.InstrumentationScope{Name: "s2"},
+					Spans: []*tracepb.Span{{Name: "3"}},
+				},
+			},
+		},
+		rs(resource(kv("service.name", "b")), "", "s1", "2"),
+		rs(resource(kv("service.name", "a"), kv("host.name", "h")), "https://opentelemetry.io/schemas/1.21.0", "s1", "4"),
+	}
+	assertEqualMessage(t, &tracepb.TracesData{ResourceSpans: expected}, &tracepb.TracesData{ResourceSpans: merged})
+}
+
+func TestMergeResourceMetrics(t *testing.T) {
+	bs, err := os.ReadFile("testdata/metrics.json")
+	require.NoError(t, err)
+	var data metricspb.MetricsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	original := proto.Clone(&data).(*metricspb.MetricsData)
+
+	split := otlp.SplitResourceMetrics(data.GetResourceMetrics())
+	require.Len(t, split, otlp.TotalDataPoints(data.GetResourceMetrics()))
+	splitClone := proto.Clone(&metricspb.MetricsData{ResourceMetrics: split}).(*metricspb.MetricsData)
+
+	merged := otlp.MergeResourceMetrics(split)
+	assertEqualMessage(t, original, &metricspb.MetricsData{ResourceMetrics: merged})
+	assertEqualMessage(t, splitClone, &metricspb.MetricsData{ResourceMetrics: split})
+}
+
+func TestMergeResourceLogs(t *testing.T) {
+	bs, err := os.ReadFile("testdata/logs.json")
+	require.NoError(t, err)
+	var data logspb.LogsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	original := proto.Clone(&data).(*logspb.LogsData)
+
+	split := otlp.SplitResourceLogs(data.GetResourceLogs())
+	require.Len(t, split, otlp.TotalLogRecords(data.GetResourceLogs()))
+	splitClone := proto.Clone(&logspb.LogsData{ResourceLogs: split}).(*logspb.LogsData)
+
+	merged := otlp.MergeResourceLogs(split)
+	assertEqualMessage(t, original, &logspb.LogsData{ResourceLogs: merged})
+	assertEqualMessage(t, splitClone, &logspb.LogsData{ResourceLogs: split})
+}
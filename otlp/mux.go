@@ -5,14 +5,17 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	logspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	metricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	profilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
 	tracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -22,14 +25,32 @@ type (
 	MiddlewareFunc   func(next ProtoHandlerFunc) ProtoHandlerFunc
 )
 
+// HTTPMiddlewareFunc wraps the HTTP handler of a signal, for HTTP-specific concerns such as CORS, IP allowlists and request logging with raw sizes.
+type HTTPMiddlewareFunc func(next http.Handler) http.Handler
+
 type ServerMux struct {
 	mu          sync.RWMutex
 	httpMux     *http.ServeMux
 	trace       *traceEntry
 	metrics     *metricsEntry
 	logs        *logsEntry
+	profiles    *profilesEntry
 	middlewares []MiddlewareFunc
 	logger      *slog.Logger
+	stats       muxStats
+	onCanceled  func(ctx context.Context, err *CanceledError)
+	maxBodySize atomic.Int64
+	pathPrefix  string
+	paths       map[string]string
+	readiness   func(ctx context.Context) error
+	onStat      func(ServerStat)
+	telemetry   *selfTelemetry
+	draining    bool
+	exporting   sync.WaitGroup
+	onShutdown  []func(ctx context.Context) error
+	corsOrigin  []string
+	statusMap   func(codes.Code) int
+	hooks       exportHooks
 }
 
 var DefaultServerMux = NewServerMux()
@@ -38,12 +59,91 @@ var discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOption
 	Level: slog.LevelError,
 }))
 
-func NewServerMux() *ServerMux {
-	return &ServerMux{
+// ServerMuxOption is an option for NewServerMux.
+type ServerMuxOption func(*ServerMux)
+
+// WithMuxLogger sets the logger of the mux, e.g. to observe the requests that fail to decode, the handler errors and the response write failures.
+// by default, the logs are discarded.
+func WithMuxLogger(logger *slog.Logger) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.SetLogger(logger)
+	}
+}
+
+// WithStatusMapper customizes the HTTP status of the OTLP/HTTP error responses by the gRPC code,
+// e.g. to map RESOURCE_EXHAUSTED to 503 Service Unavailable for the load balancers that treat 429 specially.
+// mapper returning zero falls back to the default mapping. the Connect and gRPC-Web responses follow their own protocols and are not affected.
+func WithStatusMapper(mapper func(codes.Code) int) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.statusMap = mapper
+	}
+}
+
+// httpStatus returns the HTTP status of the OTLP/HTTP error responses, see WithStatusMapper.
+func (mux *ServerMux) httpStatus(code codes.Code) int {
+	if mux.statusMap != nil {
+		if httpStatus := mux.statusMap(code); httpStatus != 0 {
+			return httpStatus
+		}
+	}
+	return grpcCodeToHTTPStatus(code)
+}
+
+// WithMaxRecvMsgSize limits the size of the HTTP request bodies after decompression, see SetMaxRequestBodySize.
+// the gRPC server has its own limit, 4MB by default; pass grpc.MaxRecvMsgSize(n) to NewGRPCServer for the same limit.
+func WithMaxRecvMsgSize(n int64) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.SetMaxRequestBodySize(n)
+	}
+}
+
+// WithHTTPPathPrefix mounts the HTTP routes under the prefix, e.g. WithHTTPPathPrefix("/otlp") serves traces at /otlp/v1/traces,
+// for gateways that forward the requests with the mount path. the prefix applies to the paths set by WithSignalHTTPPath too.
+func WithHTTPPathPrefix(prefix string) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.pathPrefix = "/" + strings.Trim(prefix, "/")
+		if mux.pathPrefix == "/" {
+			mux.pathPrefix = ""
+		}
+	}
+}
+
+// WithSignalHTTPPath overrides the HTTP route of the signal, "traces", "metrics", "logs" or "profiles",
+// e.g. WithSignalHTTPPath("traces", "/api/traces"). the defaults are /v1/traces, /v1/metrics, /v1/logs and /v1development/profiles.
+func WithSignalHTTPPath(signal string, path string) ServerMuxOption {
+	return func(mux *ServerMux) {
+		if mux.paths == nil {
+			mux.paths = make(map[string]string)
+		}
+		mux.paths[signal] = "/" + strings.TrimPrefix(path, "/")
+	}
+}
+
+// httpPath returns the HTTP route of the signal, with the prefix.
+func (mux *ServerMux) httpPath(signal string, defaultPath string) string {
+	path, ok := mux.paths[signal]
+	if !ok {
+		path = defaultPath
+	}
+	return mux.pathPrefix + path
+}
+
+func NewServerMux(opts ...ServerMuxOption) *ServerMux {
+	mux := &ServerMux{
 		httpMux:     http.NewServeMux(),
 		middlewares: make([]MiddlewareFunc, 0),
 		logger:      discardLogger,
 	}
+	for _, opt := range opts {
+		opt(mux)
+	}
+	if mux.readiness != nil {
+		mux.registerHealthCheck()
+	}
+	if mux.telemetry != nil {
+		go mux.runSelfTelemetry()
+	}
+	return mux
 }
 
 func (mux *ServerMux) Use(m ...MiddlewareFunc) *ServerMux {
@@ -53,18 +153,42 @@ func (mux *ServerMux) Use(m ...MiddlewareFunc) *ServerMux {
 	return mux
 }
 
+// SetLogger sets the logger of the mux, see WithMuxLogger. the handlers already registered use it too.
+// the records in the requests have the request_id attribute, see RequestIDFromContext.
 func (mux *ServerMux) SetLogger(logger *slog.Logger) {
 	mux.mu.Lock()
 	defer mux.mu.Unlock()
-	mux.logger = logger
+	mux.logger = slog.New(requestIDLogHandler{logger.Handler()})
+}
+
+func (mux *ServerMux) getLogger() *slog.Logger {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	return mux.logger
 }
 
+// SetMaxRequestBodySize limits the size of the HTTP request bodies after decompression, to protect the server from giant payloads and decompression bombs.
+// larger requests are rejected with RESOURCE_EXHAUSTED (429 Too Many Requests) and Retry-After. zero (default) means no limit,
+// except for the message frames of gRPC-Web and Connect streaming, limited to 4MB as the gRPC server.
+// it does not apply to gRPC, use grpc.MaxRecvMsgSize with NewGRPCServer.
+func (mux *ServerMux) SetMaxRequestBodySize(n int64) {
+	mux.maxBodySize.Store(n)
+}
+
+func chainHTTPMiddlewares(h http.Handler, middlewares []HTTPMiddlewareFunc) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// chainedMiddleware returns the middlewares of the mux chained, with the panic recovery outermost.
 func (mux *ServerMux) chainedMiddleware() MiddlewareFunc {
 	mux.mu.RLock()
 	defer mux.mu.RUnlock()
 	if len(mux.middlewares) == 0 {
 		return MiddlewareFunc(func(next ProtoHandlerFunc) ProtoHandlerFunc {
-			return next
+			return mux.recoverPanic(next)
 		})
 	}
 	chained := mux.middlewares[len(mux.middlewares)-1]
@@ -75,7 +199,9 @@ func (mux *ServerMux) chainedMiddleware() MiddlewareFunc {
 			})
 		}(chained, mux.middlewares[i])
 	}
-	return chained
+	return MiddlewareFunc(func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return mux.recoverPanic(chained(next))
+	})
 }
 
 func (mux *ServerMux) Register(reg grpc.ServiceRegistrar) {
@@ -88,27 +214,55 @@ func (mux *ServerMux) Register(reg grpc.ServiceRegistrar) {
 	if logs, ok := mux.getLogsEntry(); ok {
 		logspb.RegisterLogsServiceServer(reg, logs)
 	}
+	if profiles, ok := mux.getProfilesEntry(); ok {
+		profilespb.RegisterProfilesServiceServer(reg, profiles)
+	}
+	if mux.readiness != nil {
+		healthpb.RegisterHealthServer(reg, &healthServer{mux: mux})
+	}
+}
+
+// NewGRPCServer returns a new gRPC server with the services of the mux registered.
+// the options are passed to grpc.NewServer as is; note that gRPC servers reject messages larger than 4MB by default,
+// use grpc.MaxRecvMsgSize to accept larger export requests.
+func (mux *ServerMux) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	mux.Register(server)
+	return server
+}
+
+// HandleHTTP registers the handler for the pattern of http.ServeMux, to serve extra routes such as an admin page, a metrics endpoint
+// or a vendor-specific ingestion path together with the OTLP routes. the pattern is used as is, without the prefix of WithHTTPPathPrefix.
+// it panics if the pattern conflicts with the registered ones, as http.ServeMux does. note that a catch-all pattern such as "/" shadows
+// the Connect routes and the not found responses of the mux.
+func (mux *ServerMux) HandleHTTP(pattern string, handler http.Handler) {
+	mux.httpMux.Handle(pattern, handler)
 }
 
 func (mux *ServerMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	md := make(metadata.MD, len(r.Header))
-	for k, v := range r.Header {
-		md[k] = v
+	if mux.serveCORS(w, r) {
+		return
+	}
+	ctx, requestID := withRequestID(r.Context(), r.Header.Get(RequestIDHeader))
+	w.Header().Set(RequestIDHeader, requestID)
+	r = r.WithContext(ctx)
+	withContext := func(transport string) *http.Request {
+		return r.WithContext(withResponseHeader(incomingContext(r, transport), w.Header()))
+	}
+	if isGRPCWeb(r) {
+		mux.serveGRPCWeb(w, withContext("grpc-web"))
+		return
 	}
-	r = r.WithContext(metadata.NewIncomingContext(r.Context(), md))
 	if handler, pattern := mux.httpMux.Handler(r); pattern != "" {
-		handler.ServeHTTP(w, r)
+		handler.ServeHTTP(w, withContext("http"))
 		return
 	}
-	st := status.New(codes.NotFound, "no handler registered for path")
-	switch r.Header.Get("Content-Type") {
-	case "application/x-protobuf":
-		errorProto(w, st)
-	case "application/json":
-		errorJSON(w, st)
-	default:
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	if mux.isConnect(r) {
+		mux.serveConnect(w, withContext("connect"))
+		return
 	}
+	st := status.New(codes.NotFound, "no handler registered for path")
+	errorByContentType(w, r, mux.getLogger(), mux.httpStatus(codes.NotFound), st)
 }
 
 type (
@@ -131,9 +285,15 @@ func (f TraceHandlerFunc) HandleTrace(ctx context.Context, request *TraceRequest
 type TraceMiddlewareFunc func(next TraceHandler) TraceHandler
 
 type TraceEntry interface {
+	// Handle registers the handler, or replaces the registered one. it is safe to call at runtime, e.g. on a config reload,
+	// the requests in flight complete with the previous handler.
 	Handle(handler TraceHandler)
+	// Remove removes the registered handler, the subsequent requests fail with UNIMPLEMENTED until a handler is registered again.
+	Remove()
 	HandleFunc(handler func(ctx context.Context, request *TraceRequest) (*TraceResponse, error))
 	Use(m ...TraceMiddlewareFunc) TraceEntry
+	UseHTTP(m ...HTTPMiddlewareFunc) TraceEntry
+	Transform(t ...SpanTransformer) TraceEntry
 }
 
 type traceEntry struct {
@@ -143,6 +303,8 @@ type traceEntry struct {
 	middlewares []TraceMiddlewareFunc
 	h           TraceHandler
 	ph          http.Handler
+
+	httpMiddlewares []HTTPMiddlewareFunc
 }
 
 func (mux *ServerMux) getTraceEntry() (*traceEntry, bool) {
@@ -164,9 +326,11 @@ func (mux *ServerMux) newTraceEntry() *traceEntry {
 			},
 			mux.trace.Export,
 		)
-		ph.SetLogger(mux.logger)
+		ph.logger = mux.getLogger
+		ph.httpStatus = mux.httpStatus
+		ph.maxBodySize = &mux.maxBodySize
 		mux.trace.ph = ph
-		mux.httpMux.Handle("/v1/traces", mux.trace)
+		mux.httpMux.Handle(mux.httpPath("traces", "/v1/traces"), mux.trace)
 	}
 	return mux.trace
 }
@@ -178,6 +342,25 @@ func (e *traceEntry) Use(m ...TraceMiddlewareFunc) TraceEntry {
 	return e
 }
 
+// UseHTTP adds the HTTP middlewares of the signal, applied to the HTTP requests only, in order with the first outermost.
+func (e *traceEntry) UseHTTP(m ...HTTPMiddlewareFunc) TraceEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.httpMiddlewares = append(e.httpMiddlewares, m...)
+	return e
+}
+
+// Transform adds transformers that mutate the spans of each request in order, before the handler is called.
+func (e *traceEntry) Transform(t ...SpanTransformer) TraceEntry {
+	transformers := append([]SpanTransformer{}, t...)
+	return e.Use(func(next TraceHandler) TraceHandler {
+		return TraceHandlerFunc(func(ctx context.Context, request *TraceRequest) (*TraceResponse, error) {
+			transformResourceSpansInPlace(request.GetResourceSpans(), transformers...)
+			return next.HandleTrace(ctx, request)
+		})
+	})
+}
+
 func (e *traceEntry) Handle(handler TraceHandler) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -188,6 +371,10 @@ func (e *traceEntry) HandleFunc(handler func(ctx context.Context, request *Trace
 	e.Handle(TraceHandlerFunc(handler))
 }
 
+func (e *traceEntry) Remove() {
+	e.Handle(nil)
+}
+
 func (e *traceEntry) getHandler() (TraceHandler, bool) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -202,16 +389,23 @@ func (e *traceEntry) getHandler() (TraceHandler, bool) {
 }
 
 func (e *traceEntry) Export(ctx context.Context, req *TraceRequest) (*tracepb.ExportTraceServiceResponse, error) {
+	ctx = ensureRequestID(ctx)
 	base, ok := e.getHandler()
 	if !ok {
-		return e.UnimplementedTraceServiceServer.Export(ctx, req)
+		_, err := e.UnimplementedTraceServiceServer.Export(ctx, req)
+		return nil, errorWithRequestID(ctx, err)
 	}
 	h := e.mux.chainedMiddleware()(func(ctx context.Context, req proto.Message) (proto.Message, error) {
 		return base.HandleTrace(ctx, req.(*TraceRequest))
 	})
+	done, err := e.mux.beginExport(ctx, req)
+	if err != nil {
+		return nil, errorWithRequestID(ctx, err)
+	}
 	resp, err := h(ctx, req)
+	done(resp, err)
 	if err != nil {
-		return nil, err
+		return nil, errorWithRequestID(ctx, err)
 	}
 	if traceResp, ok := resp.(*TraceResponse); ok {
 		return traceResp, nil
@@ -220,7 +414,10 @@ func (e *traceEntry) Export(ctx context.Context, req *TraceRequest) (*tracepb.Ex
 }
 
 func (e *traceEntry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	e.ph.ServeHTTP(w, r)
+	e.mu.RLock()
+	h := chainHTTPMiddlewares(e.ph, e.httpMiddlewares)
+	e.mu.RUnlock()
+	h.ServeHTTP(w, r)
 }
 
 func (mux *ServerMux) Trace() TraceEntry {
@@ -248,9 +445,15 @@ func (f MetricsHandlerFunc) HandleMetrics(ctx context.Context, request *MetricsR
 type MetricsMiddlewareFunc func(next MetricsHandler) MetricsHandler
 
 type MetricsEntry interface {
+	// Handle registers the handler, or replaces the registered one. it is safe to call at runtime, e.g. on a config reload,
+	// the requests in flight complete with the previous handler.
 	Handle(handler MetricsHandler)
+	// Remove removes the registered handler, the subsequent requests fail with UNIMPLEMENTED until a handler is registered again.
+	Remove()
 	HandleFunc(handler func(ctx context.Context, request *MetricsRequest) (*MetricsResponse, error))
 	Use(m ...MetricsMiddlewareFunc) MetricsEntry
+	UseHTTP(m ...HTTPMiddlewareFunc) MetricsEntry
+	Transform(t ...MetricTransformer) MetricsEntry
 }
 
 type metricsEntry struct {
@@ -260,7 +463,8 @@ type metricsEntry struct {
 	h  MetricsHandler
 	ph http.Handler
 
-	middlewares []MetricsMiddlewareFunc
+	middlewares     []MetricsMiddlewareFunc
+	httpMiddlewares []HTTPMiddlewareFunc
 }
 
 func (mux *ServerMux) getMetricsEntry() (*metricsEntry, bool) {
@@ -282,9 +486,11 @@ func (mux *ServerMux) newMetricsEntry() *metricsEntry {
 			},
 			mux.metrics.Export,
 		)
-		ph.SetLogger(mux.logger)
+		ph.logger = mux.getLogger
+		ph.httpStatus = mux.httpStatus
+		ph.maxBodySize = &mux.maxBodySize
 		mux.metrics.ph = ph
-		mux.httpMux.Handle("/v1/metrics", mux.metrics)
+		mux.httpMux.Handle(mux.httpPath("metrics", "/v1/metrics"), mux.metrics)
 	}
 	return mux.metrics
 }
@@ -296,6 +502,25 @@ func (e *metricsEntry) Use(m ...MetricsMiddlewareFunc) MetricsEntry {
 	return e
 }
 
+// UseHTTP adds the HTTP middlewares of the signal, applied to the HTTP requests only, in order with the first outermost.
+func (e *metricsEntry) UseHTTP(m ...HTTPMiddlewareFunc) MetricsEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.httpMiddlewares = append(e.httpMiddlewares, m...)
+	return e
+}
+
+// Transform adds transformers that mutate the metrics of each request in order, before the handler is called.
+func (e *metricsEntry) Transform(t ...MetricTransformer) MetricsEntry {
+	transformers := append([]MetricTransformer{}, t...)
+	return e.Use(func(next MetricsHandler) MetricsHandler {
+		return MetricsHandlerFunc(func(ctx context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+			transformResourceMetricsInPlace(request.GetResourceMetrics(), transformers...)
+			return next.HandleMetrics(ctx, request)
+		})
+	})
+}
+
 func (e *metricsEntry) Handle(handler MetricsHandler) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -306,6 +531,10 @@ func (e *metricsEntry) HandleFunc(handler func(ctx context.Context, request *Met
 	e.Handle(MetricsHandlerFunc(handler))
 }
 
+func (e *metricsEntry) Remove() {
+	e.Handle(nil)
+}
+
 func (e *metricsEntry) getHandler() (MetricsHandler, bool) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -320,16 +549,23 @@ func (e *metricsEntry) getHandler() (MetricsHandler, bool) {
 }
 
 func (e *metricsEntry) Export(ctx context.Context, req *MetricsRequest) (*MetricsResponse, error) {
+	ctx = ensureRequestID(ctx)
 	base, ok := e.getHandler()
 	if !ok {
-		return e.UnimplementedMetricsServiceServer.Export(ctx, req)
+		_, err := e.UnimplementedMetricsServiceServer.Export(ctx, req)
+		return nil, errorWithRequestID(ctx, err)
 	}
 	h := e.mux.chainedMiddleware()(func(ctx context.Context, req proto.Message) (proto.Message, error) {
 		return base.HandleMetrics(ctx, req.(*MetricsRequest))
 	})
+	done, err := e.mux.beginExport(ctx, req)
+	if err != nil {
+		return nil, errorWithRequestID(ctx, err)
+	}
 	resp, err := h(ctx, req)
+	done(resp, err)
 	if err != nil {
-		return nil, err
+		return nil, errorWithRequestID(ctx, err)
 	}
 	if metricsResp, ok := resp.(*MetricsResponse); ok {
 		return metricsResp, nil
@@ -338,7 +574,10 @@ func (e *metricsEntry) Export(ctx context.Context, req *MetricsRequest) (*Metric
 }
 
 func (e *metricsEntry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	e.ph.ServeHTTP(w, r)
+	e.mu.RLock()
+	h := chainHTTPMiddlewares(e.ph, e.httpMiddlewares)
+	e.mu.RUnlock()
+	h.ServeHTTP(w, r)
 }
 
 func (mux *ServerMux) Metrics() MetricsEntry {
@@ -366,9 +605,15 @@ func (f LogsHandlerFunc) HandleLogs(ctx context.Context, request *LogsRequest) (
 type LogsMiddlewareFunc func(next LogsHandler) LogsHandler
 
 type LogsEntry interface {
+	// Handle registers the handler, or replaces the registered one. it is safe to call at runtime, e.g. on a config reload,
+	// the requests in flight complete with the previous handler.
 	Handle(handler LogsHandler)
+	// Remove removes the registered handler, the subsequent requests fail with UNIMPLEMENTED until a handler is registered again.
+	Remove()
 	HandleFunc(handler func(ctx context.Context, request *LogsRequest) (*LogsResponse, error))
 	Use(m ...LogsMiddlewareFunc) LogsEntry
+	UseHTTP(m ...HTTPMiddlewareFunc) LogsEntry
+	Transform(t ...LogRecordTransformer) LogsEntry
 }
 
 type logsEntry struct {
@@ -378,7 +623,8 @@ type logsEntry struct {
 	h  LogsHandler
 	ph http.Handler
 
-	middlewares []LogsMiddlewareFunc
+	middlewares     []LogsMiddlewareFunc
+	httpMiddlewares []HTTPMiddlewareFunc
 }
 
 func (mux *ServerMux) getLogsEntry() (*logsEntry, bool) {
@@ -400,9 +646,11 @@ func (mux *ServerMux) newLogsEntry() *logsEntry {
 			},
 			mux.logs.Export,
 		)
-		ph.SetLogger(mux.logger)
+		ph.logger = mux.getLogger
+		ph.httpStatus = mux.httpStatus
+		ph.maxBodySize = &mux.maxBodySize
 		mux.logs.ph = ph
-		mux.httpMux.Handle("/v1/logs", mux.logs)
+		mux.httpMux.Handle(mux.httpPath("logs", "/v1/logs"), mux.logs)
 	}
 	return mux.logs
 }
@@ -414,6 +662,25 @@ func (e *logsEntry) Use(m ...LogsMiddlewareFunc) LogsEntry {
 	return e
 }
 
+// UseHTTP adds the HTTP middlewares of the signal, applied to the HTTP requests only, in order with the first outermost.
+func (e *logsEntry) UseHTTP(m ...HTTPMiddlewareFunc) LogsEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.httpMiddlewares = append(e.httpMiddlewares, m...)
+	return e
+}
+
+// Transform adds transformers that mutate the log records of each request in order, before the handler is called.
+func (e *logsEntry) Transform(t ...LogRecordTransformer) LogsEntry {
+	transformers := append([]LogRecordTransformer{}, t...)
+	return e.Use(func(next LogsHandler) LogsHandler {
+		return LogsHandlerFunc(func(ctx context.Context, request *LogsRequest) (*LogsResponse, error) {
+			transformResourceLogsInPlace(request.GetResourceLogs(), transformers...)
+			return next.HandleLogs(ctx, request)
+		})
+	})
+}
+
 func (e *logsEntry) Handle(handler LogsHandler) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -424,6 +691,10 @@ func (e *logsEntry) HandleFunc(handler func(ctx context.Context, request *LogsRe
 	e.Handle(LogsHandlerFunc(handler))
 }
 
+func (e *logsEntry) Remove() {
+	e.Handle(nil)
+}
+
 func (e *logsEntry) getHandler() (LogsHandler, bool) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -438,16 +709,23 @@ func (e *logsEntry) getHandler() (LogsHandler, bool) {
 }
 
 func (e *logsEntry) Export(ctx context.Context, req *LogsRequest) (*LogsResponse, error) {
+	ctx = ensureRequestID(ctx)
 	base, ok := e.getHandler()
 	if !ok {
-		return e.UnimplementedLogsServiceServer.Export(ctx, req)
+		_, err := e.UnimplementedLogsServiceServer.Export(ctx, req)
+		return nil, errorWithRequestID(ctx, err)
 	}
 	h := e.mux.chainedMiddleware()(func(ctx context.Context, req proto.Message) (proto.Message, error) {
 		return base.HandleLogs(ctx, req.(*LogsRequest))
 	})
+	done, err := e.mux.beginExport(ctx, req)
+	if err != nil {
+		return nil, errorWithRequestID(ctx, err)
+	}
 	resp, err := h(ctx, req)
+	done(resp, err)
 	if err != nil {
-		return nil, err
+		return nil, errorWithRequestID(ctx, err)
 	}
 	if logsResp, ok := resp.(*LogsResponse); ok {
 		return logsResp, nil
@@ -456,7 +734,10 @@ func (e *logsEntry) Export(ctx context.Context, req *LogsRequest) (*LogsResponse
 }
 
 func (e *logsEntry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	e.ph.ServeHTTP(w, r)
+	e.mu.RLock()
+	h := chainHTTPMiddlewares(e.ph, e.httpMiddlewares)
+	e.mu.RUnlock()
+	h.ServeHTTP(w, r)
 }
 
 func (mux *ServerMux) Logs() LogsEntry {
@@ -465,3 +746,153 @@ func (mux *ServerMux) Logs() LogsEntry {
 	}
 	return mux.newLogsEntry()
 }
+
+type (
+	ProfilesRequest  = profilespb.ExportProfilesServiceRequest
+	ProfilesResponse = profilespb.ExportProfilesServiceResponse
+)
+
+// ProfilesHandler handles the profiles requests. the profiles signal is in development,
+// and served at /v1development/profiles over HTTP and by the v1experimental ProfilesService over gRPC.
+type ProfilesHandler interface {
+	HandleProfiles(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error)
+}
+
+type ProfilesHandlerFunc func(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error)
+
+func (f ProfilesHandlerFunc) HandleProfiles(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error) {
+	return f(ctx, request)
+}
+
+type ProfilesMiddlewareFunc func(next ProfilesHandler) ProfilesHandler
+
+type ProfilesEntry interface {
+	// Handle registers the handler, or replaces the registered one. it is safe to call at runtime, e.g. on a config reload,
+	// the requests in flight complete with the previous handler.
+	Handle(handler ProfilesHandler)
+	// Remove removes the registered handler, the subsequent requests fail with UNIMPLEMENTED until a handler is registered again.
+	Remove()
+	HandleFunc(handler func(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error))
+	Use(m ...ProfilesMiddlewareFunc) ProfilesEntry
+	UseHTTP(m ...HTTPMiddlewareFunc) ProfilesEntry
+}
+
+type profilesEntry struct {
+	mux *ServerMux
+	profilespb.UnimplementedProfilesServiceServer
+	mu sync.RWMutex
+	h  ProfilesHandler
+	ph http.Handler
+
+	middlewares     []ProfilesMiddlewareFunc
+	httpMiddlewares []HTTPMiddlewareFunc
+}
+
+func (mux *ServerMux) getProfilesEntry() (*profilesEntry, bool) {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	return mux.profiles, mux.profiles != nil
+}
+
+func (mux *ServerMux) newProfilesEntry() *profilesEntry {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	if mux.profiles == nil {
+		mux.profiles = &profilesEntry{
+			mux: mux,
+		}
+		ph := newProxyHandler(
+			func(_ context.Context) *ProfilesRequest {
+				return &ProfilesRequest{}
+			},
+			mux.profiles.Export,
+		)
+		ph.logger = mux.getLogger
+		ph.httpStatus = mux.httpStatus
+		ph.maxBodySize = &mux.maxBodySize
+		mux.profiles.ph = ph
+		mux.httpMux.Handle(mux.httpPath("profiles", "/v1development/profiles"), mux.profiles)
+	}
+	return mux.profiles
+}
+
+func (e *profilesEntry) Use(m ...ProfilesMiddlewareFunc) ProfilesEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.middlewares = append(e.middlewares, m...)
+	return e
+}
+
+// UseHTTP adds the HTTP middlewares of the signal, applied to the HTTP requests only, in order with the first outermost.
+func (e *profilesEntry) UseHTTP(m ...HTTPMiddlewareFunc) ProfilesEntry {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.httpMiddlewares = append(e.httpMiddlewares, m...)
+	return e
+}
+
+func (e *profilesEntry) Handle(handler ProfilesHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.h = handler
+}
+
+func (e *profilesEntry) HandleFunc(handler func(ctx context.Context, request *ProfilesRequest) (*ProfilesResponse, error)) {
+	e.Handle(ProfilesHandlerFunc(handler))
+}
+
+func (e *profilesEntry) Remove() {
+	e.Handle(nil)
+}
+
+func (e *profilesEntry) getHandler() (ProfilesHandler, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.h == nil {
+		return nil, false
+	}
+	wrapped := e.h
+	for i := len(e.middlewares) - 1; i >= 0; i-- {
+		wrapped = e.middlewares[i](wrapped)
+	}
+	return wrapped, true
+}
+
+func (e *profilesEntry) Export(ctx context.Context, req *ProfilesRequest) (*ProfilesResponse, error) {
+	ctx = ensureRequestID(ctx)
+	base, ok := e.getHandler()
+	if !ok {
+		_, err := e.UnimplementedProfilesServiceServer.Export(ctx, req)
+		return nil, errorWithRequestID(ctx, err)
+	}
+	h := e.mux.chainedMiddleware()(func(ctx context.Context, req proto.Message) (proto.Message, error) {
+		return base.HandleProfiles(ctx, req.(*ProfilesRequest))
+	})
+	done, err := e.mux.beginExport(ctx, req)
+	if err != nil {
+		return nil, errorWithRequestID(ctx, err)
+	}
+	resp, err := h(ctx, req)
+	done(resp, err)
+	if err != nil {
+		return nil, errorWithRequestID(ctx, err)
+	}
+	if profilesResp, ok := resp.(*ProfilesResponse); ok {
+		return profilesResp, nil
+	}
+	return nil, status.Error(codes.Internal, "unexpected response type")
+}
+
+func (e *profilesEntry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	h := chainHTTPMiddlewares(e.ph, e.httpMiddlewares)
+	e.mu.RUnlock()
+	h.ServeHTTP(w, r)
+}
+
+func (mux *ServerMux) Profiles() ProfilesEntry {
+	if profiles, ok := mux.getProfilesEntry(); ok {
+		return profiles
+	}
+	return mux.newProfilesEntry()
+}
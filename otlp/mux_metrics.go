@@ -0,0 +1,135 @@
+package otlp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+type muxSignalStats struct {
+	requests         atomic.Int64
+	errors           atomic.Int64
+	canceled         atomic.Int64
+	deadlineExceeded atomic.Int64
+	records          atomic.Int64
+	accepted         atomic.Int64
+	refused          atomic.Int64
+	inFlight         atomic.Int64
+}
+
+// begin records the start of an export request with n records, and returns the function to record its end.
+func (s *muxSignalStats) begin(n int) func(err error, canceled *CanceledError) {
+	s.requests.Add(1)
+	s.records.Add(int64(n))
+	s.inFlight.Add(1)
+	return func(err error, canceled *CanceledError) {
+		s.inFlight.Add(-1)
+		if err != nil {
+			s.errors.Add(1)
+			s.refused.Add(int64(n))
+		} else {
+			s.accepted.Add(int64(n))
+		}
+		switch {
+		case canceled == nil:
+		case canceled.Timeout():
+			s.deadlineExceeded.Add(1)
+		default:
+			s.canceled.Add(1)
+		}
+	}
+}
+
+type muxStats struct {
+	traces   muxSignalStats
+	metrics  muxSignalStats
+	logs     muxSignalStats
+	profiles muxSignalStats
+}
+
+func (s *muxStats) signal(signalType string) *muxSignalStats {
+	switch signalType {
+	case "traces":
+		return &s.traces
+	case "metrics":
+		return &s.metrics
+	case "profiles":
+		return &s.profiles
+	default:
+		return &s.logs
+	}
+}
+
+var muxMetricFamilies = []struct {
+	name  string
+	typ   string
+	help  string
+	value func(*muxSignalStats) int64
+}{
+	{
+		name:  "otlp_mux_requests_total",
+		typ:   "counter",
+		help:  "The number of export requests received.",
+		value: func(s *muxSignalStats) int64 { return s.requests.Load() },
+	},
+	{
+		name:  "otlp_mux_errors_total",
+		typ:   "counter",
+		help:  "The number of export requests the handler returned an error for.",
+		value: func(s *muxSignalStats) int64 { return s.errors.Load() },
+	},
+	{
+		name:  "otlp_mux_canceled_total",
+		typ:   "counter",
+		help:  "The number of export requests that failed because they were canceled, e.g. the client disconnected.",
+		value: func(s *muxSignalStats) int64 { return s.canceled.Load() },
+	},
+	{
+		name:  "otlp_mux_deadline_exceeded_total",
+		typ:   "counter",
+		help:  "The number of export requests that failed because their deadline was exceeded.",
+		value: func(s *muxSignalStats) int64 { return s.deadlineExceeded.Load() },
+	},
+	{
+		name:  "otlp_mux_records_total",
+		typ:   "counter",
+		help:  "The number of spans, metric data points and log records received.",
+		value: func(s *muxSignalStats) int64 { return s.records.Load() },
+	},
+	{
+		name:  "otlp_mux_in_flight_requests",
+		typ:   "gauge",
+		help:  "The number of export requests being handled.",
+		value: func(s *muxSignalStats) int64 { return s.inFlight.Load() },
+	},
+}
+
+// MetricsHandler returns an http.Handler that exposes the counters of the mux in the Prometheus text exposition format.
+// the counters are labeled by signal, and count the requests over HTTP, gRPC and WebSocket alike; mount the handler at /metrics.
+// the mux does not queue requests, so otlp_mux_in_flight_requests is the closest to a queue depth.
+func (mux *ServerMux) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var buf bytes.Buffer
+		mux.writeMetrics(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			mux.getLogger().DebugContext(r.Context(), "failed to write metrics", "details", err)
+		}
+	})
+}
+
+func (mux *ServerMux) writeMetrics(buf *bytes.Buffer) {
+	for _, family := range muxMetricFamilies {
+		fmt.Fprintf(buf, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.name, family.typ)
+		for _, signalType := range []string{"traces", "metrics", "logs", "profiles"} {
+			fmt.Fprintf(buf, "%s{signal=%q} %d\n", family.name, signalType, family.value(mux.stats.signal(signalType)))
+		}
+	}
+}
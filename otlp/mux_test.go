@@ -3,9 +3,14 @@ package otlp_test
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -21,7 +26,21 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/log"
+	colprofilespb "go.opentelemetry.io/proto/otlp/collector/profiles/v1experimental"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	profilespb "go.opentelemetry.io/proto/otlp/profiles/v1experimental"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type testContextKey string
@@ -131,6 +150,44 @@ func TestServer__HTTP_Trace(t *testing.T) {
 	require.True(t, existsHeader.Load())
 }
 
+func TestServer__HTTP_Trace_IDGenerator(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var mu sync.Mutex
+	var traceIDs [][]byte
+	mux.Trace().HandleFunc(
+		func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, rs := range request.GetResourceSpans() {
+				for _, ss := range rs.GetScopeSpans() {
+					for _, span := range ss.GetSpans() {
+						traceIDs = append(traceIDs, span.GetTraceId())
+					}
+				}
+			}
+			return &otlp.TraceResponse{}, nil
+		},
+	)
+	server := otlptest.NewHTTPServer(mux)
+	defer server.Close()
+	before := time.Now().Unix()
+	tracerProvider, err := server.Trace.Provider(otlptest.WithIDGenerator(otlp.NewXRayIDGenerator()))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tracer := tracerProvider.Tracer("test")
+	_, span := tracer.Start(ctx, "test")
+	span.End()
+	require.NoError(t, tracerProvider.ForceFlush(ctx))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, traceIDs, 1)
+	require.Len(t, traceIDs[0], 16)
+	epoch := int64(binary.BigEndian.Uint32(traceIDs[0][0:4]))
+	require.GreaterOrEqual(t, epoch, before)
+	require.LessOrEqual(t, epoch, time.Now().Unix())
+}
+
 func TestServer__HTTP_Metrics(t *testing.T) {
 	mux := otlp.NewServerMux()
 	metricCount := int32(0)
@@ -393,3 +450,505 @@ func TestServer__gRPC_Logs(t *testing.T) {
 	require.EqualValues(t, 1, atomic.LoadInt32(&logCount))
 	require.True(t, existsHeader.Load())
 }
+
+func TestMux__HTTP_Trace_Transform(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux()
+	var actualNames []string
+	mux.Trace().Transform(
+		func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) {
+			span.Name = "renamed"
+		},
+		func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) {
+			span.Name += "-twice"
+		},
+	).HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					actualNames = append(actualNames, span.GetName())
+				}
+			}
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"renamed-twice"}, actualNames)
+}
+
+func TestServer__gRPC_MaxRecvMsgSize(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	spans := newSpans(1)
+	spans[0].ScopeSpans[0].Spans[0].Attributes = []*commonpb.KeyValue{
+		{
+			Key:   "payload",
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: strings.Repeat("x", 5<<20)}},
+		},
+	}
+	upload := func(t *testing.T, server *otlptest.Server) error {
+		t.Helper()
+		client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		ctx := context.Background()
+		require.NoError(t, client.Start(ctx))
+		defer client.Stop(ctx)
+		return client.UploadTraces(ctx, spans)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		err := upload(t, server)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+	t.Run("raised", func(t *testing.T) {
+		server := otlptest.NewServer(mux, grpc.MaxRecvMsgSize(16<<20))
+		defer server.Close()
+		require.NoError(t, upload(t, server))
+	})
+}
+
+func TestMux__MetricsHandler(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux()
+	var calls atomic.Int32
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		if calls.Add(1) == 2 {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+		req.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	mux.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE otlp_mux_requests_total counter",
+		`otlp_mux_requests_total{signal="traces"} 2`,
+		`otlp_mux_errors_total{signal="traces"} 1`,
+		`otlp_mux_records_total{signal="traces"} 2`,
+		`otlp_mux_requests_total{signal="metrics"} 0`,
+		"# TYPE otlp_mux_in_flight_requests gauge",
+		`otlp_mux_in_flight_requests{signal="traces"} 0`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func TestMux__Logger(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	mux := otlp.NewServerMux(otlp.WithMuxLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	})
+	post := func(body []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post([]byte("{invalid"))
+	post(traceData)
+	require.Contains(t, buf.String(), "failed to unmarshal request body")
+	require.Contains(t, buf.String(), "handler returned an error")
+	require.Contains(t, buf.String(), "code=Unavailable")
+
+	// the logger set after the handler is registered is used too.
+	var replaced bytes.Buffer
+	mux.SetLogger(slog.New(slog.NewTextHandler(&replaced, nil)))
+	post([]byte("{invalid"))
+	require.Contains(t, replaced.String(), "failed to unmarshal request body")
+}
+
+func TestMux__Profiles(t *testing.T) {
+	expected := &otlp.ProfilesRequest{
+		ResourceProfiles: []*profilespb.ResourceProfiles{
+			{
+				ScopeProfiles: []*profilespb.ScopeProfiles{
+					{
+						Profiles: []*profilespb.ProfileContainer{
+							{ProfileId: []byte("0123456789abcdef"), StartTimeUnixNano: 1, EndTimeUnixNano: 2},
+						},
+					},
+				},
+			},
+		},
+	}
+	mux := otlp.NewServerMux()
+	var handleCount atomic.Int32
+	mux.Profiles().HandleFunc(func(_ context.Context, req *otlp.ProfilesRequest) (*otlp.ProfilesResponse, error) {
+		assertEqualMessage(t, expected, req)
+		handleCount.Add(1)
+		return &otlp.ProfilesResponse{}, nil
+	})
+
+	t.Run("http", func(t *testing.T) {
+		body, err := proto.Marshal(expected)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1development/profiles", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		conn, err := grpc.NewClient(server.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = colprofilespb.NewProfilesServiceClient(conn).Export(context.Background(), expected)
+		require.NoError(t, err)
+	})
+	require.EqualValues(t, 2, handleCount.Load())
+}
+
+func TestMux__HTTPPath(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	cases := []struct {
+		name     string
+		opts     []otlp.ServerMuxOption
+		path     string
+		notFound string
+	}{
+		{
+			name:     "prefix",
+			opts:     []otlp.ServerMuxOption{otlp.WithHTTPPathPrefix("/otlp/")},
+			path:     "/otlp/v1/traces",
+			notFound: "/v1/traces",
+		},
+		{
+			name:     "signal path",
+			opts:     []otlp.ServerMuxOption{otlp.WithSignalHTTPPath("traces", "api/traces")},
+			path:     "/api/traces",
+			notFound: "/v1/traces",
+		},
+		{
+			name: "prefix and signal path",
+			opts: []otlp.ServerMuxOption{
+				otlp.WithHTTPPathPrefix("/otlp"),
+				otlp.WithSignalHTTPPath("traces", "/traces"),
+			},
+			path:     "/otlp/traces",
+			notFound: "/otlp/v1/traces",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mux := otlp.NewServerMux(c.opts...)
+			mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+				return &otlp.TraceResponse{}, nil
+			})
+			post := func(path string) int {
+				req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(traceData))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				return w.Code
+			}
+			require.Equal(t, http.StatusOK, post(c.path))
+			require.Equal(t, http.StatusNotFound, post(c.notFound))
+		})
+	}
+}
+
+func TestMux__HandleHTTP(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.HandleHTTP("GET /admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := otlp.ClientInfoFromContext(r.Context())
+		assert.True(t, ok)
+		w.Write([]byte("admin"))
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/admin")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "admin", string(body))
+
+	resp, err = http.Post(server.URL+"/v1/traces", "application/json", bytes.NewReader(traceData))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Panics(t, func() {
+		mux.HandleHTTP("GET /admin", http.NotFoundHandler())
+	})
+}
+
+func TestMux__StatusMapper(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux(otlp.WithStatusMapper(func(code codes.Code) int {
+		if code == codes.ResourceExhausted {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	}))
+	var handlerErr error
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return nil, handlerErr
+	})
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	handlerErr = otlp.ThrottleError(time.Second)
+	w := post()
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	handlerErr = status.Error(codes.InvalidArgument, "invalid")
+	require.Equal(t, http.StatusBadRequest, post().Code, "zero falls back to the default mapping")
+}
+
+func TestMux__UseHTTP(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	mux := otlp.NewServerMux()
+	var order []string
+	header := func(name string) otlp.HTTPMiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.RemoteAddr != "192.0.2.1:1234" {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	mux.Trace().UseHTTP(header("first"), header("second"), deny).HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		order = append(order, "handler")
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return &otlp.LogsResponse{}, nil
+	})
+	post := func(path, remoteAddr string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	w := post("/v1/traces", "", traceData)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, []string{"first", "second", "handler"}, order)
+
+	require.Equal(t, http.StatusForbidden, post("/v1/traces", "198.51.100.1:1234", traceData).Code)
+	w = post("/v1/logs", "198.51.100.1:1234", []byte("{}"))
+	require.Equal(t, http.StatusOK, w.Code, "the middlewares of the traces do not apply to the logs")
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestMux__HTTP_ContentTypeParameters(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var expected otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(traceData, &expected))
+	protoData, err := proto.Marshal(&expected)
+	require.NoError(t, err)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		assertEqualMessage(t, &expected, req)
+		return &otlp.TraceResponse{}, nil
+	})
+	cases := []struct {
+		contentType string
+		body        []byte
+		expected    string
+	}{
+		{"application/json; charset=utf-8", traceData, "application/json"},
+		{"Application/JSON;charset=UTF-8", traceData, "application/json"},
+		{"application/x-protobuf; proto=opentelemetry.proto.collector.trace.v1.ExportTraceServiceRequest", protoData, "application/x-protobuf"},
+	}
+	for _, c := range cases {
+		t.Run(c.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(c.body))
+			req.Header.Set("Content-Type", c.contentType)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			require.Equal(t, c.expected, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestMux__ReplaceAndRemoveHandler(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var first, second atomic.Int64
+	handler := func(counter *atomic.Int64) otlp.TraceHandlerFunc {
+		return func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+			counter.Add(1)
+			return &otlp.TraceResponse{}, nil
+		}
+	}
+	mux.Trace().Handle(handler(&first))
+	body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.NoError(t, err)
+	post := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// export concurrently during the swaps, every request is handled by either of the handlers.
+	const workers, requests = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				assert.Equal(t, http.StatusOK, post())
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			mux.Trace().Handle(handler(&second))
+		} else {
+			mux.Trace().Handle(handler(&first))
+		}
+	}
+	wg.Wait()
+	require.EqualValues(t, workers*requests, first.Load()+second.Load())
+
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	ctx := context.Background()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+	require.NoError(t, err)
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	mux.Trace().Remove()
+	require.Equal(t, http.StatusNotImplemented, post())
+	err = client.UploadTraces(ctx, newSpans(1))
+	require.Equal(t, codes.Unimplemented, status.Code(err), err)
+
+	mux.Trace().Handle(handler(&second))
+	before := second.Load()
+	require.Equal(t, http.StatusOK, post())
+	require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+	require.EqualValues(t, before+2, second.Load())
+}
+
+func TestMux__HTTP_ErrorStatusDetails(t *testing.T) {
+	expectedHTTPStatus := map[codes.Code]int{
+		codes.Canceled:           http.StatusRequestTimeout,
+		codes.Unknown:            http.StatusInternalServerError,
+		codes.InvalidArgument:    http.StatusBadRequest,
+		codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		codes.NotFound:           http.StatusNotFound,
+		codes.AlreadyExists:      http.StatusConflict,
+		codes.PermissionDenied:   http.StatusForbidden,
+		codes.ResourceExhausted:  http.StatusTooManyRequests,
+		codes.FailedPrecondition: http.StatusPreconditionFailed,
+		codes.Aborted:            http.StatusConflict,
+		codes.OutOfRange:         http.StatusBadRequest,
+		codes.Unimplemented:      http.StatusNotImplemented,
+		codes.Internal:           http.StatusInternalServerError,
+		codes.Unavailable:        http.StatusServiceUnavailable,
+		codes.DataLoss:           http.StatusInternalServerError,
+		codes.Unauthenticated:    http.StatusUnauthorized,
+	}
+	unresolvable := &anypb.Any{TypeUrl: "type.googleapis.com/example.NotLinked", Value: []byte{0x08, 0x01}}
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		code := codes.Code(otlp.TotalSpans(req.GetResourceSpans()))
+		errorInfo, err := anypb.New(&errdetails.ErrorInfo{Reason: "REASON", Domain: "example.com", Metadata: map[string]string{"traceId": "AAAAAAAAAAAAAAAAAAAAAQ=="}})
+		require.NoError(t, err)
+		retryInfo, err := anypb.New(&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)})
+		require.NoError(t, err)
+		return nil, status.FromProto(&spb.Status{
+			Code:    int32(code),
+			Message: code.String(),
+			Details: []*anypb.Any{errorInfo, unresolvable, retryInfo},
+		}).Err()
+	})
+	for code, httpStatus := range expectedHTTPStatus {
+		for _, c := range []struct {
+			contentType string
+			marshal     func(proto.Message) ([]byte, error)
+			unmarshal   func([]byte, proto.Message) error
+			details     int
+		}{
+			{"application/x-protobuf", proto.Marshal, proto.Unmarshal, 4},
+			{"application/json", otlp.MarshalJSON, protojson.Unmarshal, 3},
+		} {
+			t.Run(code.String()+"/"+c.contentType, func(t *testing.T) {
+				body, err := c.marshal(&otlp.TraceRequest{ResourceSpans: newSpans(int(code))})
+				require.NoError(t, err)
+				req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+				req.Header.Set("Content-Type", c.contentType)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				require.Equal(t, httpStatus, w.Code)
+				require.Equal(t, c.contentType, w.Header().Get("Content-Type"))
+				require.Equal(t, "2", w.Header().Get("Retry-After"))
+
+				var actual spb.Status
+				require.NoError(t, c.unmarshal(w.Body.Bytes(), &actual), w.Body.String())
+				require.EqualValues(t, code, actual.GetCode())
+				require.Equal(t, code.String(), actual.GetMessage())
+				// the unresolvable detail survives the protobuf encoding only, and the request id is appended.
+				require.Len(t, actual.GetDetails(), c.details)
+				var errorInfo errdetails.ErrorInfo
+				require.NoError(t, actual.GetDetails()[0].UnmarshalTo(&errorInfo))
+				require.Equal(t, "REASON", errorInfo.GetReason())
+				require.Equal(t, "AAAAAAAAAAAAAAAAAAAAAQ==", errorInfo.GetMetadata()["traceId"])
+				var retryInfo errdetails.RetryInfo
+				require.NoError(t, actual.GetDetails()[c.details-2].UnmarshalTo(&retryInfo))
+				require.Equal(t, 2*time.Second, retryInfo.GetRetryDelay().AsDuration())
+				var requestInfo errdetails.RequestInfo
+				require.NoError(t, actual.GetDetails()[c.details-1].UnmarshalTo(&requestInfo))
+				require.Equal(t, w.Header().Get(otlp.RequestIDHeader), requestInfo.GetRequestId())
+			})
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Package otlplambda adapts otlp.ServerMux, or any http.Handler, to the Lambda events of Function URLs, API Gateway REST and HTTP APIs and ALB,
+// so that an OTLP/HTTP receiver runs on Lambda without an HTTP server in the function, e.g.
+//
+//	mux := otlp.NewServerMux()
+//	mux.Trace().Handle(handler)
+//	lambda.Start(otlplambda.NewHandler(mux))
+//
+// the binary bodies are base64-decoded, Content-Encoding such as gzip is decompressed by the mux, and the stage of API Gateway is stripped from the path,
+// so the OTLP paths, /v1/traces etc., are routed as is. use otlp.WithHTTPPathPrefix for the other mount paths, e.g. the path patterns of ALB rules.
+package otlplambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type options struct {
+	logger *slog.Logger
+}
+
+// Option is the option for NewHandler.
+type Option func(*options)
+
+// WithLogger sets the logger of the handler, for the events that can not be adapted. default is slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Handler adapts an http.Handler to the Lambda events. it implements lambda.Handler of github.com/aws/aws-lambda-go.
+type Handler struct {
+	handler http.Handler
+	opts    options
+}
+
+// NewHandler returns the Handler serving the events with handler, typically otlp.ServerMux.
+func NewHandler(handler http.Handler, opts ...Option) *Handler {
+	o := options{
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Handler{handler: handler, opts: o}
+}
+
+// event is the union of the request events, the payload format version 2.0 of Function URLs and HTTP APIs,
+// and the version 1.0 of REST APIs, HTTP APIs and ALB.
+type event struct {
+	Version string `json:"version"`
+
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	RequestContext struct {
+		Stage string `json:"stage"`
+		ELB   *struct {
+			TargetGroupArn string `json:"targetGroupArn"`
+		} `json:"elb"`
+		HTTP struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+		Identity struct {
+			SourceIP string `json:"sourceIp"`
+		} `json:"identity"`
+	} `json:"requestContext"`
+
+	Body            string `json:"body"`
+	IsBase64Encoded bool   `json:"isBase64Encoded"`
+}
+
+func (e *event) isV2() bool {
+	return e.Version == "2.0"
+}
+
+func (e *event) isALB() bool {
+	return e.RequestContext.ELB != nil
+}
+
+// response is the union of the response formats, the fields not in the format of the event are omitted.
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// Invoke handles the event of payload, and returns the response of the format of the event.
+func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var e event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	r, err := newRequest(ctx, &e)
+	if err != nil {
+		h.opts.logger.WarnContext(ctx, "failed to adapt event", "details", err)
+		return json.Marshal(newResponse(&e, http.StatusBadRequest, http.Header{"Content-Type": {"text/plain; charset=utf-8"}}, []byte(err.Error())))
+	}
+	w := &responseWriter{header: make(http.Header)}
+	h.handler.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return json.Marshal(newResponse(&e, w.status, w.header, w.body.Bytes()))
+}
+
+func newRequest(ctx context.Context, e *event) (*http.Request, error) {
+	body := []byte(e.Body)
+	if e.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(e.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 body: %w", err)
+		}
+		body = decoded
+	}
+	method, path, query, sourceIP := e.HTTPMethod, e.Path, "", e.RequestContext.Identity.SourceIP
+	if e.isV2() {
+		method, path, query, sourceIP = e.RequestContext.HTTP.Method, e.RawPath, e.RawQueryString, e.RequestContext.HTTP.SourceIP
+		// the raw path of the named stages of HTTP APIs starts with the stage.
+		if stage := e.RequestContext.Stage; stage != "" && stage != "$default" {
+			path = strings.TrimPrefix(path, "/"+stage)
+		}
+	} else {
+		query = v1Query(e)
+	}
+	if path == "" {
+		path = "/"
+	}
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range e.Headers {
+		r.Header.Set(k, v)
+	}
+	for k, values := range e.MultiValueHeaders {
+		r.Header.Del(k)
+		for _, v := range values {
+			r.Header.Add(k, v)
+		}
+	}
+	if len(e.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(e.Cookies, "; "))
+	}
+	r.Host = r.Header.Get("Host")
+	r.ContentLength = int64(len(body))
+	r.RequestURI = target
+	if sourceIP != "" {
+		r.RemoteAddr = net.JoinHostPort(sourceIP, "0")
+	}
+	return r, nil
+}
+
+// v1Query returns the query string of the version 1.0 events. ALB passes the parameters as they are sent, i.e. URL-encoded,
+// and API Gateway passes them decoded.
+func v1Query(e *event) string {
+	if e.isALB() {
+		var pairs []string
+		if len(e.MultiValueQueryStringParameters) > 0 {
+			for k, values := range e.MultiValueQueryStringParameters {
+				for _, v := range values {
+					pairs = append(pairs, k+"="+v)
+				}
+			}
+		} else {
+			for k, v := range e.QueryStringParameters {
+				pairs = append(pairs, k+"="+v)
+			}
+		}
+		return strings.Join(pairs, "&")
+	}
+	values := make(url.Values)
+	for k, v := range e.QueryStringParameters {
+		values.Set(k, v)
+	}
+	for k, v := range e.MultiValueQueryStringParameters {
+		values[k] = v
+	}
+	return values.Encode()
+}
+
+func newResponse(e *event, status int, header http.Header, body []byte) *response {
+	resp := &response{StatusCode: status}
+	if isTextContent(header.Get("Content-Type")) {
+		resp.Body = string(body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.IsBase64Encoded = true
+	}
+	switch {
+	case e.isV2():
+		resp.Headers = make(map[string]string, len(header))
+		for k, values := range header {
+			if k == "Set-Cookie" {
+				resp.Cookies = values
+				continue
+			}
+			resp.Headers[k] = strings.Join(values, ",")
+		}
+	case e.isALB() && len(e.MultiValueHeaders) == 0:
+		// ALB responds with the headers of the same format as the request, the multi-value headers are enabled on the target group.
+		resp.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		resp.Headers = make(map[string]string, len(header))
+		for k := range header {
+			resp.Headers[k] = header.Get(k)
+		}
+	default:
+		if e.isALB() {
+			resp.StatusDescription = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		}
+		resp.MultiValueHeaders = header
+	}
+	return resp
+}
+
+// isTextContent reports whether the body of the content type is sent as is, otherwise it is base64-encoded.
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json"
+}
+
+// responseWriter buffers the response, the Lambda events respond with the whole body.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
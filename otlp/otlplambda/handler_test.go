@@ -0,0 +1,184 @@
+package otlplambda_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlplambda"
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+func newTraceRequest(name string) *otlp.TraceRequest {
+	return &otlp.TraceRequest{
+		ResourceSpans: []*otlp.ResourceSpans{{
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Spans: []*tracepb.Span{{
+					TraceId: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+					SpanId:  []byte{0, 0, 0, 0, 0, 0, 0, 1},
+					Name:    name,
+				}},
+			}},
+		}},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	received := make(chan *otlp.TraceRequest, 1)
+	peers := make(chan string, 1)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		info, _ := otlp.ClientInfoFromContext(ctx)
+		if info.Addr != nil {
+			peers <- info.Addr.String()
+		} else {
+			peers <- ""
+		}
+		received <- req
+		return &otlp.TraceResponse{}, nil
+	})
+	handler := otlplambda.NewHandler(mux)
+	invoke := func(t *testing.T, event map[string]any) response {
+		t.Helper()
+		payload, err := json.Marshal(event)
+		require.NoError(t, err)
+		data, err := handler.Invoke(context.Background(), payload)
+		require.NoError(t, err)
+		var resp response
+		require.NoError(t, json.Unmarshal(data, &resp))
+		return resp
+	}
+
+	expected := newTraceRequest("function-url")
+	protoBody, err := proto.Marshal(expected)
+	require.NoError(t, err)
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err = gw.Write(protoBody)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	t.Run("function url with gzip protobuf", func(t *testing.T) {
+		resp := invoke(t, map[string]any{
+			"version":        "2.0",
+			"rawPath":        "/v1/traces",
+			"rawQueryString": "",
+			"headers": map[string]string{
+				"content-type":     "application/x-protobuf",
+				"content-encoding": "gzip",
+				"host":             "abc.lambda-url.ap-northeast-1.on.aws",
+			},
+			"requestContext": map[string]any{
+				"stage": "$default",
+				"http":  map[string]string{"method": "POST", "path": "/v1/traces", "sourceIp": "192.0.2.1"},
+			},
+			"body":            base64.StdEncoding.EncodeToString(gzipped.Bytes()),
+			"isBase64Encoded": true,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, resp.IsBase64Encoded)
+		require.Equal(t, "application/x-protobuf", resp.Headers["Content-Type"])
+		require.True(t, proto.Equal(expected, <-received))
+		require.Equal(t, "192.0.2.1:0", <-peers)
+		body, err := base64.StdEncoding.DecodeString(resp.Body)
+		require.NoError(t, err)
+		var traceResp otlp.TraceResponse
+		require.NoError(t, proto.Unmarshal(body, &traceResp))
+	})
+	t.Run("http api with a named stage", func(t *testing.T) {
+		jsonBody, err := otlp.MarshalJSON(newTraceRequest("http-api"))
+		require.NoError(t, err)
+		resp := invoke(t, map[string]any{
+			"version": "2.0",
+			"rawPath": "/prod/v1/traces",
+			"headers": map[string]string{"content-type": "application/json"},
+			"requestContext": map[string]any{
+				"stage": "prod",
+				"http":  map[string]string{"method": "POST", "sourceIp": "192.0.2.2"},
+			},
+			"body": string(jsonBody),
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, resp.IsBase64Encoded)
+		require.JSONEq(t, `{}`, resp.Body)
+		require.Equal(t, "http-api", (<-received).GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0].GetName())
+		<-peers
+	})
+	t.Run("rest api", func(t *testing.T) {
+		resp := invoke(t, map[string]any{
+			"httpMethod":        "POST",
+			"path":              "/v1/traces",
+			"multiValueHeaders": map[string][]string{"Content-Type": {"application/x-protobuf"}},
+			"requestContext": map[string]any{
+				"stage":    "prod",
+				"identity": map[string]string{"sourceIp": "192.0.2.3"},
+			},
+			"body":            base64.StdEncoding.EncodeToString(protoBody),
+			"isBase64Encoded": true,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.StatusDescription)
+		require.Equal(t, []string{"application/x-protobuf"}, resp.MultiValueHeaders["Content-Type"])
+		require.True(t, proto.Equal(expected, <-received))
+		require.Equal(t, "192.0.2.3:0", <-peers)
+	})
+	t.Run("alb", func(t *testing.T) {
+		resp := invoke(t, map[string]any{
+			"httpMethod": "POST",
+			"path":       "/v1/traces",
+			"headers":    map[string]string{"content-type": "application/x-protobuf"},
+			"requestContext": map[string]any{
+				"elb": map[string]string{"targetGroupArn": "arn:aws:elasticloadbalancing:ap-northeast-1:123456789012:targetgroup/otlp/abc"},
+			},
+			"body":            base64.StdEncoding.EncodeToString(protoBody),
+			"isBase64Encoded": true,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "200 OK", resp.StatusDescription)
+		require.Equal(t, "application/x-protobuf", resp.Headers["Content-Type"])
+		require.Empty(t, resp.MultiValueHeaders)
+		require.True(t, proto.Equal(expected, <-received))
+		<-peers
+	})
+	t.Run("not found", func(t *testing.T) {
+		resp := invoke(t, map[string]any{
+			"httpMethod": "POST",
+			"path":       "/unknown",
+			"headers":    map[string]string{"content-type": "application/json"},
+			"requestContext": map[string]any{
+				"elb": map[string]string{"targetGroupArn": "arn"},
+			},
+			"multiValueHeaders": map[string][]string{"content-type": {"application/json"}},
+			"body":              "{}",
+		})
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Equal(t, "404 Not Found", resp.StatusDescription)
+		require.Equal(t, []string{"application/json"}, resp.MultiValueHeaders["Content-Type"])
+	})
+	t.Run("invalid base64", func(t *testing.T) {
+		resp := invoke(t, map[string]any{
+			"version":         "2.0",
+			"rawPath":         "/v1/traces",
+			"requestContext":  map[string]any{"http": map[string]string{"method": "POST"}},
+			"body":            "not base64!",
+			"isBase64Encoded": true,
+		})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,82 @@
+// Package otlpprometheus provides the prometheus.Collector of the export statistics of otlp.Client, e.g.
+//
+//	prometheus.MustRegister(otlpprometheus.NewClientCollector(client))
+//
+// it is a separate module, so that the otlp package does not depend on the Prometheus client library.
+// without the library, use Client.MetricsHandler for the same metrics in the text exposition format.
+package otlpprometheus
+
+import (
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var signals = []string{"traces", "metrics", "logs", "profiles"}
+
+type metricFamily struct {
+	desc  *prometheus.Desc
+	typ   prometheus.ValueType
+	value func(otlp.ExportStats) float64
+}
+
+func newMetricFamily(name, help string, typ prometheus.ValueType, value func(otlp.ExportStats) float64) metricFamily {
+	return metricFamily{
+		desc:  prometheus.NewDesc(name, help, []string{"signal"}, nil),
+		typ:   typ,
+		value: value,
+	}
+}
+
+// ClientCollector collects otlp.Client.Stats labeled by signal, with the same names as otlp.Client.MetricsHandler.
+type ClientCollector struct {
+	client   *otlp.Client
+	families []metricFamily
+}
+
+var _ prometheus.Collector = (*ClientCollector)(nil)
+
+// NewClientCollector returns a new ClientCollector of the client.
+func NewClientCollector(client *otlp.Client) *ClientCollector {
+	return &ClientCollector{
+		client: client,
+		families: []metricFamily{
+			newMetricFamily("otlp_client_exports_total", "The number of upload calls.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Exports) }),
+			newMetricFamily("otlp_client_failures_total", "The number of upload calls that returned an error, after retries.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Failures) }),
+			newMetricFamily("otlp_client_canceled_total", "The number of upload calls that failed because they were canceled.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Canceled) }),
+			newMetricFamily("otlp_client_deadline_exceeded_total", "The number of upload calls that failed because their deadline was exceeded.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.DeadlineExceeded) }),
+			newMetricFamily("otlp_client_retries_total", "The number of retried export attempts.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Retries) }),
+			newMetricFamily("otlp_client_rejected_total", "The number of items rejected by the server with partial success responses.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Rejected) }),
+			newMetricFamily("otlp_client_dropped_total", "The number of items of the uploads aborted by Stop.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return float64(s.Dropped) }),
+			newMetricFamily("otlp_client_queue_depth", "The number of items of the upload calls in flight.", prometheus.GaugeValue,
+				func(s otlp.ExportStats) float64 { return float64(s.QueueDepth) }),
+			newMetricFamily("otlp_client_export_duration_seconds_total", "The total duration of the upload calls, including retries.", prometheus.CounterValue,
+				func(s otlp.ExportStats) float64 { return s.ExportDuration.Seconds() }),
+			newMetricFamily("otlp_client_last_export_duration_seconds", "The duration of the last upload call.", prometheus.GaugeValue,
+				func(s otlp.ExportStats) float64 { return s.LastExportDuration.Seconds() }),
+		},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ClientCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, family := range c.families {
+		ch <- family.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *ClientCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.Stats()
+	for _, family := range c.families {
+		for _, signalType := range signals {
+			ch <- prometheus.MustNewConstMetric(family.desc, family.typ, family.value(stats[signalType]), signalType)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package otlpprometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlpprometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.Error(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+
+	collector := otlpprometheus.NewClientCollector(client)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+	expected := `
+# HELP otlp_client_failures_total The number of upload calls that returned an error, after retries.
+# TYPE otlp_client_failures_total counter
+otlp_client_failures_total{signal="logs"} 0
+otlp_client_failures_total{signal="metrics"} 0
+otlp_client_failures_total{signal="profiles"} 0
+otlp_client_failures_total{signal="traces"} 1
+# HELP otlp_client_queue_depth The number of items of the upload calls in flight.
+# TYPE otlp_client_queue_depth gauge
+otlp_client_queue_depth{signal="logs"} 0
+otlp_client_queue_depth{signal="metrics"} 0
+otlp_client_queue_depth{signal="profiles"} 0
+otlp_client_queue_depth{signal="traces"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "otlp_client_failures_total", "otlp_client_queue_depth"))
+}
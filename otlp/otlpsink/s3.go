@@ -0,0 +1,356 @@
+// Package otlpsink implements the handlers of otlp.ServerMux that store the telemetry into the external storages.
+package otlpsink
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultPartitionLayout is the time layout of the partitions of S3Handler, hourly.
+	DefaultPartitionLayout = "2006/01/02/15"
+	// DefaultFlushInterval is the interval of S3Handler to write the buffered telemetry.
+	DefaultFlushInterval = time.Minute
+	// DefaultBufferLimit is the upper bound of the spans, data points and log records buffered by S3Handler.
+	DefaultBufferLimit = 1 << 20
+
+	// maxRetryBackoff is the upper bound of the backoff of the periodic flush after failures.
+	maxRetryBackoff = 30 * time.Minute
+)
+
+// ObjectStore puts the objects into an object storage such as S3. with AWS SDK for Go v2, it is implemented like:
+//
+//	type s3Store struct {
+//		client *s3.Client
+//		bucket string
+//	}
+//
+//	func (s *s3Store) PutObject(ctx context.Context, key string, body []byte) error {
+//		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+//			Bucket:          aws.String(s.bucket),
+//			Key:             aws.String(key),
+//			Body:            bytes.NewReader(body),
+//			ContentType:     aws.String("application/json"),
+//			ContentEncoding: aws.String("gzip"),
+//		})
+//		return err
+//	}
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body []byte) error
+}
+
+type options struct {
+	prefix        string
+	layout        string
+	location      *time.Location
+	flushInterval time.Duration
+	maxItems      int
+	bufferLimit   int
+	logger        *slog.Logger
+}
+
+// Option is the option for NewS3Handler.
+type Option func(*options)
+
+// WithKeyPrefix sets the prefix of the object keys, e.g. "otlp/". default is empty.
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithPartitionLayout sets the time layout and the location of the partitions, e.g. "2006/01/02" for daily. default is DefaultPartitionLayout in UTC.
+func WithPartitionLayout(layout string, loc *time.Location) Option {
+	return func(o *options) {
+		o.layout = layout
+		o.location = loc
+	}
+}
+
+// WithFlushInterval sets the interval to write the buffered telemetry. default is DefaultFlushInterval.
+func WithFlushInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.flushInterval = d
+	}
+}
+
+// WithMaxBufferedItems writes the buffered telemetry before the interval when the spans, data points and log records buffered reach n. zero means no limit.
+func WithMaxBufferedItems(n int) Option {
+	return func(o *options) {
+		o.maxItems = n
+	}
+}
+
+// WithBufferLimit sets the upper bound of the spans, data points and log records buffered, including the partitions kept to be retried.
+// the requests over the limit are rejected with RESOURCE_EXHAUSTED for the exporters to retry later,
+// and the failed partitions over the limit are dropped. default is DefaultBufferLimit.
+func WithBufferLimit(n int) Option {
+	return func(o *options) {
+		o.bufferLimit = n
+	}
+}
+
+// WithLogger sets the logger of the failures of the background writes. by default, they are discarded.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// S3Handler is a handler of traces, metrics and logs that buffers the requests, partitions them by the time of the items,
+// and writes gzip-compressed OTLP JSON objects of the partitions to keys like traces/2024/05/01/13/<uuid>.json.gz periodically.
+// the requests are acknowledged when buffered, so the buffered telemetry is lost if the process dies before Close.
+// the partitions that fail to be written are kept to be retried by the periodic flush with an exponential backoff, up to WithBufferLimit.
+type S3Handler struct {
+	store ObjectStore
+	o     options
+
+	tracesPartition  func(*otlp.ResourceSpans) string
+	metricsPartition func(*otlp.ResourceMetrics) string
+	logsPartition    func(*otlp.ResourceLogs) string
+
+	mu      sync.Mutex
+	traces  map[string][]*otlp.ResourceSpans
+	metrics map[string][]*otlp.ResourceMetrics
+	logs    map[string][]*otlp.ResourceLogs
+	items   int
+	closed  bool
+
+	flushMu sync.Mutex
+	full    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+var (
+	_ otlp.TraceHandler   = (*S3Handler)(nil)
+	_ otlp.MetricsHandler = (*S3Handler)(nil)
+	_ otlp.LogsHandler    = (*S3Handler)(nil)
+)
+
+// NewS3Handler returns a new S3Handler writing to the store, and starts the periodic flush. call Close to write the rest.
+func NewS3Handler(store ObjectStore, opts ...Option) *S3Handler {
+	o := options{
+		layout:        DefaultPartitionLayout,
+		location:      time.UTC,
+		flushInterval: DefaultFlushInterval,
+		bufferLimit:   DefaultBufferLimit,
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := &S3Handler{
+		store:            store,
+		o:                o,
+		tracesPartition:  otlp.PartitionBySpanStartTime(o.layout, o.location),
+		metricsPartition: otlp.PartitionByMetricTime(o.layout, o.location),
+		logsPartition:    otlp.PartitionByLogTime(o.layout, o.location),
+		traces:           make(map[string][]*otlp.ResourceSpans),
+		metrics:          make(map[string][]*otlp.ResourceMetrics),
+		logs:             make(map[string][]*otlp.ResourceLogs),
+		full:             make(chan struct{}, 1),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+func (h *S3Handler) run() {
+	defer close(h.done)
+	var tick <-chan time.Time
+	if h.o.flushInterval > 0 {
+		ticker := time.NewTicker(h.o.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var (
+		failures int
+		retryAt  time.Time
+	)
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-tick:
+		case <-h.full:
+		}
+		if time.Now().Before(retryAt) {
+			continue
+		}
+		if err := h.Flush(context.Background()); err != nil {
+			failures++
+			backoff := h.retryBackoff(failures)
+			retryAt = time.Now().Add(backoff)
+			h.o.logger.Warn("failed to write the buffered telemetry", "details", err, "retry_after", backoff)
+			continue
+		}
+		failures, retryAt = 0, time.Time{}
+	}
+}
+
+// retryBackoff returns the backoff after the consecutive failures of the periodic flush, doubled from the flush interval.
+func (h *S3Handler) retryBackoff(failures int) time.Duration {
+	backoff := h.o.flushInterval
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for i := 1; i < failures && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxRetryBackoff)
+}
+
+// HandleTrace buffers the spans.
+func (h *S3Handler) HandleTrace(_ context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+	partitions := otlp.PartitionResourceSpans(request.GetResourceSpans(), h.tracesPartition)
+	return &otlp.TraceResponse{}, h.buffer(otlp.TotalSpans(request.GetResourceSpans()), func() {
+		for key, partition := range partitions {
+			h.traces[key] = otlp.AppendResourceSpans(h.traces[key], partition...)
+		}
+	})
+}
+
+// HandleMetrics buffers the data points.
+func (h *S3Handler) HandleMetrics(_ context.Context, request *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
+	partitions := otlp.PartitionResourceMetrics(request.GetResourceMetrics(), h.metricsPartition)
+	return &otlp.MetricsResponse{}, h.buffer(otlp.TotalDataPoints(request.GetResourceMetrics()), func() {
+		for key, partition := range partitions {
+			h.metrics[key] = otlp.AppendResourceMetrics(h.metrics[key], partition...)
+		}
+	})
+}
+
+// HandleLogs buffers the log records.
+func (h *S3Handler) HandleLogs(_ context.Context, request *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+	partitions := otlp.PartitionResourceLogs(request.GetResourceLogs(), h.logsPartition)
+	return &otlp.LogsResponse{}, h.buffer(otlp.TotalLogRecords(request.GetResourceLogs()), func() {
+		for key, partition := range partitions {
+			h.logs[key] = otlp.AppendResourceLogs(h.logs[key], partition...)
+		}
+	})
+}
+
+func (h *S3Handler) buffer(items int, appendFunc func()) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errors.New("s3 handler is closed")
+	}
+	if h.o.bufferLimit > 0 && h.items+items > h.o.bufferLimit {
+		return otlp.ThrottleError(max(h.o.flushInterval, time.Second))
+	}
+	appendFunc()
+	h.items += items
+	if h.o.maxItems > 0 && h.items >= h.o.maxItems {
+		select {
+		case h.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// requeue buffers the partition that failed to be written, even after Close, or drops it over the buffer limit.
+// it does not trigger the flush, the periodic flush retries it.
+func (h *S3Handler) requeue(signalType string, items int, appendFunc func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.o.bufferLimit > 0 && h.items+items > h.o.bufferLimit {
+		h.o.logger.Warn("dropped the partition failed to be written over the buffer limit", "signal", signalType, "items", items)
+		return
+	}
+	appendFunc()
+	h.items += items
+}
+
+// Flush writes the buffered telemetry now. the partitions that fail are kept in the buffer, and their errors are returned.
+func (h *S3Handler) Flush(ctx context.Context) error {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+	h.mu.Lock()
+	traces, metrics, logs := h.traces, h.metrics, h.logs
+	h.traces = make(map[string][]*otlp.ResourceSpans)
+	h.metrics = make(map[string][]*otlp.ResourceMetrics)
+	h.logs = make(map[string][]*otlp.ResourceLogs)
+	h.items = 0
+	h.mu.Unlock()
+
+	var errs []error
+	for key, partition := range traces {
+		if err := h.put(ctx, "traces", key, &otlp.TraceRequest{ResourceSpans: partition}); err != nil {
+			errs = append(errs, err)
+			h.requeue("traces", otlp.TotalSpans(partition), func() {
+				h.traces[key] = otlp.AppendResourceSpans(h.traces[key], partition...)
+			})
+		}
+	}
+	for key, partition := range metrics {
+		if err := h.put(ctx, "metrics", key, &otlp.MetricsRequest{ResourceMetrics: partition}); err != nil {
+			errs = append(errs, err)
+			h.requeue("metrics", otlp.TotalDataPoints(partition), func() {
+				h.metrics[key] = otlp.AppendResourceMetrics(h.metrics[key], partition...)
+			})
+		}
+	}
+	for key, partition := range logs {
+		if err := h.put(ctx, "logs", key, &otlp.LogsRequest{ResourceLogs: partition}); err != nil {
+			errs = append(errs, err)
+			h.requeue("logs", otlp.TotalLogRecords(partition), func() {
+				h.logs[key] = otlp.AppendResourceLogs(h.logs[key], partition...)
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *S3Handler) put(ctx context.Context, signalType string, partitionKey string, msg proto.Message) error {
+	data, err := otlp.MarshalJSON(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", signalType, err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", signalType, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", signalType, err)
+	}
+	key := h.o.prefix + signalType + "/"
+	if partitionKey != "" {
+		key += partitionKey + "/"
+	}
+	key += uuid.NewString() + ".json.gz"
+	if err := h.store.PutObject(ctx, key, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Close stops the periodic flush and writes the buffered telemetry. the requests after Close are rejected,
+// and the partitions that fail are kept for Flush.
+func (h *S3Handler) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	h.mu.Unlock()
+	close(h.stop)
+	<-h.done
+	return h.Flush(ctx)
+}
@@ -0,0 +1,166 @@
+package otlpsink_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlpsink"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	err     error
+	puts    int
+}
+
+func (s *memoryStore) PutObject(_ context.Context, key string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.puts++
+	if s.err != nil {
+		return s.err
+	}
+	if s.objects == nil {
+		s.objects = make(map[string][]byte)
+	}
+	s.objects[key] = body
+	return nil
+}
+
+func (s *memoryStore) putCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.puts
+}
+
+func (s *memoryStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *memoryStore) traces(t *testing.T, key string) *otlp.TraceRequest {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zr, err := gzip.NewReader(bytes.NewReader(s.objects[key]))
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	var req otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(data, &req))
+	return &req
+}
+
+func spansAt(times ...time.Time) []*otlp.ResourceSpans {
+	spans := make([]*tracepb.Span, 0, len(times))
+	for i, ts := range times {
+		spans = append(spans, &tracepb.Span{
+			TraceId:           bytes.Repeat([]byte{1}, 16),
+			SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, byte(i + 1)},
+			Name:              "span",
+			StartTimeUnixNano: uint64(ts.UnixNano()),
+			EndTimeUnixNano:   uint64(ts.Add(time.Second).UnixNano()),
+		})
+	}
+	return []*otlp.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: spans}}}}
+}
+
+var uuidKey = `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.json\.gz`
+
+func TestS3Handler(t *testing.T) {
+	store := &memoryStore{}
+	h := otlpsink.NewS3Handler(store, otlpsink.WithKeyPrefix("otlp/"))
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC)
+	_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(at, at.Add(time.Hour))})
+	require.NoError(t, err)
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(at.Add(time.Minute))})
+	require.NoError(t, err)
+	_, err = h.HandleLogs(ctx, &otlp.LogsRequest{ResourceLogs: []*otlp.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{TimeUnixNano: uint64(at.UnixNano())}}}},
+	}}})
+	require.NoError(t, err)
+	require.Empty(t, store.keys(), "buffered until the flush")
+	require.NoError(t, h.Close(ctx))
+
+	keys := store.keys()
+	require.Len(t, keys, 3)
+	require.Regexp(t, regexp.MustCompile(`^otlp/logs/2024/05/01/13/`+uuidKey+`$`), keys[0])
+	require.Regexp(t, regexp.MustCompile(`^otlp/traces/2024/05/01/13/`+uuidKey+`$`), keys[1])
+	require.Regexp(t, regexp.MustCompile(`^otlp/traces/2024/05/01/14/`+uuidKey+`$`), keys[2])
+	require.Equal(t, 2, otlp.TotalSpans(store.traces(t, keys[1]).GetResourceSpans()))
+	require.Equal(t, 1, otlp.TotalSpans(store.traces(t, keys[2]).GetResourceSpans()))
+
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(at)})
+	require.Error(t, err, "closed")
+}
+
+func TestS3Handler_Retry(t *testing.T) {
+	store := &memoryStore{err: errors.New("unavailable")}
+	h := otlpsink.NewS3Handler(store, otlpsink.WithFlushInterval(0))
+	ctx := context.Background()
+	_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.NoError(t, err)
+	require.Error(t, h.Close(ctx))
+
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+	require.NoError(t, h.Flush(ctx), "the failed partitions are kept")
+	require.Len(t, store.keys(), 1)
+}
+
+func TestS3Handler_MaxBufferedItems(t *testing.T) {
+	store := &memoryStore{}
+	h := otlpsink.NewS3Handler(store, otlpsink.WithFlushInterval(time.Hour), otlpsink.WithMaxBufferedItems(2))
+	defer h.Close(context.Background())
+	ctx := context.Background()
+	_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.NoError(t, err)
+	require.Never(t, func() bool { return len(store.keys()) > 0 }, 50*time.Millisecond, 10*time.Millisecond)
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(store.keys()) > 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestS3Handler_StoreDown(t *testing.T) {
+	store := &memoryStore{err: errors.New("unavailable")}
+	h := otlpsink.NewS3Handler(store,
+		otlpsink.WithFlushInterval(time.Hour),
+		otlpsink.WithMaxBufferedItems(1),
+		otlpsink.WithBufferLimit(2),
+	)
+	ctx := context.Background()
+	_, err := h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return store.putCount() == 1 }, time.Second, 10*time.Millisecond)
+	require.Never(t, func() bool { return store.putCount() > 1 }, 100*time.Millisecond, 10*time.Millisecond, "the failed partitions wait for the backoff")
+
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.NoError(t, err)
+	_, err = h.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: spansAt(time.Now())})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err), "the buffer is bounded")
+	require.Never(t, func() bool { return store.putCount() > 1 }, 50*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, h.Close(ctx))
+}
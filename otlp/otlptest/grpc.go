@@ -27,19 +27,21 @@ type Server struct {
 	closed bool
 }
 
+// NewServer starts and returns a new gRPC server serving the mux.
+// the options are passed to grpc.NewServer, e.g. grpc.MaxRecvMsgSize to test export requests larger than 4MB.
 func NewServer(mux *otlp.ServerMux, opts ...grpc.ServerOption) *Server {
 	server := NewUnstartedServer(mux, opts...)
 	server.Start()
 	return server
 }
 
+// NewUnstartedServer returns a new gRPC server serving the mux, but does not start it.
 func NewUnstartedServer(mux *otlp.ServerMux, opts ...grpc.ServerOption) *Server {
 	s := &Server{
 		Listener: newLocalListener(grpcServeFlag),
-		server:   grpc.NewServer(opts...),
+		server:   mux.NewGRPCServer(opts...),
 	}
 	s.SetLogger(nil)
-	mux.Register(s.server)
 	return s
 }
 
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"sync"
 
+	"github.com/mashiike/go-otlp-helper/otlp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
@@ -81,6 +82,12 @@ func (s *TraceService) httpExporter(opts ...otlptracehttp.Option) (*otlptrace.Ex
 	return exporter, nil
 }
 
+// WithIDGenerator is the option of TraceService.Provider that generates the trace IDs and span IDs of the spans with the generator,
+// e.g. otlp.NewXRayIDGenerator() to test the X-Ray formatted trace IDs.
+func WithIDGenerator(gen otlp.IDGenerator) trace.TracerProviderOption {
+	return trace.WithIDGenerator(gen)
+}
+
 func (s *TraceService) Provider(opts ...any) (*trace.TracerProvider, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -0,0 +1,56 @@
+package otlp
+
+import (
+	"errors"
+	"time"
+)
+
+// PartialSuccess is the details of a partial success response of an export.
+type PartialSuccess struct {
+	// Rejected is the number of rejected spans, data points, log records or profiles.
+	Rejected int64
+	// ErrorMessage is the message of the partial success returned by the server.
+	ErrorMessage string
+	// Time is when the partial success response is received.
+	Time time.Time
+}
+
+// LastPartialSuccess returns the most recent partial success of the signal, "traces", "metrics", "logs" or "profiles".
+// it is useful for health endpoints and debugging without inspecting every error returned by the uploads.
+func (c *Client) LastPartialSuccess(signal string) (PartialSuccess, bool) {
+	c.partialMu.Lock()
+	defer c.partialMu.Unlock()
+	ps, ok := c.lastPartialSuccess[signal]
+	return ps, ok
+}
+
+func (c *Client) recordPartialSuccess(signal string, err error) {
+	var ps PartialSuccess
+	var (
+		tracesErr   *UploadTracesPartialSuccessError
+		metricsErr  *UploadMetricsPartialSuccessError
+		logsErr     *UploadLogsPartialSuccessError
+		profilesErr *UploadProfilesPartialSuccessError
+	)
+	switch {
+	case errors.As(err, &tracesErr):
+		ps.Rejected = tracesErr.Response().GetPartialSuccess().GetRejectedSpans()
+		ps.ErrorMessage = tracesErr.Response().GetPartialSuccess().GetErrorMessage()
+	case errors.As(err, &metricsErr):
+		ps.Rejected = metricsErr.Response().GetPartialSuccess().GetRejectedDataPoints()
+		ps.ErrorMessage = metricsErr.Response().GetPartialSuccess().GetErrorMessage()
+	case errors.As(err, &logsErr):
+		ps.Rejected = logsErr.Response().GetPartialSuccess().GetRejectedLogRecords()
+		ps.ErrorMessage = logsErr.Response().GetPartialSuccess().GetErrorMessage()
+	case errors.As(err, &profilesErr):
+		ps.Rejected = profilesErr.Response().GetPartialSuccess().GetRejectedProfiles()
+		ps.ErrorMessage = profilesErr.Response().GetPartialSuccess().GetErrorMessage()
+	default:
+		return
+	}
+	ps.Time = time.Now()
+	c.stats.signal(signal).rejected.Add(ps.Rejected)
+	c.partialMu.Lock()
+	defer c.partialMu.Unlock()
+	c.lastPartialSuccess[signal] = ps
+}
@@ -0,0 +1,76 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	logspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewTracePartialSuccess returns the response that accepts the request except for the rejected spans.
+func NewTracePartialSuccess(rejectedSpans int64, msg string) *TraceResponse {
+	return &TraceResponse{
+		PartialSuccess: &tracepb.ExportTracePartialSuccess{RejectedSpans: rejectedSpans, ErrorMessage: msg},
+	}
+}
+
+// NewMetricsPartialSuccess returns the response that accepts the request except for the rejected data points.
+func NewMetricsPartialSuccess(rejectedDataPoints int64, msg string) *MetricsResponse {
+	return &MetricsResponse{
+		PartialSuccess: &metricspb.ExportMetricsPartialSuccess{RejectedDataPoints: rejectedDataPoints, ErrorMessage: msg},
+	}
+}
+
+// NewLogsPartialSuccess returns the response that accepts the request except for the rejected log records.
+func NewLogsPartialSuccess(rejectedLogRecords int64, msg string) *LogsResponse {
+	return &LogsResponse{
+		PartialSuccess: &logspb.ExportLogsPartialSuccess{RejectedLogRecords: rejectedLogRecords, ErrorMessage: msg},
+	}
+}
+
+// RejectedItemsError is returned by the handlers that accept the request except for some items,
+// converted to the partial success response by PartialSuccessMiddleware.
+type RejectedItemsError struct {
+	// Rejected is the number of the rejected spans, data points or log records.
+	Rejected int64
+	Message  string
+}
+
+func (e *RejectedItemsError) Error() string {
+	return fmt.Sprintf("%d items rejected: %s", e.Rejected, e.Message)
+}
+
+// PartialSuccessMiddleware returns a middleware that converts *RejectedItemsError returned by the handlers into the partial success responses,
+// e.g. mux.Use(otlp.PartialSuccessMiddleware()). the handlers can return the error instead of building the response of the signal.
+func PartialSuccessMiddleware() MiddlewareFunc {
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			resp, err := next(ctx, req)
+			var rejected *RejectedItemsError
+			if !errors.As(err, &rejected) {
+				return resp, err
+			}
+			if resp := partialSuccessResponse(req, rejected.Rejected, rejected.Message); resp != nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// partialSuccessResponse returns the partial success response of the signal of the request, or nil for the unknown requests.
+func partialSuccessResponse(req proto.Message, rejected int64, msg string) proto.Message {
+	switch req.(type) {
+	case *TraceRequest:
+		return NewTracePartialSuccess(rejected, msg)
+	case *MetricsRequest:
+		return NewMetricsPartialSuccess(rejected, msg)
+	case *LogsRequest:
+		return NewLogsPartialSuccess(rejected, msg)
+	}
+	return nil
+}
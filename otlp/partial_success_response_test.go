@@ -0,0 +1,63 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialSuccessMiddleware(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Use(otlp.PartialSuccessMiddleware())
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return nil, fmt.Errorf("store spans: %w", &otlp.RejectedItemsError{Rejected: 1, Message: "span too large"})
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return otlp.NewLogsPartialSuccess(2, "invalid severity"), nil
+	})
+	errFailed := errors.New("failed")
+	mux.Metrics().HandleFunc(func(_ context.Context, _ *otlp.MetricsRequest) (*otlp.MetricsResponse, error) {
+		return nil, errFailed
+	})
+
+	for _, protocol := range []string{"grpc", "http/protobuf"} {
+		t.Run(protocol, func(t *testing.T) {
+			var endpoint string
+			if protocol == "grpc" {
+				server := otlptest.NewServer(mux)
+				defer server.Close()
+				endpoint = server.URL
+			} else {
+				server := otlptest.NewHTTPServer(mux)
+				defer server.Close()
+				endpoint = server.URL
+			}
+			client, err := otlp.NewClient(endpoint, otlp.WithProtocol(protocol))
+			require.NoError(t, err)
+			ctx := context.Background()
+			require.NoError(t, client.Start(ctx))
+			defer client.Stop(ctx)
+
+			err = client.UploadTraces(ctx, newSpans(3))
+			var tracesErr *otlp.UploadTracesPartialSuccessError
+			require.ErrorAs(t, err, &tracesErr)
+			require.EqualValues(t, 1, tracesErr.Response().GetPartialSuccess().GetRejectedSpans())
+			require.Equal(t, "span too large", tracesErr.Response().GetPartialSuccess().GetErrorMessage())
+
+			err = client.UploadLogs(ctx, nil)
+			var logsErr *otlp.UploadLogsPartialSuccessError
+			require.ErrorAs(t, err, &logsErr)
+			require.EqualValues(t, 2, logsErr.Response().GetPartialSuccess().GetRejectedLogRecords())
+
+			err = client.UploadMetrics(ctx, nil)
+			require.Error(t, err)
+			var metricsErr *otlp.UploadMetricsPartialSuccessError
+			require.False(t, errors.As(err, &metricsErr), "the other errors are not converted")
+		})
+	}
+}
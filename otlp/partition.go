@@ -1,11 +1,17 @@
 package otlp
 
 import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
 	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
 	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	profilespb "go.opentelemetry.io/proto/otlp/profiles/v1experimental"
 	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 )
@@ -38,6 +44,67 @@ func PartitionBySpanStartTime(format string, tz *time.Location) func(*tracepb.Re
 	}
 }
 
+// PartitionBySpanKind returns a function that partitions ResourceSpans by Span kind, e.g. SPAN_KIND_SERVER.
+func PartitionBySpanKind() func(*tracepb.ResourceSpans) string {
+	return func(rspans *tracepb.ResourceSpans) string {
+		scopeSpans := rspans.GetScopeSpans()
+		if len(scopeSpans) == 0 {
+			return ""
+		}
+		spans := scopeSpans[0].GetSpans()
+		if len(spans) == 0 {
+			return ""
+		}
+		return spans[0].GetKind().String()
+	}
+}
+
+// PartitionBySpanStatusCode returns a function that partitions ResourceSpans by Span status code, e.g. STATUS_CODE_ERROR.
+func PartitionBySpanStatusCode() func(*tracepb.ResourceSpans) string {
+	return func(rspans *tracepb.ResourceSpans) string {
+		scopeSpans := rspans.GetScopeSpans()
+		if len(scopeSpans) == 0 {
+			return ""
+		}
+		spans := scopeSpans[0].GetSpans()
+		if len(spans) == 0 {
+			return ""
+		}
+		return spans[0].GetStatus().GetCode().String()
+	}
+}
+
+// PartitionByTraceID returns a function that partitions ResourceSpans by the trace id of Span, in lowercase hex as W3C Trace Context, e.g. 5b8efff798038103d269b633813fc60c.
+func PartitionByTraceID() func(*tracepb.ResourceSpans) string {
+	return func(rspans *tracepb.ResourceSpans) string {
+		scopeSpans := rspans.GetScopeSpans()
+		if len(scopeSpans) == 0 {
+			return ""
+		}
+		spans := scopeSpans[0].GetSpans()
+		if len(spans) == 0 {
+			return ""
+		}
+		return hex.EncodeToString(spans[0].GetTraceId())
+	}
+}
+
+// GroupByTraceID groups the spans of the given ResourceSpans slice by the trace id, see PartitionByTraceID for the keys.
+// unlike PartitionResourceSpans, the spans of a trace are merged by MergeResourceSpans, so each group is a complete trace in the compact form,
+// e.g. for tail sampling or the storage layouts keyed by trace.
+func GroupByTraceID(src []*tracepb.ResourceSpans) map[string][]*tracepb.ResourceSpans {
+	m := make(map[string][]*tracepb.ResourceSpans)
+	getTraceID := PartitionByTraceID()
+	for _, elem := range SplitResourceSpans(src) {
+		key := getTraceID(elem)
+		m[key] = append(m[key], elem)
+	}
+	for key, elems := range m {
+		m[key] = MergeResourceSpans(elems)
+	}
+	return m
+}
+
 // PartitionBySpanEndTime returns a function that partitions ResourceSpans by Span end time.
 func PartitionBySpanEndTime(format string, tz *time.Location) func(*tracepb.ResourceSpans) string {
 	return func(rspans *tracepb.ResourceSpans) string {
@@ -63,6 +130,74 @@ const (
 	Hourly  = "2006/01/02/15"
 )
 
+// HourlyWithZoneOffset is Hourly with the zone offset, which keeps partitions of the repeated hour at DST fall back transitions apart.
+const HourlyWithZoneOffset = Hourly + "-0700"
+
+// LoadPartitionLocation loads the time zone by IANA name, e.g. "Asia/Tokyo" or "America/New_York".
+// unlike time.FixedZone, the location follows DST transitions. empty name and "UTC" are UTC, "Local" is the local time zone.
+// the time zone database of the system is used, import time/tzdata to embed it in the binary for environments without it.
+func LoadPartitionLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("load location %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// PartitionBySpanStartTimeInLocationName is PartitionBySpanStartTime with the time zone loaded by LoadPartitionLocation.
+func PartitionBySpanStartTimeInLocationName(format string, name string) (func(*tracepb.ResourceSpans) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionBySpanStartTime(format, loc), nil
+}
+
+// PartitionBySpanEndTimeInLocationName is PartitionBySpanEndTime with the time zone loaded by LoadPartitionLocation.
+func PartitionBySpanEndTimeInLocationName(format string, name string) (func(*tracepb.ResourceSpans) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionBySpanEndTime(format, loc), nil
+}
+
+// PartitionByMetricStartTimeInLocationName is PartitionByMetricStartTime with the time zone loaded by LoadPartitionLocation.
+func PartitionByMetricStartTimeInLocationName(format string, name string) (func(*metricspb.ResourceMetrics) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionByMetricStartTime(format, loc), nil
+}
+
+// PartitionByMetricTimeInLocationName is PartitionByMetricTime with the time zone loaded by LoadPartitionLocation.
+func PartitionByMetricTimeInLocationName(format string, name string) (func(*metricspb.ResourceMetrics) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionByMetricTime(format, loc), nil
+}
+
+// PartitionByLogTimeInLocationName is PartitionByLogTime with the time zone loaded by LoadPartitionLocation.
+func PartitionByLogTimeInLocationName(format string, name string) (func(*logspb.ResourceLogs) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionByLogTime(format, loc), nil
+}
+
+// PartitionByLogObservedTimeInLocationName is PartitionByLogObservedTime with the time zone loaded by LoadPartitionLocation.
+func PartitionByLogObservedTimeInLocationName(format string, name string) (func(*logspb.ResourceLogs) string, error) {
+	loc, err := LoadPartitionLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	return PartitionByLogObservedTime(format, loc), nil
+}
+
 // TotalSpans returns the total number of spans in the given ResourceSpans slice.
 func TotalSpans(src []*tracepb.ResourceSpans) int {
 	total := 0
@@ -86,9 +221,65 @@ func SpanInTimeRangeFilter(start, end time.Time) func(*resourcepb.Resource, *com
 	}
 }
 
+// SpanNameFilter returns a filter function that keeps the spans whose name matches the pattern, where * matches any characters including /,
+// e.g. "GET /health*". combine with NotFilter to drop them, FilterResourceSpans(src, NotFilter(SpanNameFilter("*/healthz"))).
+func SpanNameFilter(pattern string) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) bool {
+		return matchWildcard(pattern, span.GetName())
+	}
+}
+
+// SpanKindFilter returns a filter function that keeps the spans of the given kinds, e.g. SpanKindFilter(tracepb.Span_SPAN_KIND_SERVER).
+func SpanKindFilter(kinds ...tracepb.Span_SpanKind) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) bool {
+		return slices.Contains(kinds, span.GetKind())
+	}
+}
+
+// SpanStatusFilter returns a filter function that keeps the spans of the given status codes, e.g. SpanStatusFilter(tracepb.Status_STATUS_CODE_ERROR).
+func SpanStatusFilter(codes ...tracepb.Status_StatusCode) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) bool {
+		return slices.Contains(codes, span.GetStatus().GetCode())
+	}
+}
+
+// SpanHasErrorFilter returns a filter function that keeps the spans with errors, the status ERROR or the exception events,
+// which are recorded by RecordError of the OpenTelemetry SDKs without setting the status.
+func SpanHasErrorFilter() func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) bool {
+		if span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+			return true
+		}
+		return slices.ContainsFunc(span.GetEvents(), func(event *tracepb.Span_Event) bool {
+			return event.GetName() == "exception"
+		})
+	}
+}
+
+// matchWildcard reports whether s matches the pattern, where * matches any characters.
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
+
 // FilterResourceSpans filters the given ResourceSpans slice based on the given filter function.
 func FilterResourceSpans(src []*tracepb.ResourceSpans, filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool) []*tracepb.ResourceSpans {
-	filter := andFilter(filters...)
+	filter := AndFilter(filters...)
 	splited := SplitResourceSpans(src)
 	filtered := make([]*tracepb.ResourceSpans, 0, len(splited))
 	for _, elem := range splited {
@@ -359,7 +550,7 @@ func MetricDataPointInTimeRangeFilter(start, end time.Time) func(*resourcepb.Res
 
 // FilterResourceMetrics filters the given ResourceMetrics slice based on the given filter function.
 func FilterResourceMetrics(src []*metricspb.ResourceMetrics, filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, *metricspb.Metric) bool) []*metricspb.ResourceMetrics {
-	filter := andFilter(filters...)
+	filter := AndFilter(filters...)
 	splited := SplitResourceMetrics(src)
 	filtered := make([]*metricspb.ResourceMetrics, 0, len(splited))
 	for _, elem := range splited {
@@ -532,6 +723,31 @@ func PartitionByLogSeverityNumber() func(*logspb.ResourceLogs) string {
 	}
 }
 
+// PartitionByLogSeverityBucket returns a function that partitions ResourceLogs by the range of Log severity number defined by the OpenTelemetry log data model:
+// TRACE (1-4), DEBUG (5-8), INFO (9-12), WARN (13-16), ERROR (17-20), FATAL (21-24) and UNSPECIFIED for the others.
+func PartitionByLogSeverityBucket() func(*logspb.ResourceLogs) string {
+	return func(rlogs *logspb.ResourceLogs) string {
+		scopeLogs := rlogs.GetScopeLogs()
+		if len(scopeLogs) == 0 {
+			return ""
+		}
+		logRecords := scopeLogs[0].GetLogRecords()
+		if len(logRecords) == 0 {
+			return ""
+		}
+		return logSeverityBucket(logRecords[0].GetSeverityNumber())
+	}
+}
+
+var logSeverityBuckets = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func logSeverityBucket(n logspb.SeverityNumber) string {
+	if n < logspb.SeverityNumber_SEVERITY_NUMBER_TRACE || n > logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4 {
+		return "UNSPECIFIED"
+	}
+	return logSeverityBuckets[(n-1)/4]
+}
+
 // PartitionByLogSeverityText returns a function that partitions ResourceLogs by Log severity text.
 func PartitionByLogSeverityText() func(*logspb.ResourceLogs) string {
 	return func(rlogs *logspb.ResourceLogs) string {
@@ -576,6 +792,17 @@ func TotalLogRecords(src []*logspb.ResourceLogs) int {
 	return total
 }
 
+// TotalProfiles returns the total number of profiles in the given ResourceProfiles slice.
+func TotalProfiles(src []*profilespb.ResourceProfiles) int {
+	total := 0
+	for _, elem := range src {
+		for _, elemScopeProfiles := range elem.GetScopeProfiles() {
+			total += len(elemScopeProfiles.GetProfiles())
+		}
+	}
+	return total
+}
+
 // SplitResourceLogs splits the given ResourceLogs slice into multiple ResourceLogs slices, each containing only one log record.
 func SplitResourceLogs(src []*logspb.ResourceLogs) []*logspb.ResourceLogs {
 	dst := make([]*logspb.ResourceLogs, 0, TotalLogRecords(src))
@@ -601,9 +828,42 @@ func LogRecordInTimeRangeFilter(start, end time.Time) func(*resourcepb.Resource,
 	}
 }
 
+// LogSeverityRangeFilter returns a filter function that keeps the log records whose severity number is between min and max inclusive,
+// e.g. LogSeverityRangeFilter(logspb.SeverityNumber_SEVERITY_NUMBER_INFO, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4) drops the TRACE and DEBUG logs.
+func LogSeverityRangeFilter(min, max logspb.SeverityNumber) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, logRecord *logspb.LogRecord) bool {
+		n := logRecord.GetSeverityNumber()
+		return n >= min && n <= max
+	}
+}
+
+// LogSeverityTextFilter returns a filter function that keeps the log records whose severity text is one of values, case-insensitively, e.g. "WARN", "warning".
+func LogSeverityTextFilter(values ...string) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, logRecord *logspb.LogRecord) bool {
+		return slices.ContainsFunc(values, func(value string) bool {
+			return strings.EqualFold(value, logRecord.GetSeverityText())
+		})
+	}
+}
+
+// LogBodyMatchFilter returns a filter function that keeps the log records whose body matches re.
+// bodies other than strings are matched in the form formatted with fmt, e.g. map[key:value].
+func LogBodyMatchFilter(re *regexp.Regexp) func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool {
+	return func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, logRecord *logspb.LogRecord) bool {
+		body := logRecord.GetBody()
+		if body.GetValue() == nil {
+			return re.MatchString("")
+		}
+		if s, ok := body.GetValue().(*commonpb.AnyValue_StringValue); ok {
+			return re.MatchString(s.StringValue)
+		}
+		return re.MatchString(fmt.Sprint(AnyValueToInterface(body)))
+	}
+}
+
 // FilterResourceLogs filters the given ResourceLogs slice based on the given filter function.
 func FilterResourceLogs(src []*logspb.ResourceLogs, filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord) bool) []*logspb.ResourceLogs {
-	filter := andFilter(filters...)
+	filter := AndFilter(filters...)
 	splited := SplitResourceLogs(src)
 	filtered := make([]*logspb.ResourceLogs, 0, len(splited))
 	for _, elem := range splited {
@@ -634,7 +894,9 @@ func splitScopeLogs(src []*logspb.ScopeLogs) []*logspb.ScopeLogs {
 	return dst
 }
 
-func andFilter[T any](filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
+// AndFilter returns a filter function that keeps the records matching all the given filters, as the filters of FilterResourceSpans etc. are combined.
+// it keeps all the records if no filters are given.
+func AndFilter[T any](filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
 	return func(r *resourcepb.Resource, s *commonpb.InstrumentationScope, t T) bool {
 		for _, f := range filters {
 			if !f(r, s, t) {
@@ -644,3 +906,167 @@ func andFilter[T any](filters ...func(*resourcepb.Resource, *commonpb.Instrument
 		return true
 	}
 }
+
+// NotFilter returns a filter function that inverts the given filter, e.g. to drop the records matching it.
+func NotFilter[T any](filter func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
+	return func(r *resourcepb.Resource, s *commonpb.InstrumentationScope, t T) bool {
+		return !filter(r, s, t)
+	}
+}
+
+// OrFilter returns a filter function that keeps the records matching any of the given filters,
+// e.g. FilterResourceSpans(src, OrFilter(SpanHasErrorFilter(), SpanKindFilter(tracepb.Span_SPAN_KIND_SERVER))).
+// it keeps no records if no filters are given.
+func OrFilter[T any](filters ...func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
+	return func(r *resourcepb.Resource, s *commonpb.InstrumentationScope, t T) bool {
+		for _, f := range filters {
+			if f(r, s, t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ScopeAttribute returns the value of the instrumentation scope attribute as a string, and whether it is set.
+// values other than strings are formatted with fmt, e.g. true, 42.
+func ScopeAttribute(scope *commonpb.InstrumentationScope, key string) (string, bool) {
+	return attributeString(scope.GetAttributes(), key)
+}
+
+// ResourceAttribute returns the value of the resource attribute as a string, and whether it is set, formatted as ScopeAttribute.
+func ResourceAttribute(resource *resourcepb.Resource, key string) (string, bool) {
+	return attributeString(resource.GetAttributes(), key)
+}
+
+func attributeString(attrs []*commonpb.KeyValue, key string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.GetKey() != key {
+			continue
+		}
+		if s, ok := attr.GetValue().GetValue().(*commonpb.AnyValue_StringValue); ok {
+			return s.StringValue, true
+		}
+		return fmt.Sprint(AnyValueToInterface(attr.GetValue())), true
+	}
+	return "", false
+}
+
+// ScopeAttributeFilter returns a filter function that keeps the records whose instrumentation scope has the attribute,
+// and if values are given, whose value is one of them. see ScopeAttribute for the string form of the value.
+// e.g. FilterResourceSpans(src, ScopeAttributeFilter[*tracepb.Span]("team", "payments"))
+func ScopeAttributeFilter[T any](key string, values ...string) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
+	return func(_ *resourcepb.Resource, scope *commonpb.InstrumentationScope, _ T) bool {
+		value, ok := ScopeAttribute(scope, key)
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		return slices.Contains(values, value)
+	}
+}
+
+// ResourceAttributeFilter returns a filter function that keeps the records whose resource has the attribute,
+// and if values are given, whose value is one of them. see ResourceAttribute for the string form of the value.
+func ResourceAttributeFilter[T any](key string, values ...string) func(*resourcepb.Resource, *commonpb.InstrumentationScope, T) bool {
+	return func(resource *resourcepb.Resource, _ *commonpb.InstrumentationScope, _ T) bool {
+		value, ok := ResourceAttribute(resource, key)
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		return slices.Contains(values, value)
+	}
+}
+
+// PartitionBySpanScopeAttribute returns a function that partitions ResourceSpans by the instrumentation scope attribute, empty if it is not set.
+func PartitionBySpanScopeAttribute(key string) func(*tracepb.ResourceSpans) string {
+	return func(rspans *tracepb.ResourceSpans) string {
+		scopeSpans := rspans.GetScopeSpans()
+		if len(scopeSpans) == 0 {
+			return ""
+		}
+		value, _ := ScopeAttribute(scopeSpans[0].GetScope(), key)
+		return value
+	}
+}
+
+// PartitionByMetricScopeAttribute returns a function that partitions ResourceMetrics by the instrumentation scope attribute, empty if it is not set.
+func PartitionByMetricScopeAttribute(key string) func(*metricspb.ResourceMetrics) string {
+	return func(rmetrics *metricspb.ResourceMetrics) string {
+		scopeMetrics := rmetrics.GetScopeMetrics()
+		if len(scopeMetrics) == 0 {
+			return ""
+		}
+		value, _ := ScopeAttribute(scopeMetrics[0].GetScope(), key)
+		return value
+	}
+}
+
+// PartitionByLogScopeAttribute returns a function that partitions ResourceLogs by the instrumentation scope attribute, empty if it is not set.
+func PartitionByLogScopeAttribute(key string) func(*logspb.ResourceLogs) string {
+	return func(rlogs *logspb.ResourceLogs) string {
+		scopeLogs := rlogs.GetScopeLogs()
+		if len(scopeLogs) == 0 {
+			return ""
+		}
+		value, _ := ScopeAttribute(scopeLogs[0].GetScope(), key)
+		return value
+	}
+}
+
+// PartitionByResourceAttribute returns a function that partitions ResourceSpans, ResourceMetrics or ResourceLogs by the resource attribute, empty if it is not set.
+// e.g. to route by the tenant id, deployment.environment or cloud.account.id:
+//
+//	PartitionResourceSpans(src, PartitionByResourceAttribute[*tracepb.ResourceSpans]("cloud.account.id"))
+func PartitionByResourceAttribute[T interface{ GetResource() *resourcepb.Resource }](key string) func(T) string {
+	return func(elem T) string {
+		value, _ := ResourceAttribute(elem.GetResource(), key)
+		return value
+	}
+}
+
+// PartitionByScopeName returns a function that partitions ResourceSpans, ResourceMetrics or ResourceLogs by the instrumentation scope name, empty if it is not set.
+// e.g. to apply the sampling or storage policies per instrumentation library:
+//
+//	PartitionResourceSpans(src, PartitionByScopeName[*tracepb.ResourceSpans]())
+func PartitionByScopeName[T *tracepb.ResourceSpans | *metricspb.ResourceMetrics | *logspb.ResourceLogs]() func(T) string {
+	return func(elem T) string {
+		return firstScope(elem).GetName()
+	}
+}
+
+// PartitionByScopeNameVersion returns a function that partitions ResourceSpans, ResourceMetrics or ResourceLogs by the instrumentation scope name and version,
+// joined with "@", e.g. go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp@0.56.0. the name only if the version is not set.
+func PartitionByScopeNameVersion[T *tracepb.ResourceSpans | *metricspb.ResourceMetrics | *logspb.ResourceLogs]() func(T) string {
+	return func(elem T) string {
+		scope := firstScope(elem)
+		if scope.GetVersion() == "" {
+			return scope.GetName()
+		}
+		return scope.GetName() + "@" + scope.GetVersion()
+	}
+}
+
+// firstScope returns the scope of the first scope spans, metrics or logs, the only one in the partitioned elements.
+func firstScope(elem any) *commonpb.InstrumentationScope {
+	switch elem := elem.(type) {
+	case *tracepb.ResourceSpans:
+		if scopeSpans := elem.GetScopeSpans(); len(scopeSpans) > 0 {
+			return scopeSpans[0].GetScope()
+		}
+	case *metricspb.ResourceMetrics:
+		if scopeMetrics := elem.GetScopeMetrics(); len(scopeMetrics) > 0 {
+			return scopeMetrics[0].GetScope()
+		}
+	case *logspb.ResourceLogs:
+		if scopeLogs := elem.GetScopeLogs(); len(scopeLogs) > 0 {
+			return scopeLogs[0].GetScope()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PartitionError is the error of a partition, returned as a part of MultiPartitionError.
+type PartitionError struct {
+	Key string
+	Err error
+}
+
+func (e *PartitionError) Error() string {
+	return fmt.Sprintf("partition %q: %v", e.Key, e.Err)
+}
+
+func (e *PartitionError) Unwrap() error {
+	return e.Err
+}
+
+// MultiPartitionError is the error of the partitions that failed, so callers can retry only the failed partitions.
+type MultiPartitionError struct {
+	Errors []*PartitionError
+}
+
+func (e *MultiPartitionError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d partitions failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the partitions, errors.Is and errors.As look into them.
+func (e *MultiPartitionError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Keys returns the sorted keys of the failed partitions.
+func (e *MultiPartitionError) Keys() []string {
+	keys := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		keys = append(keys, err.Key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ForEachPartition calls f for each partition returned by PartitionResourceSpans, PartitionResourceMetrics or PartitionResourceLogs in key order,
+// e.g. to upload each partition to a different sink.
+// all partitions are processed even if some of them fail, and *MultiPartitionError is returned for the failed partitions.
+func ForEachPartition[T any](ctx context.Context, partitions map[string]T, f func(ctx context.Context, key string, partition T) error) error {
+	keys := make([]string, 0, len(partitions))
+	for key := range partitions {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	var errs []*PartitionError
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, &PartitionError{Key: key, Err: err})
+			continue
+		}
+		if err := f(ctx, key, partitions[key]); err != nil {
+			errs = append(errs, &PartitionError{Key: key, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return &MultiPartitionError{Errors: errs}
+	}
+	return nil
+}
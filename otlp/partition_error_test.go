@@ -0,0 +1,40 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestForEachPartition(t *testing.T) {
+	errSink := errors.New("sink unavailable")
+	partitions := map[string][]*tracepb.ResourceSpans{
+		"a": newSpans(1),
+		"b": newSpans(2),
+		"c": newSpans(3),
+	}
+	var called []string
+	err := otlp.ForEachPartition(context.Background(), partitions, func(_ context.Context, key string, spans []*tracepb.ResourceSpans) error {
+		called = append(called, key)
+		if key == "a" || key == "c" {
+			return errSink
+		}
+		return nil
+	})
+	require.Equal(t, []string{"a", "b", "c"}, called)
+	var multiErr *otlp.MultiPartitionError
+	require.ErrorAs(t, err, &multiErr)
+	require.Equal(t, []string{"a", "c"}, multiErr.Keys())
+	require.ErrorIs(t, err, errSink)
+	var partitionErr *otlp.PartitionError
+	require.ErrorAs(t, err, &partitionErr)
+	require.Equal(t, "a", partitionErr.Key)
+
+	require.NoError(t, otlp.ForEachPartition(context.Background(), partitions, func(_ context.Context, _ string, _ []*tracepb.ResourceSpans) error {
+		return nil
+	}))
+}
@@ -2,6 +2,7 @@ package otlp_test
 
 import (
 	"os"
+	"regexp"
 	"testing"
 	"time"
 
@@ -215,3 +216,569 @@ func TestFilterResourceLogs(t *testing.T) {
 	t.Log("expected", string(expected))
 	require.JSONEq(t, string(expected), string(actual))
 }
+
+func newSpansAt(times ...time.Time) []*tracepb.ResourceSpans {
+	spans := make([]*tracepb.Span, 0, len(times))
+	for i, t := range times {
+		spans = append(spans, &tracepb.Span{
+			TraceId:           []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+			SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, byte(i + 1)},
+			StartTimeUnixNano: uint64(t.UnixNano()),
+			EndTimeUnixNano:   uint64(t.UnixNano()),
+		})
+	}
+	return []*tracepb.ResourceSpans{
+		{ScopeSpans: []*tracepb.ScopeSpans{{Spans: spans}}},
+	}
+}
+
+func TestPartitionBySpanStartTimeInLocationName(t *testing.T) {
+	partitionBy, err := otlp.PartitionBySpanStartTimeInLocationName(otlp.Hourly, "Asia/Tokyo")
+	require.NoError(t, err)
+	m := otlp.PartitionResourceSpans(newSpansAt(time.Date(2018, 12, 13, 14, 51, 0, 0, time.UTC)), partitionBy)
+	require.ElementsMatch(t, []string{"2018/12/13/23"}, mapKeys(m))
+
+	_, err = otlp.PartitionBySpanStartTimeInLocationName(otlp.Hourly, "Asia/Unknown")
+	require.Error(t, err)
+}
+
+func TestPartitionBySpanStartTimeInLocationName__DST(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		times    []time.Time
+		expected []string
+	}{
+		{
+			name:   "spring forward",
+			format: otlp.Hourly,
+			times: []time.Time{
+				time.Date(2024, 3, 10, 6, 30, 0, 0, time.UTC), // 01:30 EST
+				time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC), // 03:30 EDT, 02:xx is skipped
+			},
+			expected: []string{"2024/03/10/01", "2024/03/10/03"},
+		},
+		{
+			name:   "fall back",
+			format: otlp.Hourly,
+			times: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC), // 01:30 EST
+			},
+			expected: []string{"2024/11/03/01"},
+		},
+		{
+			name:   "fall back with zone offset",
+			format: otlp.HourlyWithZoneOffset,
+			times: []time.Time{
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC),
+			},
+			expected: []string{"2024/11/03/01-0400", "2024/11/03/01-0500"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			partitionBy, err := otlp.PartitionBySpanStartTimeInLocationName(c.format, "America/New_York")
+			require.NoError(t, err)
+			m := otlp.PartitionResourceSpans(newSpansAt(c.times...), partitionBy)
+			require.ElementsMatch(t, c.expected, mapKeys(m))
+		})
+	}
+}
+
+func TestPartitionBySpanKindAndStatusCode(t *testing.T) {
+	src := []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Spans: []*tracepb.Span{
+						{Name: "a", Kind: tracepb.Span_SPAN_KIND_SERVER, Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}},
+						{Name: "b", Kind: tracepb.Span_SPAN_KIND_CLIENT},
+						{Name: "c", Kind: tracepb.Span_SPAN_KIND_SERVER, Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK}},
+					},
+				},
+			},
+		},
+	}
+	byKind := otlp.PartitionResourceSpans(src, otlp.PartitionBySpanKind())
+	require.ElementsMatch(t, []string{"SPAN_KIND_SERVER", "SPAN_KIND_CLIENT"}, mapKeys(byKind))
+	require.Equal(t, 2, otlp.TotalSpans(byKind["SPAN_KIND_SERVER"]))
+
+	byStatus := otlp.PartitionResourceSpans(src, otlp.PartitionBySpanStatusCode())
+	require.ElementsMatch(t, []string{"STATUS_CODE_ERROR", "STATUS_CODE_UNSET", "STATUS_CODE_OK"}, mapKeys(byStatus))
+}
+
+func TestPartitionByLogSeverityBucket(t *testing.T) {
+	src := []*logspb.ResourceLogs{
+		{
+			ScopeLogs: []*logspb.ScopeLogs{
+				{
+					LogRecords: []*logspb.LogRecord{
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_INFO},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_INFO4},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR2},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4},
+						{},
+					},
+				},
+			},
+		},
+	}
+	m := otlp.PartitionResourceLogs(src, otlp.PartitionByLogSeverityBucket())
+	require.ElementsMatch(t, []string{"INFO", "ERROR", "FATAL", "UNSPECIFIED"}, mapKeys(m))
+	require.Equal(t, 2, otlp.TotalLogRecords(m["INFO"]))
+}
+
+func TestScopeAttributeFilterAndPartition(t *testing.T) {
+	scope := func(team string) *commonpb.InstrumentationScope {
+		return &commonpb.InstrumentationScope{
+			Name: "github.com/example/lib",
+			Attributes: []*commonpb.KeyValue{
+				{Key: "team", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: team}}},
+				{Key: "tier", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 1}}},
+			},
+		}
+	}
+	src := []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{Scope: scope("payments"), Spans: []*tracepb.Span{{Name: "a"}, {Name: "b"}}},
+				{Scope: scope("search"), Spans: []*tracepb.Span{{Name: "c"}}},
+				{Scope: &commonpb.InstrumentationScope{Name: "other"}, Spans: []*tracepb.Span{{Name: "d"}}},
+			},
+		},
+	}
+	value, ok := otlp.ScopeAttribute(scope("payments"), "tier")
+	require.True(t, ok)
+	require.Equal(t, "1", value)
+	_, ok = otlp.ScopeAttribute(nil, "team")
+	require.False(t, ok)
+
+	filtered := otlp.FilterResourceSpans(src, otlp.ScopeAttributeFilter[*tracepb.Span]("team", "payments"))
+	require.Equal(t, 2, otlp.TotalSpans(filtered))
+	filtered = otlp.FilterResourceSpans(src, otlp.ScopeAttributeFilter[*tracepb.Span]("team"))
+	require.Equal(t, 3, otlp.TotalSpans(filtered))
+
+	byTeam := otlp.PartitionResourceSpans(src, otlp.PartitionBySpanScopeAttribute("team"))
+	require.ElementsMatch(t, []string{"payments", "search", ""}, mapKeys(byTeam))
+	require.Equal(t, 2, otlp.TotalSpans(byTeam["payments"]))
+
+	logs := []*logspb.ResourceLogs{
+		{
+			ScopeLogs: []*logspb.ScopeLogs{
+				{Scope: scope("payments"), LogRecords: []*logspb.LogRecord{{}}},
+				{Scope: scope("search"), LogRecords: []*logspb.LogRecord{{}}},
+			},
+		},
+	}
+	byLogTeam := otlp.PartitionResourceLogs(logs, otlp.PartitionByLogScopeAttribute("team"))
+	require.ElementsMatch(t, []string{"payments", "search"}, mapKeys(byLogTeam))
+	require.Equal(t, 1, otlp.TotalLogRecords(otlp.FilterResourceLogs(logs, otlp.ScopeAttributeFilter[*logspb.LogRecord]("team", "search"))))
+}
+
+func TestPartitionByResourceAttribute(t *testing.T) {
+	resource := func(tenant string) *resourcepb.Resource {
+		if tenant == "" {
+			return &resourcepb.Resource{}
+		}
+		return &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "tenant.id", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: tenant}}},
+				{Key: "cloud.account.id", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 123456789012}}},
+			},
+		}
+	}
+	value, ok := otlp.ResourceAttribute(resource("acme"), "cloud.account.id")
+	require.True(t, ok)
+	require.Equal(t, "123456789012", value)
+	_, ok = otlp.ResourceAttribute(nil, "tenant.id")
+	require.False(t, ok)
+
+	spans := []*tracepb.ResourceSpans{
+		{Resource: resource("acme"), ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{{Name: "a"}, {Name: "b"}}}}},
+		{Resource: resource("globex"), ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{{Name: "c"}}}}},
+		{Resource: resource(""), ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{{Name: "d"}}}}},
+	}
+	bySpanTenant := otlp.PartitionResourceSpans(spans, otlp.PartitionByResourceAttribute[*tracepb.ResourceSpans]("tenant.id"))
+	require.ElementsMatch(t, []string{"acme", "globex", ""}, mapKeys(bySpanTenant))
+	require.Equal(t, 2, otlp.TotalSpans(bySpanTenant["acme"]))
+
+	metrics := []*metricspb.ResourceMetrics{
+		{
+			Resource: resource("acme"),
+			ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: []*metricspb.Metric{{
+				Name: "m",
+				Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}, {}}}},
+			}}}},
+		},
+		{
+			Resource: resource("globex"),
+			ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: []*metricspb.Metric{{
+				Name: "m",
+				Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}}}},
+			}}}},
+		},
+	}
+	byMetricAccount := otlp.PartitionResourceMetrics(metrics, otlp.PartitionByResourceAttribute[*metricspb.ResourceMetrics]("cloud.account.id"))
+	require.ElementsMatch(t, []string{"123456789012"}, mapKeys(byMetricAccount))
+	byMetricTenant := otlp.PartitionResourceMetrics(metrics, otlp.PartitionByResourceAttribute[*metricspb.ResourceMetrics]("tenant.id"))
+	require.Equal(t, 2, otlp.TotalDataPoints(byMetricTenant["acme"]))
+	require.Equal(t, 1, otlp.TotalDataPoints(byMetricTenant["globex"]))
+
+	logs := []*logspb.ResourceLogs{
+		{Resource: resource("acme"), ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{}}}}},
+		{Resource: resource("globex"), ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{}, {}}}}},
+	}
+	byLogTenant := otlp.PartitionResourceLogs(logs, otlp.PartitionByResourceAttribute[*logspb.ResourceLogs]("tenant.id"))
+	require.ElementsMatch(t, []string{"acme", "globex"}, mapKeys(byLogTenant))
+	require.Equal(t, 2, otlp.TotalLogRecords(byLogTenant["globex"]))
+}
+
+func TestPartitionByScopeName(t *testing.T) {
+	spans := []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{Scope: &commonpb.InstrumentationScope{Name: "otelhttp", Version: "0.56.0"}, Spans: []*tracepb.Span{{Name: "a"}, {Name: "b"}}},
+				{Scope: &commonpb.InstrumentationScope{Name: "otelhttp", Version: "0.55.0"}, Spans: []*tracepb.Span{{Name: "c"}}},
+				{Scope: &commonpb.InstrumentationScope{Name: "otelsql"}, Spans: []*tracepb.Span{{Name: "d"}}},
+				{Spans: []*tracepb.Span{{Name: "e"}}},
+			},
+		},
+	}
+	byName := otlp.PartitionResourceSpans(spans, otlp.PartitionByScopeName[*tracepb.ResourceSpans]())
+	require.ElementsMatch(t, []string{"otelhttp", "otelsql", ""}, mapKeys(byName))
+	require.Equal(t, 3, otlp.TotalSpans(byName["otelhttp"]))
+	byNameVersion := otlp.PartitionResourceSpans(spans, otlp.PartitionByScopeNameVersion[*tracepb.ResourceSpans]())
+	require.ElementsMatch(t, []string{"otelhttp@0.56.0", "otelhttp@0.55.0", "otelsql", ""}, mapKeys(byNameVersion))
+	require.Equal(t, 2, otlp.TotalSpans(byNameVersion["otelhttp@0.56.0"]))
+
+	metrics := []*metricspb.ResourceMetrics{
+		{
+			ScopeMetrics: []*metricspb.ScopeMetrics{
+				{
+					Scope: &commonpb.InstrumentationScope{Name: "runtime", Version: "1.0.0"},
+					Metrics: []*metricspb.Metric{{
+						Name: "m",
+						Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}, {}}}},
+					}},
+				},
+				{
+					Scope: &commonpb.InstrumentationScope{Name: "host"},
+					Metrics: []*metricspb.Metric{{
+						Name: "m",
+						Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{{}}}},
+					}},
+				},
+			},
+		},
+	}
+	byMetricScope := otlp.PartitionResourceMetrics(metrics, otlp.PartitionByScopeNameVersion[*metricspb.ResourceMetrics]())
+	require.ElementsMatch(t, []string{"runtime@1.0.0", "host"}, mapKeys(byMetricScope))
+	require.Equal(t, 2, otlp.TotalDataPoints(byMetricScope["runtime@1.0.0"]))
+
+	logs := []*logspb.ResourceLogs{
+		{
+			ScopeLogs: []*logspb.ScopeLogs{
+				{Scope: &commonpb.InstrumentationScope{Name: "slog"}, LogRecords: []*logspb.LogRecord{{}, {}}},
+				{Scope: &commonpb.InstrumentationScope{Name: "zap"}, LogRecords: []*logspb.LogRecord{{}}},
+			},
+		},
+	}
+	byLogScope := otlp.PartitionResourceLogs(logs, otlp.PartitionByScopeName[*logspb.ResourceLogs]())
+	require.ElementsMatch(t, []string{"slog", "zap"}, mapKeys(byLogScope))
+	require.Equal(t, 2, otlp.TotalLogRecords(byLogScope["slog"]))
+}
+
+func TestGroupByTraceID(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_trace.json")
+	require.NoError(t, err)
+	var data tracepb.TracesData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+
+	byTraceID := otlp.PartitionResourceSpans(data.GetResourceSpans(), otlp.PartitionByTraceID())
+	require.ElementsMatch(t, []string{"5b8efff798038103d269b633813fc60c"}, mapKeys(byTraceID))
+	groups := otlp.GroupByTraceID(data.GetResourceSpans())
+	require.ElementsMatch(t, []string{"5b8efff798038103d269b633813fc60c"}, mapKeys(groups))
+	group := groups["5b8efff798038103d269b633813fc60c"]
+	require.Len(t, group, 1)
+	require.Len(t, group[0].GetScopeSpans(), 1)
+	require.Len(t, group[0].GetScopeSpans()[0].GetSpans(), 2)
+
+	traceID := func(b byte) []byte {
+		id := make([]byte, 16)
+		id[15] = b
+		return id
+	}
+	resource := func(service string) *resourcepb.Resource {
+		return &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: service}}},
+			},
+		}
+	}
+	scope := &commonpb.InstrumentationScope{Name: "otelhttp"}
+	src := []*tracepb.ResourceSpans{
+		{
+			Resource: resource("frontend"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+				{Name: "a", TraceId: traceID(1)},
+				{Name: "b", TraceId: traceID(2)},
+				{Name: "c", TraceId: traceID(1)},
+			}}},
+		},
+		{
+			Resource: resource("backend"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+				{Name: "d", TraceId: traceID(1)},
+			}}},
+		},
+	}
+	groups = otlp.GroupByTraceID(src)
+	require.ElementsMatch(t, []string{
+		"00000000000000000000000000000001",
+		"00000000000000000000000000000002",
+	}, mapKeys(groups))
+	assertEqualMessage(t, &tracepb.TracesData{ResourceSpans: []*tracepb.ResourceSpans{
+		{
+			Resource: resource("frontend"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+				{Name: "a", TraceId: traceID(1)},
+				{Name: "c", TraceId: traceID(1)},
+			}}},
+		},
+		{
+			Resource: resource("backend"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: scope, Spans: []*tracepb.Span{
+				{Name: "d", TraceId: traceID(1)},
+			}}},
+		},
+	}}, &tracepb.TracesData{ResourceSpans: groups["00000000000000000000000000000001"]})
+	require.Equal(t, 1, otlp.TotalSpans(groups["00000000000000000000000000000002"]))
+}
+
+func TestSpanNameAndKindFilter(t *testing.T) {
+	src := []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Spans: []*tracepb.Span{
+						{Name: "GET /healthz", Kind: tracepb.Span_SPAN_KIND_SERVER},
+						{Name: "GET /users/{id}", Kind: tracepb.Span_SPAN_KIND_SERVER},
+						{Name: "POST /orders", Kind: tracepb.Span_SPAN_KIND_SERVER},
+						{Name: "SELECT users", Kind: tracepb.Span_SPAN_KIND_CLIENT},
+						{Name: "publish orders", Kind: tracepb.Span_SPAN_KIND_PRODUCER},
+						{Name: "health", Kind: tracepb.Span_SPAN_KIND_INTERNAL},
+					},
+				},
+			},
+		},
+	}
+	names := func(src []*tracepb.ResourceSpans) []string {
+		var names []string
+		for _, rs := range src {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					names = append(names, span.GetName())
+				}
+			}
+		}
+		return names
+	}
+	cases := []struct {
+		name     string
+		filters  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool
+		expected []string
+	}{
+		{
+			name:     "exact name",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.SpanNameFilter("health")},
+			expected: []string{"health"},
+		},
+		{
+			name:     "wildcard name",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.SpanNameFilter("GET /*")},
+			expected: []string{"GET /healthz", "GET /users/{id}"},
+		},
+		{
+			name:     "wildcards in the middle",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.SpanNameFilter("*orders*")},
+			expected: []string{"POST /orders", "publish orders"},
+		},
+		{
+			name:     "drop health checks",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.NotFilter(otlp.SpanNameFilter("*health*"))},
+			expected: []string{"GET /users/{id}", "POST /orders", "SELECT users", "publish orders"},
+		},
+		{
+			name:     "server spans only",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.SpanKindFilter(tracepb.Span_SPAN_KIND_SERVER)},
+			expected: []string{"GET /healthz", "GET /users/{id}", "POST /orders"},
+		},
+		{
+			name: "server spans without health checks",
+			filters: []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{
+				otlp.SpanKindFilter(tracepb.Span_SPAN_KIND_SERVER, tracepb.Span_SPAN_KIND_CONSUMER),
+				otlp.NotFilter(otlp.SpanNameFilter("GET /healthz")),
+			},
+			expected: []string{"GET /users/{id}", "POST /orders"},
+		},
+		{
+			name:     "no kinds",
+			filters:  []func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span) bool{otlp.SpanKindFilter()},
+			expected: nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expected, names(otlp.FilterResourceSpans(src, c.filters...)))
+		})
+	}
+}
+
+func TestSpanStatusAndErrorFilter(t *testing.T) {
+	src := []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Spans: []*tracepb.Span{
+						{Name: "ok", Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK}},
+						{Name: "error", Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "boom"}},
+						{Name: "unset"},
+						{Name: "exception", Events: []*tracepb.Span_Event{{Name: "retry"}, {Name: "exception"}}},
+						{Name: "event", Events: []*tracepb.Span_Event{{Name: "retry"}}},
+					},
+				},
+			},
+		},
+	}
+	names := func(src []*tracepb.ResourceSpans) []string {
+		var names []string
+		for _, rs := range src {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					names = append(names, span.GetName())
+				}
+			}
+		}
+		return names
+	}
+	require.Equal(t, []string{"error"}, names(otlp.FilterResourceSpans(src, otlp.SpanStatusFilter(tracepb.Status_STATUS_CODE_ERROR))))
+	require.Equal(t, []string{"ok", "unset", "exception", "event"}, names(otlp.FilterResourceSpans(src, otlp.SpanStatusFilter(tracepb.Status_STATUS_CODE_OK, tracepb.Status_STATUS_CODE_UNSET))))
+	require.Equal(t, []string{"error", "exception"}, names(otlp.FilterResourceSpans(src, otlp.SpanHasErrorFilter())))
+	require.Equal(t, []string{"ok", "unset", "event"}, names(otlp.FilterResourceSpans(src, otlp.NotFilter(otlp.SpanHasErrorFilter()))))
+}
+
+func TestLogSeverityAndBodyFilter(t *testing.T) {
+	str := func(s string) *commonpb.AnyValue {
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+	}
+	src := []*logspb.ResourceLogs{
+		{
+			ScopeLogs: []*logspb.ScopeLogs{
+				{
+					LogRecords: []*logspb.LogRecord{
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, SeverityText: "DEBUG", Body: str("cache hit")},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_INFO, SeverityText: "info", Body: str("order created")},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN, SeverityText: "Warn", Body: str("payment timeout, retrying")},
+						{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR2, SeverityText: "ERROR", Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+							Values: []*commonpb.KeyValue{{Key: "error", Value: str("payment failed")}},
+						}}}},
+						{},
+					},
+				},
+			},
+		},
+	}
+	severityTexts := func(src []*logspb.ResourceLogs) []string {
+		var severityTexts []string
+		for _, rl := range src {
+			for _, sl := range rl.GetScopeLogs() {
+				for _, record := range sl.GetLogRecords() {
+					severityTexts = append(severityTexts, record.GetSeverityText())
+				}
+			}
+		}
+		return severityTexts
+	}
+	require.Equal(t, []string{"info", "Warn", "ERROR"}, severityTexts(otlp.FilterResourceLogs(src,
+		otlp.LogSeverityRangeFilter(logspb.SeverityNumber_SEVERITY_NUMBER_INFO, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4),
+	)))
+	require.Equal(t, []string{"Warn"}, severityTexts(otlp.FilterResourceLogs(src,
+		otlp.LogSeverityRangeFilter(logspb.SeverityNumber_SEVERITY_NUMBER_WARN, logspb.SeverityNumber_SEVERITY_NUMBER_WARN4),
+	)))
+	require.Equal(t, []string{"DEBUG", "Warn"}, severityTexts(otlp.FilterResourceLogs(src, otlp.LogSeverityTextFilter("debug", "WARN"))))
+	require.Equal(t, []string{"Warn", "ERROR"}, severityTexts(otlp.FilterResourceLogs(src, otlp.LogBodyMatchFilter(regexp.MustCompile(`payment`)))))
+	require.Equal(t, []string{"ERROR"}, severityTexts(otlp.FilterResourceLogs(src, otlp.LogBodyMatchFilter(regexp.MustCompile(`error:payment failed`)))))
+	require.Equal(t, []string{"DEBUG", "info", ""}, severityTexts(otlp.FilterResourceLogs(src, otlp.NotFilter(otlp.LogBodyMatchFilter(regexp.MustCompile(`payment`))))))
+	require.Equal(t, []string{""}, severityTexts(otlp.FilterResourceLogs(src, otlp.LogBodyMatchFilter(regexp.MustCompile(`^$`)))))
+}
+
+func TestFilterCombinators(t *testing.T) {
+	resource := func(service string) *resourcepb.Resource {
+		return &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: service}}},
+			},
+		}
+	}
+	src := []*tracepb.ResourceSpans{
+		{
+			Resource: resource("checkout"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{
+				{Name: "a", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "b", Kind: tracepb.Span_SPAN_KIND_CLIENT},
+			}}},
+		},
+		{
+			Resource: resource("search"),
+			ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{
+				{Name: "c", Kind: tracepb.Span_SPAN_KIND_SERVER},
+				{Name: "d", Kind: tracepb.Span_SPAN_KIND_CLIENT, Status: &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}},
+			}}},
+		},
+	}
+	names := func(src []*tracepb.ResourceSpans) []string {
+		var names []string
+		for _, rs := range src {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					names = append(names, span.GetName())
+				}
+			}
+		}
+		return names
+	}
+	checkoutServer := otlp.AndFilter(
+		otlp.ResourceAttributeFilter[*tracepb.Span]("service.name", "checkout"),
+		otlp.SpanKindFilter(tracepb.Span_SPAN_KIND_SERVER),
+	)
+	require.Equal(t, []string{"a"}, names(otlp.FilterResourceSpans(src, checkoutServer)))
+	require.Equal(t, []string{"a", "d"}, names(otlp.FilterResourceSpans(src, otlp.OrFilter(checkoutServer, otlp.SpanHasErrorFilter()))))
+	require.Equal(t, []string{"b", "c"}, names(otlp.FilterResourceSpans(src, otlp.NotFilter(otlp.OrFilter(checkoutServer, otlp.SpanHasErrorFilter())))))
+	require.Empty(t, otlp.FilterResourceSpans(src, otlp.OrFilter[*tracepb.Span]()))
+	require.Equal(t, []string{"a", "b", "c", "d"}, names(otlp.FilterResourceSpans(src, otlp.AndFilter[*tracepb.Span]())))
+	require.Equal(t, []string{"a", "b", "c", "d"}, names(otlp.FilterResourceSpans(src, otlp.ResourceAttributeFilter[*tracepb.Span]("service.name"))))
+	require.Empty(t, otlp.FilterResourceSpans(src, otlp.ResourceAttributeFilter[*tracepb.Span]("deployment.environment")))
+
+	logs := []*logspb.ResourceLogs{
+		{
+			Resource: resource("checkout"),
+			ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG},
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR},
+			}}},
+		},
+		{
+			Resource: resource("search"),
+			ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+				{SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG},
+			}}},
+		},
+	}
+	filtered := otlp.FilterResourceLogs(logs, otlp.OrFilter(
+		otlp.ResourceAttributeFilter[*logspb.LogRecord]("service.name", "search"),
+		otlp.LogSeverityRangeFilter(logspb.SeverityNumber_SEVERITY_NUMBER_WARN, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4),
+	))
+	require.Equal(t, 2, otlp.TotalLogRecords(filtered))
+}
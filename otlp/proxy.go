@@ -3,13 +3,22 @@ package otlp
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"math"
+	"mime"
+	"net"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -56,56 +65,174 @@ func grpcCodeToHTTPStatus(code codes.Code) int {
 	}
 }
 
-func errorProto(w http.ResponseWriter, st *status.Status) {
-	httpStatus := grpcCodeToHTTPStatus(st.Code())
+func (h *proxyHandler[Req, Resp]) errorProto(w http.ResponseWriter, st *status.Status) {
+	httpStatus, logger := h.httpStatus(st.Code()), h.logger()
 	bs, err := proto.Marshal(st.Proto())
 	if err != nil {
 		http.Error(w, http.StatusText(httpStatus), httpStatus)
+		return
 	}
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.WriteHeader(httpStatus)
 	if _, err := w.Write(bs); err != nil {
-		slog.Debug("failed to write response", "error", err.Error())
+		logger.Warn("failed to write response", "details", err)
 	}
 }
 
-func errorJSON(w http.ResponseWriter, st *status.Status) {
-	httpStatus := grpcCodeToHTTPStatus(st.Code())
-	bs, err := MarshalJSON(st.Proto())
+func (h *proxyHandler[Req, Resp]) errorJSON(w http.ResponseWriter, st *status.Status) {
+	httpStatus, logger := h.httpStatus(st.Code()), h.logger()
+	bs, err := marshalStatusJSON(st)
 	if err != nil {
 		http.Error(w, http.StatusText(httpStatus), httpStatus)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
 	if _, err := w.Write(bs); err != nil {
-		slog.Debug("failed to write response", "error", err.Error())
+		logger.Warn("failed to write response", "details", err)
+	}
+}
+
+// marshalStatusJSON encodes st in JSON with the details such as ErrorInfo and RetryInfo, as the protobuf encoding does.
+// the details whose types are not linked into the binary can not be encoded in JSON, they are dropped instead of the whole status.
+func marshalStatusJSON(st *status.Status) ([]byte, error) {
+	p := st.Proto()
+	bs, err := defaultMarshalOptions.Marshal(p)
+	if err == nil {
+		return bs, nil
+	}
+	details := p.Details[:0]
+	for _, detail := range p.GetDetails() {
+		if _, err := defaultMarshalOptions.Marshal(detail); err == nil {
+			details = append(details, detail)
+		}
+	}
+	p.Details = details
+	return defaultMarshalOptions.Marshal(p)
+}
+
+// allowedMethods is the Allow header of the OTLP/HTTP routes.
+const allowedMethods = "POST, OPTIONS"
+
+// requestContentType returns the media type of the request without the parameters such as charset.
+func requestContentType(r *http.Request) string {
+	return parseMediaType(r.Header.Get("Content-Type"))
+}
+
+// parseMediaType returns the lower-cased media type of the Content-Type without the parameters, or empty if it is invalid.
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// errorByContentType writes st with the HTTP status, in the encoding of the request, or as a plain text if it is neither protobuf nor JSON.
+func errorByContentType(w http.ResponseWriter, r *http.Request, logger *slog.Logger, httpStatus int, st *status.Status) {
+	var (
+		bs  []byte
+		err error
+	)
+	contentType := requestContentType(r)
+	switch contentType {
+	case "application/x-protobuf":
+		bs, err = proto.Marshal(st.Proto())
+	case "application/json":
+		bs, err = marshalStatusJSON(st)
+	default:
+		http.Error(w, st.Message(), httpStatus)
+		return
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(httpStatus), httpStatus)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(httpStatus)
+	if _, err := w.Write(bs); err != nil {
+		logger.Warn("failed to write response", "details", err)
+	}
+}
+
+// statusFromError returns the status of the handler error. the context errors are mapped to Canceled and DeadlineExceeded, the others to Internal.
+func statusFromError(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	if cause := contextCause(err); cause != nil {
+		return status.FromContextError(cause)
+	}
+	return status.New(codes.Internal, err.Error())
+}
+
+// tooLargeRetryAfter is the Retry-After of the requests over the body size limit,
+// so that the clients back off, e.g. to split the batch, instead of retrying immediately.
+const tooLargeRetryAfter = "1"
+
+// tooLargeStatus returns RESOURCE_EXHAUSTED and sets Retry-After if err is caused by the request body size limit.
+func tooLargeStatus(w http.ResponseWriter, err error) (*status.Status, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return nil, false
+	}
+	w.Header().Set("Retry-After", tooLargeRetryAfter)
+	return status.New(codes.ResourceExhausted, fmt.Sprintf("request body is larger than %d bytes", maxBytesErr.Limit)), true
+}
+
+// setRetryAfter sets Retry-After from the RetryInfo details of the status, in seconds rounded up, as the HTTP clients do not see the details.
+func setRetryAfter(w http.ResponseWriter, st *status.Status) {
+	for _, detail := range st.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+			seconds := int64(math.Ceil(ri.GetRetryDelay().AsDuration().Seconds()))
+			w.Header().Set("Retry-After", strconv.FormatInt(max(seconds, 1), 10))
+			return
+		}
 	}
 }
 
 type proxyHandler[Req, Resp proto.Message] struct {
 	newRequestFunc func(context.Context) Req
 	handler        func(context.Context, Req) (Resp, error)
-	logger         *slog.Logger
+	logger         func() *slog.Logger
+	httpStatus     func(codes.Code) int
+	maxBodySize    *atomic.Int64
 }
 
 func newProxyHandler[Req, Resp proto.Message](newRequestFunc func(context.Context) Req, handler func(context.Context, Req) (Resp, error)) *proxyHandler[Req, Resp] {
 	return &proxyHandler[Req, Resp]{
 		newRequestFunc: newRequestFunc,
 		handler:        handler,
-		logger:         discardLogger,
+		logger: func() *slog.Logger {
+			return discardLogger
+		},
+		httpStatus: grpcCodeToHTTPStatus,
 	}
 }
 
-func (h *proxyHandler[Req, Resp]) SetLogger(logger *slog.Logger) {
-	h.logger = logger
-}
-
 func (h *proxyHandler[Req, Resp]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodOptions:
+		w.Header().Set("Allow", allowedMethods)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", allowedMethods)
+		st := status.Newf(codes.Unimplemented, "method %s is not allowed", r.Method)
+		errorByContentType(w, r, h.logger(), http.StatusMethodNotAllowed, st)
+		return
+	}
+	var limit int64
+	if h.maxBodySize != nil {
+		limit = h.maxBodySize.Load()
+	}
+	if err := decompressRequestBody(w, r, limit); err != nil {
+		h.logger().DebugContext(r.Context(), "unsupported request", "details", err)
+		errorByContentType(w, r, h.logger(), http.StatusUnsupportedMediaType, status.New(codes.InvalidArgument, err.Error()))
 		return
 	}
-	switch r.Header.Get("Content-Type") {
+	switch requestContentType(r) {
 	case "application/x-protobuf":
 		h.serveHTTPWithProto(w, r)
 	case "application/json":
@@ -117,103 +244,131 @@ func (h *proxyHandler[Req, Resp]) ServeHTTP(w http.ResponseWriter, r *http.Reque
 
 func (h *proxyHandler[Req, Resp]) serveHTTPWithProto(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
+	logger := h.logger()
+	// proto.Unmarshal copies the bytes fields, so the buffer is reused safely.
+	body := getDecodeBuffer()
+	defer putDecodeBuffer(body)
+	if _, err := body.ReadFrom(r.Body); err != nil {
+		if st, ok := tooLargeStatus(w, err); ok {
+			h.errorProto(w, st)
+			return
+		}
+		logger.WarnContext(ctx, "failed to read request body", "details", err)
 		st := status.New(codes.InvalidArgument, "Unable to read request body")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorProto(w, st)
+		h.errorProto(w, st)
 		return
 	}
 	defer func() {
 		if err := r.Body.Close(); err != nil {
-			h.logger.Warn("failed to close request body", "error", err.Error())
+			logger.WarnContext(ctx, "failed to close request body", "details", err)
 		}
 	}()
 	req := h.newRequestFunc(ctx)
-	if err := proto.Unmarshal(body, req); err != nil {
-		errorProto(w, status.New(codes.InvalidArgument, "Unable to unmarshal request body"))
+	if err := proto.Unmarshal(body.Bytes(), req); err != nil {
+		logger.WarnContext(ctx, "failed to unmarshal request body", "details", err)
+		h.errorProto(w, status.New(codes.InvalidArgument, "Unable to unmarshal request body"))
 		return
 	}
 	resp, err := h.handler(ctx, req)
 	if err != nil {
-		if st, ok := status.FromError(err); ok {
-			errorProto(w, st)
-			return
-		}
-		errorProto(w, status.New(codes.Internal, err.Error()))
+		st := statusFromError(err)
+		logger.WarnContext(ctx, "handler returned an error", "code", st.Code().String(), "details", err)
+		setRetryAfter(w, st)
+		h.errorProto(w, st)
 		return
 	}
 	data, err := proto.Marshal(resp)
 	if err != nil {
 		st := status.New(codes.Internal, "Unable to marshal response")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorProto(w, st)
+		h.errorProto(w, st)
 		return
 	}
 	var buf bytes.Buffer
 	if _, err := buf.Write(data); err != nil {
 		st := status.New(codes.Internal, "Unable to write response")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorProto(w, st)
+		h.errorProto(w, st)
 		return
 	}
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.WriteHeader(http.StatusOK)
 	if _, err := io.Copy(w, &buf); err != nil {
-		h.logger.Debug("failed to write response", "error", err.Error())
+		logger.WarnContext(ctx, "failed to write response", "details", err)
 	}
 }
 
 func (h *proxyHandler[Req, Resp]) serveHTTPWithJSON(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	logger := h.logger()
 	req := h.newRequestFunc(ctx)
-	bs, err := io.ReadAll(r.Body)
-	if err != nil {
-		st := status.New(codes.InvalidArgument, "Unable to read request body")
-		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorJSON(w, st)
-		return
-	}
 	defer func() {
 		if err := r.Body.Close(); err != nil {
-			h.logger.Warn("failed to close request body", "error", err.Error())
+			logger.WarnContext(ctx, "failed to close request body", "details", err)
 		}
 	}()
-
-	if err := UnmarshalJSON(bs, req); err != nil {
+	if err := decodeJSONStream(r.Body, req); err != nil {
+		if st, ok := tooLargeStatus(w, err); ok {
+			h.errorJSON(w, st)
+			return
+		}
+		logger.WarnContext(ctx, "failed to unmarshal request body", "details", err)
 		st := status.New(codes.InvalidArgument, "Unable to unmarshal request body")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorJSON(w, st)
+		h.errorJSON(w, st)
 		return
 	}
 	resp, err := h.handler(ctx, req)
 	if err != nil {
-		if st, ok := status.FromError(err); ok {
-			errorJSON(w, st)
-			return
-		}
-		errorJSON(w, status.New(codes.Internal, err.Error()))
+		st := statusFromError(err)
+		logger.WarnContext(ctx, "handler returned an error", "code", st.Code().String(), "details", err)
+		setRetryAfter(w, st)
+		h.errorJSON(w, st)
 		return
 	}
 	data, err := MarshalJSON(resp)
 	if err != nil {
 		st := status.New(codes.Internal, "Unable to marshal response")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorJSON(w, st)
+		h.errorJSON(w, st)
 		return
 	}
 	var buf bytes.Buffer
 	if _, err := buf.Write(data); err != nil {
 		st := status.New(codes.Internal, "Unable to write response")
 		st, _ = st.WithDetails(&errdetails.ErrorInfo{Reason: err.Error()})
-		errorJSON(w, st)
+		h.errorJSON(w, st)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err := io.Copy(w, &buf); err != nil {
-		h.logger.Debug("failed to write response", "error", err.Error())
+		logger.WarnContext(ctx, "failed to write response", "details", err)
+	}
+}
+
+// incomingContext returns the context of the request with the headers as the incoming metadata, see HeadersFromContext,
+// the remote address and the TLS connection state as the peer, as the gRPC server does, and the client info of the transport, see ClientInfoFromContext.
+func incomingContext(r *http.Request, transport string) context.Context {
+	md := make(metadata.MD, len(r.Header))
+	for k, v := range r.Header {
+		md[k] = v
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	info := ClientInfo{Transport: transport, Protocol: r.Proto}
+	p := &peer.Peer{}
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		p.Addr = addr
+		info.Addr = addr
+	}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	if p.Addr != nil || p.AuthInfo != nil {
+		ctx = peer.NewContext(ctx, p)
 	}
+	return withClientInfo(ctx, info)
 }
 
 func HeadersFromContext(ctx context.Context) (http.Header, bool) {
@@ -0,0 +1,112 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// RateLimits is the limits of RateLimitMiddleware, in items per second. zero means unlimited.
+type RateLimits struct {
+	SpansPerSecond      float64
+	DataPointsPerSecond float64
+	LogRecordsPerSecond float64
+}
+
+type rateLimitOptions struct {
+	burst          time.Duration
+	partialSuccess bool
+}
+
+// RateLimitOption is an option for RateLimitMiddleware.
+type RateLimitOption func(*rateLimitOptions)
+
+// WithRateLimitBurst sets the burst of the limits, as the duration of the items at the limit, e.g. 10s allows 10 seconds of items at once. default is 1s.
+func WithRateLimitBurst(d time.Duration) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.burst = d
+	}
+}
+
+// WithRateLimitPartialSuccess responds to the over-limit requests with a partial success that rejects all the items of the request,
+// instead of RESOURCE_EXHAUSTED. the clients do not retry the partial successes, so the items are dropped.
+func WithRateLimitPartialSuccess() RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.partialSuccess = true
+	}
+}
+
+// RateLimitMiddleware returns a middleware that limits the export requests by the number of the items per second, per signal,
+// counted with TotalSpans, TotalDataPoints and TotalLogRecords. the over-limit requests are not passed to the handler,
+// and are rejected with RESOURCE_EXHAUSTED and RetryInfo, sent as Retry-After over HTTP, so that the clients retry after the items are allowed.
+// a request larger than the burst is allowed when the bucket is full, so that it is not rejected forever.
+func RateLimitMiddleware(limits RateLimits, opts ...RateLimitOption) MiddlewareFunc {
+	o := rateLimitOptions{
+		burst: time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	buckets := map[string]*tokenBucket{
+		"traces":  newTokenBucket(limits.SpansPerSecond, o.burst),
+		"metrics": newTokenBucket(limits.DataPointsPerSecond, o.burst),
+		"logs":    newTokenBucket(limits.LogRecordsPerSecond, o.burst),
+	}
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			signalType, items := requestItems(req)
+			bucket, ok := buckets[signalType]
+			if !ok || bucket == nil {
+				return next(ctx, req)
+			}
+			wait, ok := bucket.take(float64(items), time.Now())
+			if ok {
+				return next(ctx, req)
+			}
+			message := fmt.Sprintf("%s rate limit exceeded: %d items", signalType, items)
+			if o.partialSuccess {
+				if resp := partialSuccessResponse(req, int64(items), message); resp != nil {
+					return resp, nil
+				}
+			}
+			return nil, throttleStatus(message, wait).Err()
+		}
+	}
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil if rate is not positive.
+func newTokenBucket(rate float64, burst time.Duration) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	size := math.Max(rate*burst.Seconds(), 1)
+	return &tokenBucket{rate: rate, burst: size, tokens: size}
+}
+
+// take takes n tokens, or returns the duration until they are available.
+func (b *tokenBucket) take(n float64, now time.Time) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	need := math.Min(n, b.burst)
+	if b.tokens >= need {
+		b.tokens -= need
+		return 0, true
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second)), false
+}
@@ -0,0 +1,63 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	newMux := func(opts ...otlp.RateLimitOption) *otlp.ServerMux {
+		mux := otlp.NewServerMux()
+		mux.Use(otlp.RateLimitMiddleware(otlp.RateLimits{SpansPerSecond: 10}, opts...))
+		mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+			return &otlp.TraceResponse{}, nil
+		})
+		mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+			return &otlp.LogsResponse{}, nil
+		})
+		return mux
+	}
+	post := func(t *testing.T, mux *otlp.ServerMux, path string, msg proto.Message) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := proto.Marshal(msg)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("resource exhausted", func(t *testing.T) {
+		mux := newMux(otlp.WithRateLimitBurst(time.Second))
+		require.Equal(t, http.StatusOK, post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(10)}).Code)
+		w := post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(5)})
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "1", w.Header().Get("Retry-After"))
+		// the logs are not limited.
+		require.Equal(t, http.StatusOK, post(t, mux, "/v1/logs", &otlp.LogsRequest{}).Code)
+	})
+	t.Run("larger than burst", func(t *testing.T) {
+		mux := newMux()
+		require.Equal(t, http.StatusOK, post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(50)}).Code)
+		require.Equal(t, http.StatusTooManyRequests, post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(1)}).Code)
+	})
+	t.Run("partial success", func(t *testing.T) {
+		mux := newMux(otlp.WithRateLimitPartialSuccess())
+		require.Equal(t, http.StatusOK, post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(10)}).Code)
+		w := post(t, mux, "/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(5)})
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp otlp.TraceResponse
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &resp))
+		require.EqualValues(t, 5, resp.GetPartialSuccess().GetRejectedSpans())
+		require.Contains(t, resp.GetPartialSuccess().GetErrorMessage(), "rate limit exceeded")
+	})
+}
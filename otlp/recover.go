@@ -0,0 +1,25 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// recoverPanic converts the panics of the handler, including the middlewares, into codes.Internal and logs them with the stack,
+// so that a panicking handler fails the request instead of crashing the server.
+func (mux *ServerMux) recoverPanic(next ProtoHandlerFunc) ProtoHandlerFunc {
+	return func(ctx context.Context, req proto.Message) (resp proto.Message, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				mux.getLogger().ErrorContext(ctx, "handler panicked", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+				resp, err = nil, status.Errorf(codes.Internal, "handler panicked: %v", r)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
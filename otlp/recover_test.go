@@ -0,0 +1,49 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMux__RecoverPanic(t *testing.T) {
+	traceData, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	mux := otlp.NewServerMux(otlp.WithMuxLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		panic("boom")
+	})
+
+	t.Run("http", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(traceData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Contains(t, w.Body.String(), "handler panicked: boom")
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		ctx := context.Background()
+		require.NoError(t, client.Start(ctx))
+		defer client.Stop(ctx)
+		err = client.UploadTraces(ctx, newSpans(1))
+		require.Equal(t, codes.Internal, status.Code(err))
+	})
+	require.Contains(t, buf.String(), "handler panicked")
+	require.Contains(t, buf.String(), "recover_test.go")
+}
@@ -0,0 +1,108 @@
+package otlp
+
+import (
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// RepairStrategy decides how RepairTraces fixes dangling parent span references.
+type RepairStrategy int
+
+const (
+	// RepairClearReferences clears the dangling ParentSpanId, so the span becomes a root span.
+	RepairClearReferences RepairStrategy = iota
+	// RepairSynthesizeRoots adds a placeholder root span for each missing parent span.
+	// the placeholder spans the time range of its children.
+	RepairSynthesizeRoots
+)
+
+// PlaceholderSpanName is the name of the placeholder span synthesized by RepairTraces.
+const PlaceholderSpanName = "<missing span>"
+
+// RepairTraces detects dangling ParentSpanIds and links to missing spans, and fixes them so partially captured traces render correctly in backends.
+// a link is considered broken only when the linked trace is in src but the linked span is not, because links to other traces usually point outside of the batch.
+// broken links are always dropped, dangling parents are fixed by the strategy.
+// the spans are modified in place, the placeholder spans are appended as new ResourceSpans.
+// it returns the repaired slice and the number of repaired references.
+func RepairTraces(src []*tracepb.ResourceSpans, strategy RepairStrategy) ([]*tracepb.ResourceSpans, int) {
+	traces := make(map[string]bool)
+	spans := make(map[string]bool)
+	for _, elem := range src {
+		for _, elemScopeSpan := range elem.GetScopeSpans() {
+			for _, elemSpan := range elemScopeSpan.GetSpans() {
+				traces[string(elemSpan.GetTraceId())] = true
+				spans[spanKey(elemSpan.GetTraceId(), elemSpan.GetSpanId())] = true
+			}
+		}
+	}
+	var (
+		repaired     int
+		placeholders []*tracepb.ResourceSpans
+	)
+	missing := make(map[string]*tracepb.Span)
+	for _, elem := range src {
+		for _, elemScopeSpan := range elem.GetScopeSpans() {
+			for _, elemSpan := range elemScopeSpan.GetSpans() {
+				links := elemSpan.GetLinks()[:0]
+				for _, link := range elemSpan.GetLinks() {
+					if traces[string(link.GetTraceId())] && !spans[spanKey(link.GetTraceId(), link.GetSpanId())] {
+						repaired++
+						continue
+					}
+					links = append(links, link)
+				}
+				if len(links) != len(elemSpan.GetLinks()) {
+					elemSpan.Links = links
+				}
+				if len(elemSpan.GetParentSpanId()) == 0 {
+					continue
+				}
+				key := spanKey(elemSpan.GetTraceId(), elemSpan.GetParentSpanId())
+				if spans[key] {
+					continue
+				}
+				repaired++
+				if strategy == RepairClearReferences {
+					elemSpan.ParentSpanId = nil
+					continue
+				}
+				placeholder, ok := missing[key]
+				if !ok {
+					placeholder = &tracepb.Span{
+						TraceId:           elemSpan.GetTraceId(),
+						SpanId:            elemSpan.GetParentSpanId(),
+						Name:              PlaceholderSpanName,
+						StartTimeUnixNano: elemSpan.GetStartTimeUnixNano(),
+						EndTimeUnixNano:   elemSpan.GetEndTimeUnixNano(),
+					}
+					missing[key] = placeholder
+					placeholders = append(placeholders, newPlaceholderResourceSpans(elem.GetResource(), placeholder))
+					continue
+				}
+				placeholder.StartTimeUnixNano = min(placeholder.GetStartTimeUnixNano(), elemSpan.GetStartTimeUnixNano())
+				placeholder.EndTimeUnixNano = max(placeholder.GetEndTimeUnixNano(), elemSpan.GetEndTimeUnixNano())
+			}
+		}
+	}
+	return append(src, placeholders...), repaired
+}
+
+func spanKey(traceID, spanID []byte) string {
+	return string(traceID) + string(spanID)
+}
+
+func newPlaceholderResourceSpans(resource *resourcepb.Resource, span *tracepb.Span) *tracepb.ResourceSpans {
+	return &tracepb.ResourceSpans{
+		Resource: resource,
+		ScopeSpans: []*tracepb.ScopeSpans{
+			{
+				Scope: &commonpb.InstrumentationScope{
+					Name:    "github.com/mashiike/go-otlp-helper/otlp",
+					Version: version,
+				},
+				Spans: []*tracepb.Span{span},
+			},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package otlp_test
+
+import (
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func newBrokenTrace() []*tracepb.ResourceSpans {
+	traceID := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	otherTraceID := []byte{0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01}
+	return []*tracepb.ResourceSpans{
+		{
+			ScopeSpans: []*tracepb.ScopeSpans{
+				{
+					Spans: []*tracepb.Span{
+						{
+							TraceId:           traceID,
+							SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, 1},
+							ParentSpanId:      []byte{0, 0, 0, 0, 0, 0, 0, 9},
+							StartTimeUnixNano: 200,
+							EndTimeUnixNano:   300,
+							Links: []*tracepb.Span_Link{
+								{TraceId: traceID, SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 8}},
+								{TraceId: otherTraceID, SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 8}},
+							},
+						},
+						{
+							TraceId:           traceID,
+							SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, 2},
+							ParentSpanId:      []byte{0, 0, 0, 0, 0, 0, 0, 9},
+							StartTimeUnixNano: 100,
+							EndTimeUnixNano:   250,
+						},
+						{
+							TraceId:           traceID,
+							SpanId:            []byte{0, 0, 0, 0, 0, 0, 0, 3},
+							ParentSpanId:      []byte{0, 0, 0, 0, 0, 0, 0, 1},
+							StartTimeUnixNano: 210,
+							EndTimeUnixNano:   220,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRepairTraces__ClearReferences(t *testing.T) {
+	repaired, n := otlp.RepairTraces(newBrokenTrace(), otlp.RepairClearReferences)
+	require.Equal(t, 3, n)
+	require.Equal(t, 3, otlp.TotalSpans(repaired))
+	spans := repaired[0].GetScopeSpans()[0].GetSpans()
+	require.Empty(t, spans[0].GetParentSpanId())
+	require.Len(t, spans[0].GetLinks(), 1, "links to other traces are kept")
+	require.Empty(t, spans[1].GetParentSpanId())
+	require.Equal(t, spans[0].GetSpanId(), spans[2].GetParentSpanId())
+}
+
+func TestRepairTraces__SynthesizeRoots(t *testing.T) {
+	repaired, n := otlp.RepairTraces(newBrokenTrace(), otlp.RepairSynthesizeRoots)
+	require.Equal(t, 3, n)
+	require.Equal(t, 4, otlp.TotalSpans(repaired))
+	spans := repaired[0].GetScopeSpans()[0].GetSpans()
+	require.Len(t, spans[0].GetLinks(), 1)
+	placeholder := repaired[1].GetScopeSpans()[0].GetSpans()[0]
+	require.Equal(t, otlp.PlaceholderSpanName, placeholder.GetName())
+	require.Equal(t, spans[0].GetParentSpanId(), placeholder.GetSpanId())
+	require.Empty(t, placeholder.GetParentSpanId())
+	require.EqualValues(t, 100, placeholder.GetStartTimeUnixNano())
+	require.EqualValues(t, 300, placeholder.GetEndTimeUnixNano())
+
+	_, n = otlp.RepairTraces(repaired, otlp.RepairSynthesizeRoots)
+	require.Equal(t, 0, n, "repaired traces have no broken references")
+}
@@ -0,0 +1,173 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReplaySource is the source of the records to replay, e.g. *FileExporterReader.
+// Read returns io.EOF when no more records are available.
+type ReplaySource interface {
+	Read() (*FileExporterRecord, error)
+}
+
+// Uploader uploads the signals, e.g. *Client and *TeeClient.
+type Uploader interface {
+	UploadTraces(ctx context.Context, protoSpans []*ResourceSpans) error
+	UploadMetrics(ctx context.Context, protoMetrics []*ResourceMetrics) error
+	UploadLogs(ctx context.Context, protoLogs []*ResourceLogs) error
+}
+
+var (
+	_ Uploader = (*Client)(nil)
+	_ Uploader = (*TeeClient)(nil)
+)
+
+type replayOptions struct {
+	speed        float64
+	preserveGaps bool
+	maxGap       time.Duration
+}
+
+// ReplayOption is an option for Replay.
+type ReplayOption func(*replayOptions)
+
+// WithReplaySpeed sets the playback speed, e.g. 2.0 replays twice as fast as recorded. default is 1.0.
+func WithReplaySpeed(speed float64) ReplayOption {
+	return func(o *replayOptions) {
+		o.speed = speed
+	}
+}
+
+// WithReplayPreserveGaps sets whether the idle periods between the records are replayed as recorded. default is true.
+// when false, the gaps longer than the max gap (see WithReplayMaxGap) are shortened to it, so sparse recordings replay densely.
+func WithReplayPreserveGaps(preserve bool) ReplayOption {
+	return func(o *replayOptions) {
+		o.preserveGaps = preserve
+	}
+}
+
+// WithReplayMaxGap sets the longest gap between the records, in recorded time, when the gaps are not preserved. default is 1s.
+// zero uploads the records back to back.
+func WithReplayMaxGap(d time.Duration) ReplayOption {
+	return func(o *replayOptions) {
+		o.maxGap = d
+	}
+}
+
+// Replay reads the records from source and uploads them with uploader, scheduled by their original timestamps, e.g. for load testing of collectors.
+// the timestamp of a record is the earliest span start, data point or log record time in it; the first record is uploaded immediately,
+// and the following records at their offsets from it divided by the speed. records without timestamps, or older than the previous record,
+// are uploaded right after the previous one.
+// it blocks until source returns io.EOF, ctx is done or an upload fails, and returns the error; io.EOF is not returned.
+func Replay(ctx context.Context, source ReplaySource, uploader Uploader, opts ...ReplayOption) error {
+	o := replayOptions{
+		speed:        1,
+		preserveGaps: true,
+		maxGap:       time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.speed <= 0 {
+		return fmt.Errorf("replay speed must be positive, got %v", o.speed)
+	}
+	if o.maxGap < 0 {
+		return errors.New("replay max gap must not be negative")
+	}
+	var (
+		started  time.Time
+		prev     time.Time
+		recorded time.Duration
+	)
+	for i := 0; ; i++ {
+		record, err := source.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read record %d: %w", i, err)
+		}
+		ts := recordTime(record)
+		if i == 0 {
+			started = time.Now()
+		}
+		if !ts.IsZero() {
+			if !prev.IsZero() && ts.After(prev) {
+				gap := ts.Sub(prev)
+				if !o.preserveGaps && gap > o.maxGap {
+					gap = o.maxGap
+				}
+				recorded += gap
+			}
+			if prev.IsZero() || ts.After(prev) {
+				prev = ts
+			}
+		}
+		if err := sleepUntil(ctx, started.Add(time.Duration(float64(recorded)/o.speed))); err != nil {
+			return err
+		}
+		if err := uploadRecord(ctx, uploader, record); err != nil {
+			return fmt.Errorf("upload record %d: %w", i, err)
+		}
+	}
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func uploadRecord(ctx context.Context, uploader Uploader, record *FileExporterRecord) error {
+	switch {
+	case record.Traces != nil:
+		return uploader.UploadTraces(ctx, record.Traces.GetResourceSpans())
+	case record.Metrics != nil:
+		return uploader.UploadMetrics(ctx, record.Metrics.GetResourceMetrics())
+	case record.Logs != nil:
+		return uploader.UploadLogs(ctx, record.Logs.GetResourceLogs())
+	}
+	return nil
+}
+
+// recordTime returns the earliest timestamp in the record, or the zero time if it has none.
+func recordTime(record *FileExporterRecord) time.Time {
+	var earliest time.Time
+	observe := func(t time.Time) {
+		if !t.IsZero() && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	switch {
+	case record.Traces != nil:
+		for _, span := range FlattenResourceSpans(record.Traces.GetResourceSpans()) {
+			observe(span.StartTime)
+		}
+	case record.Metrics != nil:
+		for _, point := range FlattenResourceMetrics(record.Metrics.GetResourceMetrics()) {
+			observe(point.Time)
+		}
+	case record.Logs != nil:
+		for _, logRecord := range FlattenResourceLogs(record.Logs.GetResourceLogs()) {
+			if logRecord.Time.IsZero() {
+				observe(logRecord.ObservedTime)
+				continue
+			}
+			observe(logRecord.Time)
+		}
+	}
+	return earliest
+}
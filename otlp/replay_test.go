@@ -0,0 +1,107 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceReplaySource []*otlp.FileExporterRecord
+
+func (s *sliceReplaySource) Read() (*otlp.FileExporterRecord, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	record := (*s)[0]
+	*s = (*s)[1:]
+	return record, nil
+}
+
+type recordingUploader struct {
+	mu    sync.Mutex
+	times []time.Time
+	err   error
+}
+
+func (u *recordingUploader) record() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.times = append(u.times, time.Now())
+	return u.err
+}
+
+func (u *recordingUploader) UploadTraces(context.Context, []*otlp.ResourceSpans) error {
+	return u.record()
+}
+
+func (u *recordingUploader) UploadMetrics(context.Context, []*otlp.ResourceMetrics) error {
+	return u.record()
+}
+
+func (u *recordingUploader) UploadLogs(context.Context, []*otlp.ResourceLogs) error {
+	return u.record()
+}
+
+func newReplaySource(offsets ...time.Duration) *sliceReplaySource {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := sliceReplaySource{}
+	for _, offset := range offsets {
+		source = append(source, &otlp.FileExporterRecord{
+			Traces: &otlp.TraceRequest{ResourceSpans: newSpansAt(base.Add(offset))},
+		})
+	}
+	return &source
+}
+
+func TestReplay_Speed(t *testing.T) {
+	uploader := &recordingUploader{}
+	source := newReplaySource(0, 200*time.Millisecond, 400*time.Millisecond)
+	started := time.Now()
+	err := otlp.Replay(context.Background(), source, uploader, otlp.WithReplaySpeed(2))
+	require.NoError(t, err)
+	require.Len(t, uploader.times, 3)
+	require.GreaterOrEqual(t, uploader.times[1].Sub(started), 100*time.Millisecond)
+	require.GreaterOrEqual(t, uploader.times[2].Sub(started), 200*time.Millisecond)
+	require.Less(t, uploader.times[2].Sub(started), 400*time.Millisecond)
+}
+
+func TestReplay_PreserveGaps(t *testing.T) {
+	uploader := &recordingUploader{}
+	source := newReplaySource(0, time.Hour, time.Hour+100*time.Millisecond)
+	started := time.Now()
+	err := otlp.Replay(context.Background(), source, uploader,
+		otlp.WithReplayPreserveGaps(false),
+		otlp.WithReplayMaxGap(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.Len(t, uploader.times, 3)
+	require.GreaterOrEqual(t, uploader.times[2].Sub(started), 100*time.Millisecond)
+	require.Less(t, uploader.times[2].Sub(started), time.Second)
+}
+
+func TestReplay_Canceled(t *testing.T) {
+	uploader := &recordingUploader{}
+	source := newReplaySource(0, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := otlp.Replay(ctx, source, uploader)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, uploader.times, 1)
+}
+
+func TestReplay_UploadError(t *testing.T) {
+	errUpload := errors.New("upload failed")
+	uploader := &recordingUploader{err: errUpload}
+	err := otlp.Replay(context.Background(), newReplaySource(0, time.Millisecond), uploader)
+	require.ErrorIs(t, err, errUpload)
+	require.Len(t, uploader.times, 1)
+
+	err = otlp.Replay(context.Background(), newReplaySource(0), uploader, otlp.WithReplaySpeed(0))
+	require.Error(t, err)
+}
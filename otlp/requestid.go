@@ -0,0 +1,118 @@
+package otlp
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the header of the request id, propagated from the exporters or generated by the mux,
+// and returned in the response header, the x-request-id metadata over gRPC.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength limits the propagated request ids, the longer or non-printable ones are replaced by generated ones.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request id of the export request, to correlate the failures of the exporters with the server logs.
+// the mux logs and the error statuses, as errdetails.RequestInfo, include it automatically.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// withRequestID returns the context with the request id, propagated if valid, or generated.
+func withRequestID(ctx context.Context, propagated string) (context.Context, string) {
+	id := propagated
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	return context.WithValue(ctx, requestIDKey{}, id), id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return !strings.ContainsFunc(id, func(r rune) bool {
+		return r < 0x21 || r > 0x7e
+	})
+}
+
+// ensureRequestID returns the context with the request id for the gRPC requests, whose ids are not set by ServeHTTP,
+// propagated from the x-request-id metadata or generated, and sets it to the response header.
+func ensureRequestID(ctx context.Context) context.Context {
+	if _, ok := RequestIDFromContext(ctx); ok {
+		return ctx
+	}
+	var propagated string
+	if values := metadata.ValueFromIncomingContext(ctx, RequestIDHeader); len(values) > 0 {
+		propagated = values[0]
+	}
+	ctx, id := withRequestID(ctx, propagated)
+	_ = SetResponseHeader(ctx, RequestIDHeader, id)
+	return ctx
+}
+
+// requestIDError is the handler error with the request id in the details of the status, keeping the original error for errors.Is and errors.As.
+type requestIDError struct {
+	err error
+	st  *status.Status
+}
+
+func (e *requestIDError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestIDError) Unwrap() error {
+	return e.err
+}
+
+func (e *requestIDError) GRPCStatus() *status.Status {
+	return e.st
+}
+
+// errorWithRequestID adds errdetails.RequestInfo with the request id of ctx to the status of err.
+func errorWithRequestID(ctx context.Context, err error) error {
+	id, ok := RequestIDFromContext(ctx)
+	if err == nil || !ok {
+		return err
+	}
+	st := statusFromError(err)
+	for _, detail := range st.Details() {
+		if _, ok := detail.(*errdetails.RequestInfo); ok {
+			return err
+		}
+	}
+	withID, detailErr := st.WithDetails(&errdetails.RequestInfo{RequestId: id})
+	if detailErr != nil {
+		return err
+	}
+	return &requestIDError{err: err, st: withID}
+}
+
+// requestIDLogHandler adds the request id of the context to the records, for the mux logger.
+type requestIDLogHandler struct {
+	slog.Handler
+}
+
+func (h requestIDLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDLogHandler) WithGroup(name string) slog.Handler {
+	return requestIDLogHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,117 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	mux := otlp.NewServerMux(otlp.WithMuxLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	ids := make(chan string, 1)
+	mux.Trace().HandleFunc(func(ctx context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		id, ok := otlp.RequestIDFromContext(ctx)
+		require.True(t, ok)
+		ids <- id
+		if req.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()[0].GetName() == "fail" {
+			return nil, errors.New("handler failed")
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	requestIDOf := func(t *testing.T, details []any) string {
+		t.Helper()
+		for _, detail := range details {
+			if info, ok := detail.(*errdetails.RequestInfo); ok {
+				return info.GetRequestId()
+			}
+		}
+		t.Fatal("no RequestInfo in the details")
+		return ""
+	}
+
+	t.Run("http", func(t *testing.T) {
+		post := func(t *testing.T, requestID string, fail bool) *httptest.ResponseRecorder {
+			t.Helper()
+			spans := newSpans(1)
+			if fail {
+				spans[0].ScopeSpans[0].Spans[0].Name = "fail"
+			}
+			body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: spans})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			if requestID != "" {
+				req.Header.Set(otlp.RequestIDHeader, requestID)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			return w
+		}
+
+		w := post(t, "req-1", false)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "req-1", <-ids)
+		require.Equal(t, "req-1", w.Header().Get(otlp.RequestIDHeader))
+
+		for _, invalid := range []string{"", "has space", strings.Repeat("x", 129)} {
+			w = post(t, invalid, false)
+			require.Equal(t, http.StatusOK, w.Code)
+			generated := <-ids
+			require.NotEqual(t, invalid, generated)
+			require.NotEmpty(t, generated)
+			require.Equal(t, generated, w.Header().Get(otlp.RequestIDHeader))
+		}
+
+		logs.Reset()
+		w = post(t, "req-2", true)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "req-2", <-ids)
+		var st spb.Status
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &st))
+		require.Equal(t, "req-2", requestIDOf(t, status.FromProto(&st).Details()))
+		require.Contains(t, logs.String(), "request_id=req-2")
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		ctx := context.Background()
+		client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"), otlp.WithHeaders(map[string]string{"X-Request-Id": "req-3"}))
+		require.NoError(t, err)
+		require.NoError(t, client.Start(ctx))
+		defer client.Stop(ctx)
+
+		require.NoError(t, client.UploadTraces(ctx, newSpans(1)))
+		require.Equal(t, "req-3", <-ids)
+
+		spans := newSpans(1)
+		spans[0].ScopeSpans[0].Spans[0].Name = "fail"
+		err = client.UploadTraces(ctx, spans)
+		require.Equal(t, "req-3", <-ids)
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.Internal, st.Code())
+		require.Equal(t, "req-3", requestIDOf(t, st.Details()))
+
+		generated, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		require.NoError(t, generated.Start(ctx))
+		defer generated.Stop(ctx)
+		require.NoError(t, generated.UploadTraces(ctx, newSpans(1)))
+		require.NotEmpty(t, <-ids)
+	})
+}
@@ -0,0 +1,30 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type responseHeaderKey struct{}
+
+func withResponseHeader(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, responseHeaderKey{}, header)
+}
+
+// SetResponseHeader sets the header of the export response in the handlers, e.g. rate limit hints, request ids or deprecation warnings to the exporters.
+// it is the HTTP response header over HTTP, gRPC-Web and Connect, and the header metadata over gRPC, whose key is lowercased.
+// it fails outside of the handlers, over WebSocket, or after the response header is sent.
+func SetResponseHeader(ctx context.Context, key, value string) error {
+	if header, ok := ctx.Value(responseHeaderKey{}).(http.Header); ok {
+		header.Set(key, value)
+		return nil
+	}
+	if grpc.ServerTransportStreamFromContext(ctx) == nil {
+		return errors.New("response header is not available in the context")
+	}
+	return grpc.SetHeader(ctx, metadata.Pairs(key, value))
+}
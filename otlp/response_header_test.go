@@ -0,0 +1,55 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSetResponseHeader(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		require.NoError(t, otlp.SetResponseHeader(ctx, "X-Quota-Remaining", "10"))
+		return &otlp.TraceResponse{}, nil
+	})
+
+	t.Run("http", func(t *testing.T) {
+		body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "10", w.Header().Get("X-Quota-Remaining"))
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		conn, err := grpc.NewClient(server.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+		var header metadata.MD
+		_, err = coltracepb.NewTraceServiceClient(conn).Export(
+			context.Background(),
+			&otlp.TraceRequest{ResourceSpans: newSpans(1)},
+			grpc.Header(&header),
+		)
+		require.NoError(t, err)
+		require.Equal(t, []string{"10"}, header.Get("x-quota-remaining"))
+	})
+	t.Run("none", func(t *testing.T) {
+		require.Error(t, otlp.SetResponseHeader(context.Background(), "X-Quota-Remaining", "10"))
+	})
+}
@@ -0,0 +1,260 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryConfig is the configuration for retrying failed exports.
+type RetryConfig struct {
+	// Enabled enables retrying. by default, retrying is disabled.
+	Enabled bool
+	// InitialInterval is the backoff interval after the first failure. default is 5s.
+	InitialInterval time.Duration
+	// MaxInterval is the upper bound of the backoff interval. default is 30s.
+	MaxInterval time.Duration
+	// MaxElapsedTime is the maximum total time spent on retrying. default is 1m.
+	MaxElapsedTime time.Duration
+	// MaxRetryAfter caps the delay requested by the server with Retry-After header or RetryInfo details.
+	// zero means the requested delay is used as is.
+	MaxRetryAfter time.Duration
+	// Multiplier is the factor the backoff interval grows by after each failure. default is 2.
+	Multiplier float64
+	// Jitter randomizes the backoff interval, so that many clients do not retry at the same time. default is RetryJitterNone.
+	// the delay requested by the server is not randomized.
+	Jitter RetryJitter
+}
+
+// RetryJitter decides how the backoff interval is randomized.
+type RetryJitter int
+
+const (
+	// RetryJitterNone waits the backoff interval as is.
+	RetryJitterNone RetryJitter = iota
+	// RetryJitterFull waits a random duration between zero and the backoff interval.
+	RetryJitterFull
+	// RetryJitterEqual waits half of the backoff interval plus a random duration up to the other half.
+	RetryJitterEqual
+)
+
+// Apply returns the delay to wait for the backoff interval d.
+func (j RetryJitter) Apply(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	switch j {
+	case RetryJitterFull:
+		return rand.N(d + 1)
+	case RetryJitterEqual:
+		half := d / 2
+		return half + rand.N(d-half+1)
+	}
+	return d
+}
+
+// DefaultRetryConfig is the RetryConfig used by WithRetry when the fields are zero.
+var DefaultRetryConfig = RetryConfig{
+	Enabled:         true,
+	InitialInterval: 5 * time.Second,
+	MaxInterval:     30 * time.Second,
+	MaxElapsedTime:  time.Minute,
+	Multiplier:      2,
+}
+
+// WithRetry sets the retry configuration for failed exports.
+// the delay requested by the server with Retry-After header (HTTP 429/503) or RetryInfo details (gRPC) is honored instead of the exponential backoff.
+func WithRetry(cfg RetryConfig) ClientOption {
+	return func(o *clientOptions) error {
+		if cfg.InitialInterval == 0 {
+			cfg.InitialInterval = DefaultRetryConfig.InitialInterval
+		}
+		if cfg.MaxInterval == 0 {
+			cfg.MaxInterval = DefaultRetryConfig.MaxInterval
+		}
+		if cfg.MaxElapsedTime == 0 {
+			cfg.MaxElapsedTime = DefaultRetryConfig.MaxElapsedTime
+		}
+		if cfg.Multiplier == 0 {
+			cfg.Multiplier = DefaultRetryConfig.Multiplier
+		}
+		if cfg.InitialInterval < 0 || cfg.MaxInterval < 0 || cfg.MaxElapsedTime < 0 || cfg.MaxRetryAfter < 0 {
+			return errors.New("retry intervals must not be negative")
+		}
+		if cfg.Multiplier < 1 {
+			return errors.New("retry multiplier must be greater than or equal to 1")
+		}
+		switch cfg.Jitter {
+		case RetryJitterNone, RetryJitterFull, RetryJitterEqual:
+		default:
+			return fmt.Errorf("unknown retry jitter %d", cfg.Jitter)
+		}
+		o.retry = cfg
+		return nil
+	}
+}
+
+// HTTPStatusError is the error returned when the HTTP export receives an unexpected status code.
+type HTTPStatusError struct {
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// RetryAfter returns the delay requested by the Retry-After header.
+func (e *HTTPStatusError) RetryAfter() (time.Duration, bool) {
+	return parseRetryAfter(e.Header.Get("Retry-After"), time.Now())
+}
+
+func parseRetryAfter(s string, now time.Time) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	t, err := http.ParseTime(s)
+	if err != nil {
+		return 0, false
+	}
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// retryableError reports whether the error is retryable and the delay requested by the server, if any.
+func retryableError(err error) (bool, time.Duration, bool) {
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			d, ok := httpErr.RetryAfter()
+			return true, d, ok
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true, 0, false
+		}
+		return false, 0, false
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return false, 0, false
+	}
+	var (
+		delay    time.Duration
+		hasDelay bool
+	)
+	for _, detail := range st.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+			delay, hasDelay = ri.GetRetryDelay().AsDuration(), true
+		}
+	}
+	switch st.Code() {
+	case codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return true, delay, hasDelay
+	case codes.ResourceExhausted:
+		// ResourceExhausted is retryable only when the server tells when to retry.
+		return hasDelay, delay, hasDelay
+	}
+	return false, 0, false
+}
+
+func (c *Client) doWithRetry(ctx context.Context, signalType string, items int, f func(context.Context) error) error {
+	return c.doWithRetryConfig(ctx, c.o.retry, signalType, items, f)
+}
+
+func (c *Client) doWithRetryConfig(ctx context.Context, cfg RetryConfig, signalType string, items int, export func(context.Context) error) (err error) {
+	// Stop aborts the uploads in flight when its context is done.
+	abortCtx := c.abortContext()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopAbort := context.AfterFunc(abortCtx, cancel)
+	defer stopAbort()
+	stats := c.stats.signal(signalType)
+	stats.queueDepth.Add(int64(items))
+	defer stats.queueDepth.Add(-int64(items))
+	defer func() {
+		if err != nil && abortCtx.Err() != nil {
+			c.recordDropped(signalType, items)
+		}
+	}()
+	start := time.Now()
+	err = retryLoop(ctx, c.o.logger, cfg, signalType, func(ctx context.Context) error {
+		stats.attempts.Add(1)
+		err := c.traceExport(ctx, signalType, export)
+		c.recordPartialSuccess(signalType, err)
+		return err
+	})
+	canceled := newCanceledError(ctx, signalType, err)
+	stats.record(time.Since(start), err, canceled)
+	if canceled == nil {
+		return err
+	}
+	if c.o.onCanceled != nil {
+		c.o.onCanceled(canceled)
+	}
+	return canceled
+}
+
+func retryLoop(ctx context.Context, logger *slog.Logger, cfg RetryConfig, signalType string, f func(context.Context) error) error {
+	if !cfg.Enabled {
+		return f(ctx)
+	}
+	start := time.Now()
+	interval := cfg.InitialInterval
+	multiplier := cfg.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultRetryConfig.Multiplier
+	}
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		retryable, delay, throttled := retryableError(err)
+		if !retryable {
+			return err
+		}
+		if throttled {
+			if cfg.MaxRetryAfter > 0 && delay > cfg.MaxRetryAfter {
+				delay = cfg.MaxRetryAfter
+			}
+		} else {
+			delay = cfg.Jitter.Apply(interval)
+			interval = time.Duration(float64(interval) * multiplier)
+			if interval > cfg.MaxInterval {
+				interval = cfg.MaxInterval
+			}
+		}
+		if time.Since(start)+delay > cfg.MaxElapsedTime {
+			return fmt.Errorf("max retry time elapsed: %w", err)
+		}
+		logger.WarnContext(ctx, "export failed, retrying", "signal", signalType, "attempt", attempt, "delay", delay, "throttled", throttled, "details", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
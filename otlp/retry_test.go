@@ -0,0 +1,216 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestClient_HTTP_RetryAfter(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) == 1 {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	defer server.Close()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("http/json"),
+		otlp.WithRetry(otlp.RetryConfig{
+			Enabled:       true,
+			MaxRetryAfter: 10 * time.Millisecond,
+		}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	start := time.Now()
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.EqualValues(t, 2, count.Load())
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestClient_HTTP_NoRetry(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	))
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/json"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	err = client.UploadTraces(ctx, []*otlp.ResourceSpans{})
+	var httpErr *otlp.HTTPStatusError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	require.EqualValues(t, 1, count.Load())
+}
+
+func TestClient_GRPC_RetryInfo(t *testing.T) {
+	var count atomic.Int32
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		if count.Add(1) == 1 {
+			st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+				RetryDelay: durationpb.New(10 * time.Millisecond),
+			})
+			require.NoError(t, err)
+			return nil, st.Err()
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	server := otlptest.NewServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithRetry(otlp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Hour,
+		}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.EqualValues(t, 2, count.Load())
+}
+
+func TestClient_Stats(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		},
+	))
+	defer server.Close()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("http/json"),
+		otlp.WithRetry(otlp.RetryConfig{Enabled: true}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.Error(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+
+	stats := client.Stats()
+	require.EqualValues(t, 1, stats["traces"].Exports)
+	require.EqualValues(t, 1, stats["traces"].Failures)
+	require.EqualValues(t, 1, stats["traces"].Retries)
+	require.Positive(t, stats["traces"].ExportDuration)
+	require.Equal(t, otlp.ExportStats{}, stats["logs"])
+
+	var v map[string]otlp.ExportStats
+	require.NoError(t, json.Unmarshal([]byte(client.ExpvarFunc().String()), &v))
+	require.Equal(t, stats, v)
+	var traces otlp.ExportStats
+	require.NoError(t, json.Unmarshal([]byte(client.ExpvarMap().Get("traces").String()), &traces))
+	require.Equal(t, stats["traces"], traces)
+
+	w := httptest.NewRecorder()
+	client.MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE otlp_client_exports_total counter",
+		`otlp_client_exports_total{signal="traces"} 1`,
+		`otlp_client_failures_total{signal="traces"} 1`,
+		`otlp_client_retries_total{signal="traces"} 1`,
+		`otlp_client_exports_total{signal="logs"} 0`,
+		`otlp_client_dropped_total{signal="traces"} 0`,
+		"# TYPE otlp_client_queue_depth gauge",
+		"# TYPE otlp_client_last_export_duration_seconds gauge",
+	} {
+		require.Contains(t, body, line+"\n")
+	}
+}
+
+func TestRetryJitter_Apply(t *testing.T) {
+	interval := 100 * time.Millisecond
+	require.Equal(t, interval, otlp.RetryJitterNone.Apply(interval))
+	for i := 0; i < 100; i++ {
+		full := otlp.RetryJitterFull.Apply(interval)
+		require.GreaterOrEqual(t, full, time.Duration(0))
+		require.LessOrEqual(t, full, interval)
+		equal := otlp.RetryJitterEqual.Apply(interval)
+		require.GreaterOrEqual(t, equal, interval/2)
+		require.LessOrEqual(t, equal, interval)
+	}
+	require.Equal(t, time.Duration(0), otlp.RetryJitterFull.Apply(0))
+}
+
+func TestWithRetry_Invalid(t *testing.T) {
+	_, err := otlp.NewClient("http://localhost:4318", otlp.WithRetry(otlp.RetryConfig{Enabled: true, Multiplier: 0.5}))
+	require.Error(t, err)
+	_, err = otlp.NewClient("http://localhost:4318", otlp.WithRetry(otlp.RetryConfig{Enabled: true, Jitter: otlp.RetryJitter(42)}))
+	require.Error(t, err)
+}
+
+func TestClient_HTTP_RetryJitter(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) <= 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		},
+	))
+	defer server.Close()
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("http/json"),
+		otlp.WithRetry(otlp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: 10 * time.Millisecond,
+			MaxInterval:     time.Second,
+			Multiplier:      1.5,
+			Jitter:          otlp.RetryJitterFull,
+		}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, client.UploadTraces(ctx, []*otlp.ResourceSpans{}))
+	require.EqualValues(t, 4, count.Load())
+	// full jitter never waits longer than the intervals 10ms, 15ms and 22.5ms.
+	require.Less(t, time.Since(start), 5*time.Second)
+}
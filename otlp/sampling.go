@@ -0,0 +1,104 @@
+package otlp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type samplingOptions struct {
+	traces *float64
+	logs   *float64
+}
+
+// SamplingOption is the option for SamplingMiddleware.
+type SamplingOption func(*samplingOptions)
+
+// WithTraceSampling keeps percent of the traces, 0 to 100. the spans are kept or dropped by the trace id,
+// with the same algorithm as TraceIDRatioBased sampler of the OpenTelemetry SDKs, so that the whole traces are kept together.
+func WithTraceSampling(percent float64) SamplingOption {
+	return func(o *samplingOptions) {
+		o.traces = &percent
+	}
+}
+
+// WithLogSampling keeps percent of the log records, 0 to 100. the log records with the trace id are sampled by it as WithTraceSampling,
+// so that the logs of the kept traces are kept with the same percent, and the others are sampled randomly.
+func WithLogSampling(percent float64) SamplingOption {
+	return func(o *samplingOptions) {
+		o.logs = &percent
+	}
+}
+
+// SamplingMiddleware returns a middleware that drops the spans and the log records by the probabilistic sampling, to reduce the volume stored by the handlers.
+// the dropped items are reported as rejected with the partial success. the signals without the option are not sampled.
+func SamplingMiddleware(opts ...SamplingOption) MiddlewareFunc {
+	o := samplingOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			var dropped int
+			switch req := req.(type) {
+			case *TraceRequest:
+				if o.traces == nil {
+					break
+				}
+				bound := samplingBound(*o.traces)
+				before := TotalSpans(req.GetResourceSpans())
+				req.ResourceSpans = FilterResourceSpans(req.GetResourceSpans(), func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, span *tracepb.Span) bool {
+					return sampleTraceID(span.GetTraceId(), bound)
+				})
+				dropped = before - TotalSpans(req.GetResourceSpans())
+			case *LogsRequest:
+				if o.logs == nil {
+					break
+				}
+				bound := samplingBound(*o.logs)
+				before := TotalLogRecords(req.GetResourceLogs())
+				req.ResourceLogs = FilterResourceLogs(req.GetResourceLogs(), func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, record *logspb.LogRecord) bool {
+					return sampleTraceID(record.GetTraceId(), bound)
+				})
+				dropped = before - TotalLogRecords(req.GetResourceLogs())
+			}
+			if dropped == 0 {
+				return next(ctx, req)
+			}
+			message := fmt.Sprintf("%d items are dropped by sampling", dropped)
+			if _, remaining := requestItems(req); remaining == 0 {
+				return partialSuccessResponse(req, int64(dropped), message), nil
+			}
+			resp, err := next(ctx, req)
+			if err != nil {
+				return resp, err
+			}
+			return addRejected(resp, int64(dropped), message), nil
+		}
+	}
+}
+
+// samplingBound returns the upper bound of the 63 bits of the trace ids to keep, as TraceIDRatioBased.
+func samplingBound(percent float64) uint64 {
+	ratio := min(max(percent/100, 0), 1)
+	if ratio >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(ratio * (1 << 63))
+}
+
+// sampleTraceID reports whether the trace id is kept, or randomly if it is not valid.
+func sampleTraceID(traceID []byte, bound uint64) bool {
+	if len(traceID) != 16 {
+		return rand.Uint64()>>1 < bound
+	}
+	return binary.BigEndian.Uint64(traceID[8:16])>>1 < bound
+}
@@ -0,0 +1,68 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func sampledTraceID(i int) []byte {
+	id := make([]byte, 16)
+	// spread the ids over the range of the sampling.
+	binary.BigEndian.PutUint64(id[8:], uint64(i)*(1<<56))
+	return id
+}
+
+func TestSamplingMiddleware(t *testing.T) {
+	var received proto.Message
+	h := otlp.SamplingMiddleware(otlp.WithTraceSampling(50), otlp.WithLogSampling(0))(func(_ context.Context, req proto.Message) (proto.Message, error) {
+		received = req
+		return &otlp.TraceResponse{}, nil
+	})
+	ctx := context.Background()
+
+	spans := make([]*tracepb.Span, 0, 512)
+	for i := 0; i < 256; i++ {
+		// two spans per trace.
+		spans = append(spans,
+			&tracepb.Span{TraceId: sampledTraceID(i), SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+			&tracepb.Span{TraceId: sampledTraceID(i), SpanId: []byte{0, 0, 0, 0, 0, 0, 0, 2}},
+		)
+	}
+	resp, err := h(ctx, &otlp.TraceRequest{ResourceSpans: []*otlp.ResourceSpans{{ScopeSpans: []*tracepb.ScopeSpans{{Spans: spans}}}}})
+	require.NoError(t, err)
+	kept := received.(*otlp.TraceRequest).GetResourceSpans()
+	require.Equal(t, 256, otlp.TotalSpans(kept))
+	traces := map[string]int{}
+	for _, rs := range kept {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				traces[string(span.GetTraceId())]++
+			}
+		}
+	}
+	require.Len(t, traces, 128)
+	for _, n := range traces {
+		require.Equal(t, 2, n, "the whole trace is kept")
+	}
+	require.EqualValues(t, 256, resp.(*otlp.TraceResponse).GetPartialSuccess().GetRejectedSpans())
+
+	received = nil
+	resp, err = h(ctx, &otlp.LogsRequest{ResourceLogs: []*otlp.ResourceLogs{{
+		ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{}, {TraceId: sampledTraceID(1)}}}},
+	}}})
+	require.NoError(t, err)
+	require.Nil(t, received, "the handler is not called without items")
+	require.EqualValues(t, 2, resp.(*otlp.LogsResponse).GetPartialSuccess().GetRejectedLogRecords())
+
+	metrics := &otlp.MetricsRequest{}
+	_, err = h(ctx, metrics)
+	require.NoError(t, err)
+	require.Same(t, metrics, received, "the metrics are not sampled")
+}
@@ -0,0 +1,152 @@
+package otlp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const selfTelemetryScopeName = "github.com/mashiike/go-otlp-helper/otlp"
+
+// selfTelemetryMetrics is the names of the accepted and refused items metrics per signal, after the receiver metrics of the collector.
+var selfTelemetryMetrics = []struct {
+	signal   string
+	accepted string
+	refused  string
+	unit     string
+}{
+	{signal: "traces", accepted: "otelcol_receiver_accepted_spans", refused: "otelcol_receiver_refused_spans", unit: "{spans}"},
+	{signal: "metrics", accepted: "otelcol_receiver_accepted_metric_points", refused: "otelcol_receiver_refused_metric_points", unit: "{datapoints}"},
+	{signal: "logs", accepted: "otelcol_receiver_accepted_log_records", refused: "otelcol_receiver_refused_log_records", unit: "{records}"},
+}
+
+type selfTelemetry struct {
+	uploader Uploader
+	interval time.Duration
+	start    time.Time
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// WithSelfTelemetry makes the mux upload its own metrics with uploader, e.g. *Client, every interval, default 1m,
+// the numbers of the accepted and refused spans, data points and log records, named after the receiver metrics of the collector
+// (otelcol_receiver_accepted_spans and so on) with the receiver attribute "otlp". the items of the requests the handler returned an error for are refused.
+// call Stop to stop uploading; it uploads the metrics once more.
+func WithSelfTelemetry(uploader Uploader, interval time.Duration) ServerMuxOption {
+	return func(mux *ServerMux) {
+		if uploader == nil {
+			return
+		}
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		mux.telemetry = &selfTelemetry{
+			uploader: uploader,
+			interval: interval,
+			start:    time.Now(),
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+	}
+}
+
+func (mux *ServerMux) runSelfTelemetry() {
+	t := mux.telemetry
+	defer close(t.done)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), t.interval)
+			if err := mux.uploadSelfTelemetry(ctx); err != nil {
+				mux.getLogger().WarnContext(ctx, "failed to upload self telemetry", "details", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Stop stops the background work of the mux, i.e. the self telemetry of WithSelfTelemetry, uploading the metrics for the last time.
+// it does not stop the servers serving the mux.
+func (mux *ServerMux) Stop(ctx context.Context) error {
+	t := mux.telemetry
+	if t == nil {
+		return nil
+	}
+	stopped := false
+	t.stopOnce.Do(func() {
+		close(t.stop)
+		stopped = true
+	})
+	if !stopped {
+		return nil
+	}
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return mux.uploadSelfTelemetry(ctx)
+}
+
+func (mux *ServerMux) uploadSelfTelemetry(ctx context.Context) error {
+	return mux.telemetry.uploader.UploadMetrics(ctx, mux.selfTelemetryMetrics(time.Now()))
+}
+
+// selfTelemetryMetrics returns the cumulative sums of the accepted and refused items since the mux is created.
+func (mux *ServerMux) selfTelemetryMetrics(now time.Time) []*ResourceMetrics {
+	attrs := []*commonpb.KeyValue{
+		{Key: "receiver", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "otlp"}}},
+	}
+	sum := func(name, unit string, value int64) *metricspb.Metric {
+		return &metricspb.Metric{
+			Name: name,
+			Unit: unit,
+			Data: &metricspb.Metric_Sum{
+				Sum: &metricspb.Sum{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					IsMonotonic:            true,
+					DataPoints: []*metricspb.NumberDataPoint{
+						{
+							Attributes:        attrs,
+							StartTimeUnixNano: uint64(mux.telemetry.start.UnixNano()),
+							TimeUnixNano:      uint64(now.UnixNano()),
+							Value:             &metricspb.NumberDataPoint_AsInt{AsInt: value},
+						},
+					},
+				},
+			},
+		}
+	}
+	metrics := make([]*metricspb.Metric, 0, 2*len(selfTelemetryMetrics))
+	for _, m := range selfTelemetryMetrics {
+		stats := mux.stats.signal(m.signal)
+		metrics = append(metrics,
+			sum(m.accepted, m.unit, stats.accepted.Load()),
+			sum(m.refused, m.unit, stats.refused.Load()),
+		)
+	}
+	return []*ResourceMetrics{
+		{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{
+					{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "otlp-mux"}}},
+				},
+			},
+			ScopeMetrics: []*metricspb.ScopeMetrics{
+				{
+					Scope:   &commonpb.InstrumentationScope{Name: selfTelemetryScopeName},
+					Metrics: metrics,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type metricsRecorder struct {
+	mu      sync.Mutex
+	uploads [][]*otlp.ResourceMetrics
+}
+
+func (r *metricsRecorder) UploadTraces(context.Context, []*otlp.ResourceSpans) error { return nil }
+func (r *metricsRecorder) UploadLogs(context.Context, []*otlp.ResourceLogs) error    { return nil }
+
+func (r *metricsRecorder) UploadMetrics(_ context.Context, protoMetrics []*otlp.ResourceMetrics) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uploads = append(r.uploads, protoMetrics)
+	return nil
+}
+
+func (r *metricsRecorder) last() map[string]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := map[string]float64{}
+	if len(r.uploads) == 0 {
+		return values
+	}
+	for _, point := range otlp.FlattenResourceMetrics(r.uploads[len(r.uploads)-1]) {
+		values[point.MetricName] = point.Value
+	}
+	return values
+}
+
+func TestServerMux_WithSelfTelemetry(t *testing.T) {
+	recorder := &metricsRecorder{}
+	mux := otlp.NewServerMux(otlp.WithSelfTelemetry(recorder, 10*time.Millisecond))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return nil, errors.New("failed")
+	})
+	post := func(path string, msg proto.Message) int {
+		body, err := proto.Marshal(msg)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, post("/v1/traces", &otlp.TraceRequest{ResourceSpans: newSpans(3)}))
+	require.Eventually(t, func() bool {
+		return recorder.last()["otelcol_receiver_accepted_spans"] == 3
+	}, time.Second, 10*time.Millisecond)
+
+	logs := &otlp.LogsRequest{
+		ResourceLogs: []*otlp.ResourceLogs{
+			{ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{}, {}}}}},
+		},
+	}
+	require.Equal(t, http.StatusInternalServerError, post("/v1/logs", logs))
+	ctx := context.Background()
+	require.NoError(t, mux.Stop(ctx))
+	require.NoError(t, mux.Stop(ctx))
+	values := recorder.last()
+	require.EqualValues(t, 3, values["otelcol_receiver_accepted_spans"])
+	require.EqualValues(t, 0, values["otelcol_receiver_refused_spans"])
+	require.EqualValues(t, 0, values["otelcol_receiver_accepted_log_records"])
+	require.EqualValues(t, 2, values["otelcol_receiver_refused_log_records"])
+	require.Contains(t, values, "otelcol_receiver_accepted_metric_points")
+}
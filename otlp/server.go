@@ -0,0 +1,169 @@
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultShutdownTimeout is the default of Server.ShutdownTimeout.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Server serves OTLP/gRPC and OTLP/HTTP of the mux on the same listener. the gRPC requests are told apart by the content type application/grpc over HTTP/2;
+// without TLS, HTTP/2 is accepted in cleartext (h2c) for them.
+type Server struct {
+	// Addr is the address to listen on, e.g. ":4317". default is ":4317".
+	Addr string
+	// Mux is the mux to serve. default is DefaultServerMux.
+	Mux *ServerMux
+	// TLSConfig enables TLS when not nil.
+	TLSConfig *tls.Config
+	// GRPCServerOptions is passed to NewGRPCServer, e.g. grpc.MaxRecvMsgSize.
+	GRPCServerOptions []grpc.ServerOption
+	// ShutdownTimeout is how long the in-flight requests are drained after the context is done, before the connections are closed.
+	// default is DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// ListenAndServe serves OTLP/gRPC and OTLP/HTTP of the mux on addr until ctx is done, see Server.
+func ListenAndServe(ctx context.Context, addr string, mux *ServerMux) error {
+	s := &Server{Addr: addr, Mux: mux}
+	return s.ListenAndServe(ctx)
+}
+
+// ListenAndServe listens on Addr and serves until ctx is done, see Serve.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":4317"
+	}
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve serves on l until ctx is done. then it stops accepting connections, waits for the in-flight requests up to ShutdownTimeout,
+// and closes the remaining connections. the requests received meanwhile are rejected with UNAVAILABLE, 503 over HTTP, so that the clients retry.
+// it returns nil after the graceful shutdown, or the error of serving.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	mux := s.Mux
+	if mux == nil {
+		mux = DefaultServerMux
+	}
+	grpcServer := mux.NewGRPCServer(s.GRPCServerOptions...)
+	// the HTTP/2 connections in cleartext are hijacked by h2c, and not waited for by http.Server.Shutdown, so the requests are tracked here.
+	// h2c keeps accepting streams on the open connections after Shutdown, so the requests are rejected once draining,
+	// instead of being added to inFlight while it is waited for.
+	var (
+		mu       sync.Mutex
+		draining bool
+		inFlight sync.WaitGroup
+	)
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isGRPC := r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+		mu.Lock()
+		if draining {
+			mu.Unlock()
+			writeShuttingDown(w, r, mux, isGRPC)
+			return
+		}
+		inFlight.Add(1)
+		mu.Unlock()
+		defer inFlight.Done()
+		if isGRPC {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	h2s := &http2.Server{}
+	if s.TLSConfig == nil {
+		handler = h2c.NewHandler(handler, h2s)
+	}
+	server := &http.Server{
+		Handler:           handler,
+		TLSConfig:         s.TLSConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithoutCancel(ctx)
+		},
+	}
+	// ConfigureServer lets Shutdown send GOAWAY to the HTTP/2 connections, including h2c.
+	if err := http2.ConfigureServer(server, h2s); err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		if s.TLSConfig != nil {
+			errCh <- server.ServeTLS(l, "", "")
+			return
+		}
+		errCh <- server.Serve(l)
+	}()
+	select {
+	case err := <-errCh:
+		grpcServer.Stop()
+		return err
+	case <-ctx.Done():
+	}
+	timeout := s.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	mu.Lock()
+	draining = true
+	mu.Unlock()
+	err := server.Shutdown(shutdownCtx)
+	drained := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		if err == nil {
+			err = shutdownCtx.Err()
+		}
+	}
+	if err != nil {
+		err = errors.Join(err, server.Close())
+	}
+	grpcServer.Stop()
+	if serveErr := <-errCh; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return err
+}
+
+// writeShuttingDown rejects the request received while the server is shutting down with UNAVAILABLE, 503 over HTTP, so that the clients retry.
+func writeShuttingDown(w http.ResponseWriter, r *http.Request, mux *ServerMux, isGRPC bool) {
+	st := status.New(codes.Unavailable, "server is shutting down")
+	if isGRPC {
+		// trailers-only response.
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code())))
+		w.Header().Set("Grpc-Message", encodeGRPCMessage(st.Message()))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Connection", "close")
+	errorByContentType(w, r, mux.getLogger(), http.StatusServiceUnavailable, st)
+}
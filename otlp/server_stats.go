@@ -0,0 +1,51 @@
+package otlp
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerStat is the statistics of an export request handled by ServerMux, reported by the callback of WithServerStats.
+type ServerStat struct {
+	// Signal is "traces", "metrics", "logs" or "profiles".
+	Signal string
+	// Items is the number of the spans, data points, log records or profiles in the request.
+	Items int
+	// Bytes is the size of the request in the protobuf encoding, regardless of the transport, the encoding and the compression.
+	Bytes int
+	// Duration is the latency of the handler, including the middlewares.
+	Duration time.Duration
+	// Code is the status code of the response, codes.OK on success.
+	Code codes.Code
+}
+
+// WithServerStats sets the callback called after each export request over HTTP, gRPC and WebSocket alike,
+// e.g. to expose the throughput metrics of a collector built on the mux. the callback is called synchronously, so keep it fast.
+func WithServerStats(callback func(ServerStat)) ServerMuxOption {
+	return func(mux *ServerMux) {
+		mux.onStat = callback
+	}
+}
+
+// beginServerStat returns the function to report the stat of the request at its end.
+func (mux *ServerMux) beginServerStat(signalType string, items int, req proto.Message) func(error) {
+	if mux.onStat == nil {
+		return func(error) {}
+	}
+	start := time.Now()
+	return func(err error) {
+		stat := ServerStat{
+			Signal:   signalType,
+			Items:    items,
+			Bytes:    proto.Size(req),
+			Duration: time.Since(start),
+			Code:     codes.OK,
+		}
+		if err != nil {
+			stat.Code = statusFromError(err).Code()
+		}
+		mux.onStat(stat)
+	}
+}
@@ -0,0 +1,54 @@
+package otlp_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServerMux_WithServerStats(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		stats []otlp.ServerStat
+	)
+	mux := otlp.NewServerMux(otlp.WithServerStats(func(stat otlp.ServerStat) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats = append(stats, stat)
+	}))
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	})
+	server := otlptest.NewHTTPServer(mux)
+	defer server.Close()
+	client, err := otlp.NewClient(server.URL, otlp.WithProtocol("http/protobuf"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, client.Start(ctx))
+	defer client.Stop(ctx)
+
+	spans := newSpans(3)
+	require.NoError(t, client.UploadTraces(ctx, spans))
+	require.Error(t, client.UploadLogs(ctx, nil))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, stats, 2)
+	require.Equal(t, "traces", stats[0].Signal)
+	require.Equal(t, 3, stats[0].Items)
+	require.Equal(t, proto.Size(&otlp.TraceRequest{ResourceSpans: spans}), stats[0].Bytes)
+	require.Positive(t, stats[0].Duration)
+	require.Equal(t, codes.OK, stats[0].Code)
+	require.Equal(t, "logs", stats[1].Signal)
+	require.Equal(t, codes.Unavailable, stats[1].Code)
+}
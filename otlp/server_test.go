@@ -0,0 +1,110 @@
+package otlp_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GRPCAndHTTPOnOnePort(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, req *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		started <- struct{}{}
+		if otlp.TotalSpans(req.GetResourceSpans()) > 1 {
+			<-release
+		}
+		return &otlp.TraceResponse{}, nil
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- (&otlp.Server{Mux: mux, ShutdownTimeout: 5 * time.Second}).Serve(ctx, l)
+	}()
+
+	endpoint := "http://" + l.Addr().String()
+	clients := map[string]*otlp.Client{}
+	for _, protocol := range []string{"grpc", "http/protobuf", "http/json"} {
+		client, err := otlp.NewClient(endpoint, otlp.WithProtocol(protocol))
+		require.NoError(t, err)
+		require.NoError(t, client.Start(context.Background()))
+		defer client.Stop(context.Background())
+		require.NoError(t, client.UploadTraces(context.Background(), newSpans(1)), protocol)
+		<-started
+		clients[protocol] = client
+	}
+
+	// the in-flight requests are drained on shutdown.
+	uploaded := make(chan error, 2)
+	for _, protocol := range []string{"grpc", "http/protobuf"} {
+		go func(client *otlp.Client) {
+			uploaded <- client.UploadTraces(context.Background(), newSpans(2))
+		}(clients[protocol])
+		<-started
+	}
+	cancel()
+	select {
+	case err := <-serveErr:
+		t.Fatalf("Serve returned before draining: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-uploaded)
+	require.NoError(t, <-uploaded)
+	require.NoError(t, <-serveErr)
+}
+
+func TestServer_ShutdownUnderLoad(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return &otlp.TraceResponse{}, nil
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- (&otlp.Server{Mux: mux, ShutdownTimeout: 5 * time.Second}).Serve(ctx, l)
+	}()
+
+	// the streams opened while shutting down are either drained or rejected with UNAVAILABLE, never lost by the wait of the in-flight requests.
+	var wg sync.WaitGroup
+	uploaded := make(chan struct{}, 8)
+	for i := 0; i < 8; i++ {
+		client, err := otlp.NewClient("http://"+l.Addr().String(), otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		require.NoError(t, client.Start(context.Background()))
+		defer client.Stop(context.Background())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				uploadCtx, uploadCancel := context.WithTimeout(context.Background(), time.Second)
+				err := client.UploadTraces(uploadCtx, newSpans(1))
+				uploadCancel()
+				if err != nil {
+					return
+				}
+				if j == 0 {
+					uploaded <- struct{}{}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		<-uploaded
+	}
+	cancel()
+	require.NoError(t, <-serveErr)
+	wg.Wait()
+}
@@ -0,0 +1,60 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterOnShutdown registers the hook called by Shutdown after the in-flight exports are drained, e.g. to flush the sinks of the handlers.
+// the hooks are called in order with the context of Shutdown.
+func (mux *ServerMux) RegisterOnShutdown(hook func(ctx context.Context) error) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.onShutdown = append(mux.onShutdown, hook)
+}
+
+// Shutdown stops accepting new exports, which are rejected with UNAVAILABLE so that the clients retry them elsewhere,
+// waits for the in-flight exports until ctx is done, then calls the hooks registered by RegisterOnShutdown and stops the mux, see Stop.
+// it returns the error of ctx if the exports are not drained in time, joined with the errors of the hooks.
+// it does not close the listeners; shut down the servers serving the mux too.
+func (mux *ServerMux) Shutdown(ctx context.Context) error {
+	mux.mu.Lock()
+	mux.draining = true
+	hooks := append([]func(context.Context) error{}, mux.onShutdown...)
+	mux.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		mux.exporting.Wait()
+		close(drained)
+	}()
+	var errs []error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := mux.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// enterExport counts the export in flight, or returns UNAVAILABLE if the mux is shutting down.
+func (mux *ServerMux) enterExport() error {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	if mux.draining {
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	mux.exporting.Add(1)
+	return nil
+}
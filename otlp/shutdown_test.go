@@ -0,0 +1,85 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServerMux_Shutdown(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var handled, hooked atomic.Int32
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		started <- struct{}{}
+		<-release
+		handled.Add(1)
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.RegisterOnShutdown(func(context.Context) error {
+		require.EqualValues(t, 1, handled.Load(), "the hooks are called after the in-flight exports are drained")
+		hooked.Add(1)
+		return nil
+	})
+	body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.NoError(t, err)
+	post := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+	inFlight := make(chan int, 1)
+	go func() {
+		inFlight <- post()
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- mux.Shutdown(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return post() == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before draining: %v", err)
+	default:
+	}
+	close(release)
+	require.Equal(t, http.StatusOK, <-inFlight)
+	require.NoError(t, <-shutdown)
+	require.EqualValues(t, 1, hooked.Load())
+}
+
+func TestServerMux_Shutdown_Deadline(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		close(started)
+		<-release
+		return &otlp.TraceResponse{}, nil
+	})
+	go func() {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader([]byte("{}")))
+		req.Header.Set("Content-Type", "application/json")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, mux.Shutdown(ctx), context.DeadlineExceeded)
+}
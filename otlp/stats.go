@@ -0,0 +1,230 @@
+package otlp
+
+import (
+	"bytes"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ExportStats is a snapshot of the export statistics of a signal.
+type ExportStats struct {
+	// Exports is the number of upload calls.
+	Exports int64 `json:"exports"`
+	// Failures is the number of upload calls that returned an error, after retries.
+	Failures int64 `json:"failures"`
+	// Canceled and DeadlineExceeded are the number of the failures caused by the cancellation and the deadline, see CanceledError.
+	Canceled         int64 `json:"canceled"`
+	DeadlineExceeded int64 `json:"deadline_exceeded"`
+	// Retries is the number of retried export attempts.
+	Retries int64 `json:"retries"`
+	// Rejected is the number of items rejected by the server with partial success responses.
+	Rejected int64 `json:"rejected"`
+	// ExportDuration is the total duration of the upload calls, including retries.
+	ExportDuration time.Duration `json:"export_duration_ns"`
+	// LastExportDuration is the duration of the last upload call.
+	LastExportDuration time.Duration `json:"last_export_duration_ns"`
+	// QueueDepth is the number of items of the upload calls in flight, including the ones waiting for the retries.
+	// the client does not buffer, so the uploads in flight are its queue.
+	QueueDepth int64 `json:"queue_depth"`
+	// Dropped is the number of items of the uploads aborted by Stop, see DroppedError.
+	Dropped int64 `json:"dropped"`
+}
+
+type signalStats struct {
+	exports            atomic.Int64
+	failures           atomic.Int64
+	canceled           atomic.Int64
+	deadlineExceeded   atomic.Int64
+	attempts           atomic.Int64
+	rejected           atomic.Int64
+	exportDuration     atomic.Int64
+	lastExportDuration atomic.Int64
+	queueDepth         atomic.Int64
+	dropped            atomic.Int64
+}
+
+func (s *signalStats) record(d time.Duration, err error, canceled *CanceledError) {
+	s.exports.Add(1)
+	if err != nil {
+		s.failures.Add(1)
+	}
+	switch {
+	case canceled == nil:
+	case canceled.Timeout():
+		s.deadlineExceeded.Add(1)
+	default:
+		s.canceled.Add(1)
+	}
+	s.exportDuration.Add(int64(d))
+	s.lastExportDuration.Store(int64(d))
+}
+
+func (s *signalStats) snapshot() ExportStats {
+	exports := s.exports.Load()
+	return ExportStats{
+		Exports:            exports,
+		Failures:           s.failures.Load(),
+		Canceled:           s.canceled.Load(),
+		DeadlineExceeded:   s.deadlineExceeded.Load(),
+		Retries:            max(s.attempts.Load()-exports, 0),
+		Rejected:           s.rejected.Load(),
+		ExportDuration:     time.Duration(s.exportDuration.Load()),
+		LastExportDuration: time.Duration(s.lastExportDuration.Load()),
+		QueueDepth:         s.queueDepth.Load(),
+		Dropped:            s.dropped.Load(),
+	}
+}
+
+var statsSignals = []string{"traces", "metrics", "logs", "profiles"}
+
+type clientStats struct {
+	traces   signalStats
+	metrics  signalStats
+	logs     signalStats
+	profiles signalStats
+}
+
+func (s *clientStats) signal(signalType string) *signalStats {
+	switch signalType {
+	case "traces":
+		return &s.traces
+	case "metrics":
+		return &s.metrics
+	case "profiles":
+		return &s.profiles
+	default:
+		return &s.logs
+	}
+}
+
+// Stats returns the export statistics per signal, keyed by "traces", "metrics", "logs" and "profiles".
+func (c *Client) Stats() map[string]ExportStats {
+	stats := make(map[string]ExportStats, len(statsSignals))
+	for _, signalType := range statsSignals {
+		stats[signalType] = c.stats.signal(signalType).snapshot()
+	}
+	return stats
+}
+
+// ExpvarFunc returns an expvar.Func that reports Stats, publish it with expvar.Publish under the name of your choice.
+// e.g. expvar.Publish("otlp_client", client.ExpvarFunc())
+func (c *Client) ExpvarFunc() expvar.Func {
+	return func() any {
+		return c.Stats()
+	}
+}
+
+// ExpvarMap returns an expvar.Map of Stats keyed by signal, each read when the map is reported.
+// it is the same as ExpvarFunc, but the signals are the entries of the map, e.g. for expvar.Get("otlp_client").(*expvar.Map).Get("traces").
+// the map is not published, publish it with expvar.Publish.
+func (c *Client) ExpvarMap() *expvar.Map {
+	m := new(expvar.Map).Init()
+	for _, signalType := range statsSignals {
+		m.Set(signalType, expvar.Func(func() any {
+			return c.stats.signal(signalType).snapshot()
+		}))
+	}
+	return m
+}
+
+var clientMetricFamilies = []struct {
+	name  string
+	typ   string
+	help  string
+	value func(ExportStats) float64
+}{
+	{
+		name:  "otlp_client_exports_total",
+		typ:   "counter",
+		help:  "The number of upload calls.",
+		value: func(s ExportStats) float64 { return float64(s.Exports) },
+	},
+	{
+		name:  "otlp_client_failures_total",
+		typ:   "counter",
+		help:  "The number of upload calls that returned an error, after retries.",
+		value: func(s ExportStats) float64 { return float64(s.Failures) },
+	},
+	{
+		name:  "otlp_client_canceled_total",
+		typ:   "counter",
+		help:  "The number of upload calls that failed because they were canceled.",
+		value: func(s ExportStats) float64 { return float64(s.Canceled) },
+	},
+	{
+		name:  "otlp_client_deadline_exceeded_total",
+		typ:   "counter",
+		help:  "The number of upload calls that failed because their deadline was exceeded.",
+		value: func(s ExportStats) float64 { return float64(s.DeadlineExceeded) },
+	},
+	{
+		name:  "otlp_client_retries_total",
+		typ:   "counter",
+		help:  "The number of retried export attempts.",
+		value: func(s ExportStats) float64 { return float64(s.Retries) },
+	},
+	{
+		name:  "otlp_client_rejected_total",
+		typ:   "counter",
+		help:  "The number of items rejected by the server with partial success responses.",
+		value: func(s ExportStats) float64 { return float64(s.Rejected) },
+	},
+	{
+		name:  "otlp_client_dropped_total",
+		typ:   "counter",
+		help:  "The number of items of the uploads aborted by Stop.",
+		value: func(s ExportStats) float64 { return float64(s.Dropped) },
+	},
+	{
+		name:  "otlp_client_queue_depth",
+		typ:   "gauge",
+		help:  "The number of items of the upload calls in flight.",
+		value: func(s ExportStats) float64 { return float64(s.QueueDepth) },
+	},
+	{
+		name:  "otlp_client_export_duration_seconds_total",
+		typ:   "counter",
+		help:  "The total duration of the upload calls, including retries.",
+		value: func(s ExportStats) float64 { return s.ExportDuration.Seconds() },
+	},
+	{
+		name:  "otlp_client_last_export_duration_seconds",
+		typ:   "gauge",
+		help:  "The duration of the last upload call.",
+		value: func(s ExportStats) float64 { return s.LastExportDuration.Seconds() },
+	},
+}
+
+// MetricsHandler returns an http.Handler that exposes Stats in the Prometheus text exposition format, labeled by signal.
+// it is the counterpart of ServerMux.MetricsHandler for the client, mount it at /metrics without a Prometheus client library.
+// for a prometheus.Collector, see the otlpprometheus module.
+func (c *Client) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var buf bytes.Buffer
+		c.writeMetrics(&buf)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			c.o.logger.DebugContext(r.Context(), "failed to write metrics", "details", err)
+		}
+	})
+}
+
+func (c *Client) writeMetrics(buf *bytes.Buffer) {
+	stats := c.Stats()
+	for _, family := range clientMetricFamilies {
+		fmt.Fprintf(buf, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.name, family.typ)
+		for _, signalType := range statsSignals {
+			fmt.Fprintf(buf, "%s{signal=%q} %s\n", family.name, signalType, strconv.FormatFloat(family.value(stats[signalType]), 'g', -1, 64))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type tailOptions struct {
+	pollInterval time.Duration
+}
+
+// TailOption is an option for TailFile.
+type TailOption func(*tailOptions)
+
+// WithTailPollInterval sets the interval to check the file for new lines and rotation. default is 1s.
+func WithTailPollInterval(d time.Duration) TailOption {
+	return func(o *tailOptions) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// TailFile follows a growing OTLP JSON lines file, e.g. written by the OpenTelemetry Collector fileexporter, and calls handler for each decoded request.
+// the file is read from the beginning, and reopened from the beginning when it is rotated (replaced or truncated); the file may not exist yet.
+// it blocks until ctx is done or handler returns an error, and returns the error.
+func TailFile(ctx context.Context, path string, handler func(context.Context, *FileExporterRecord) error, opts ...TailOption) error {
+	o := tailOptions{pollInterval: time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t := &fileTailer{path: path}
+	defer t.close()
+	for {
+		if err := t.readLines(ctx, handler); err != nil {
+			return err
+		}
+		timer := time.NewTimer(o.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type fileTailer struct {
+	path    string
+	f       *os.File
+	info    os.FileInfo
+	r       *bufio.Reader
+	offset  int64
+	pending []byte
+}
+
+func (t *fileTailer) close() {
+	if t.f != nil {
+		t.f.Close()
+		t.f = nil
+	}
+}
+
+func (t *fileTailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.f, t.info, t.r, t.offset, t.pending = f, info, bufio.NewReader(f), 0, nil
+	return nil
+}
+
+// rotated reports whether the file at the path is not the opened file anymore, or is truncated.
+func (t *fileTailer) rotated() bool {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	return !os.SameFile(info, t.info) || info.Size() < t.offset
+}
+
+// readLines reads the complete lines available now.
+func (t *fileTailer) readLines(ctx context.Context, handler func(context.Context, *FileExporterRecord) error) error {
+	if t.f == nil {
+		if err := t.open(); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+	for {
+		line, err := t.r.ReadBytes('\n')
+		t.offset += int64(len(line))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if errors.Is(err, io.EOF) {
+			t.pending = append(t.pending, line...)
+			if t.rotated() {
+				// the rest of the old file is already read, continue with the new file.
+				t.close()
+			}
+			return nil
+		}
+		if len(t.pending) > 0 {
+			line = append(t.pending, line...)
+			t.pending = nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		record, err := decodeFileExporterJSON(line)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.path, err)
+		}
+		if err := handler(ctx, record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package otlp_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "traces.json")
+	traceLine := `{"resourceSpans":[{"scopeSpans":[{"spans":[{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"span"}]}]}]}`
+	logsLine := `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"hello"}}]}]}]}`
+	require.NoError(t, os.WriteFile(path, []byte(traceLine+"\n"), 0o644))
+
+	var (
+		mu      sync.Mutex
+		records []*otlp.FileExporterRecord
+	)
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(records)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- otlp.TailFile(ctx, path, func(_ context.Context, record *otlp.FileExporterRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, record)
+			return nil
+		}, otlp.WithTailPollInterval(10*time.Millisecond))
+	}()
+	require.Eventually(t, func() bool { return count() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// a line written in two parts is handled once it is complete.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(logsLine[:10])
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 1, count())
+	_, err = f.WriteString(logsLine[10:] + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Eventually(t, func() bool { return count() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// rotation: the file is renamed and a new file is created.
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "traces-2024-01-01T00-00-00.000.json")))
+	require.NoError(t, os.WriteFile(path, []byte(traceLine+"\n"), 0o644))
+	require.Eventually(t, func() bool { return count() == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotNil(t, records[0].Traces)
+	require.NotNil(t, records[1].Logs)
+	require.NotNil(t, records[2].Traces)
+}
@@ -0,0 +1,132 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DestinationError is the error of a destination of TeeClient, returned as a part of MultiDestinationError.
+type DestinationError struct {
+	// Index is the index of the client passed to NewTeeClient.
+	Index int
+	Err   error
+}
+
+func (e *DestinationError) Error() string {
+	return fmt.Sprintf("destination %d: %v", e.Index, e.Err)
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// MultiDestinationError is the error of the destinations that failed, in index order.
+type MultiDestinationError struct {
+	Errors []*DestinationError
+}
+
+func (e *MultiDestinationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d destinations failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the destinations, errors.Is and errors.As look into them.
+func (e *MultiDestinationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Indexes returns the indexes of the failed destinations.
+func (e *MultiDestinationError) Indexes() []int {
+	indexes := make([]int, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		indexes = append(indexes, err.Index)
+	}
+	return indexes
+}
+
+// TeeClient uploads each request to several clients concurrently, e.g. to dual-write during a migration between backends.
+// a failure of a destination does not stop the others; *MultiDestinationError is returned for the failed destinations.
+type TeeClient struct {
+	clients []*Client
+}
+
+// NewTeeClient returns a new TeeClient uploading to the clients.
+// the requests are shared by the clients, so they must not be modified until the upload returns.
+func NewTeeClient(clients ...*Client) *TeeClient {
+	return &TeeClient{
+		clients: clients,
+	}
+}
+
+// Clients returns the clients of the destinations.
+func (t *TeeClient) Clients() []*Client {
+	return t.clients
+}
+
+func (t *TeeClient) each(ctx context.Context, f func(client *Client, ctx context.Context) error) error {
+	errs := make([]error, len(t.clients))
+	var wg sync.WaitGroup
+	for i, client := range t.clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = f(client, ctx)
+		}()
+	}
+	wg.Wait()
+	var destErrs []*DestinationError
+	for i, err := range errs {
+		if err != nil {
+			destErrs = append(destErrs, &DestinationError{Index: i, Err: err})
+		}
+	}
+	if len(destErrs) > 0 {
+		return &MultiDestinationError{Errors: destErrs}
+	}
+	return nil
+}
+
+// Start starts all clients.
+func (t *TeeClient) Start(ctx context.Context) error {
+	return t.each(ctx, (*Client).Start)
+}
+
+// Stop stops all clients.
+func (t *TeeClient) Stop(ctx context.Context) error {
+	return t.each(ctx, (*Client).Stop)
+}
+
+// ForceFlush waits for the uploads in flight of all clients.
+func (t *TeeClient) ForceFlush(ctx context.Context) error {
+	return t.each(ctx, (*Client).ForceFlush)
+}
+
+// UploadTraces uploads the spans to all destinations.
+func (t *TeeClient) UploadTraces(ctx context.Context, protoSpans []*ResourceSpans) error {
+	return t.each(ctx, func(client *Client, ctx context.Context) error {
+		return client.UploadTraces(ctx, protoSpans)
+	})
+}
+
+// UploadMetrics uploads the metrics to all destinations.
+func (t *TeeClient) UploadMetrics(ctx context.Context, protoMetrics []*ResourceMetrics) error {
+	return t.each(ctx, func(client *Client, ctx context.Context) error {
+		return client.UploadMetrics(ctx, protoMetrics)
+	})
+}
+
+// UploadLogs uploads the logs to all destinations.
+func (t *TeeClient) UploadLogs(ctx context.Context, protoLogs []*ResourceLogs) error {
+	return t.each(ctx, func(client *Client, ctx context.Context) error {
+		return client.UploadLogs(ctx, protoLogs)
+	})
+}
@@ -0,0 +1,103 @@
+package otlp
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TeePolicy is the error aggregation policy of the tee handlers.
+type TeePolicy int
+
+const (
+	// TeeAllMustSucceed fails the request if any of the handlers fails.
+	TeeAllMustSucceed TeePolicy = iota
+	// TeeBestEffort fails the request only if all of the handlers fail, e.g. for a secondary destination whose failures should not make the exporters retry.
+	TeeBestEffort
+)
+
+// TeeTraceHandlers returns a handler that calls the handlers concurrently per request with TeeAllMustSucceed, e.g. to dual-write to a file sink and a forwarder.
+// see TeePolicy.TraceHandlers.
+func TeeTraceHandlers(handlers ...TraceHandler) TraceHandler {
+	return TeeAllMustSucceed.TraceHandlers(handlers...)
+}
+
+// TeeMetricsHandlers returns a handler that calls the handlers concurrently per request with TeeAllMustSucceed.
+func TeeMetricsHandlers(handlers ...MetricsHandler) MetricsHandler {
+	return TeeAllMustSucceed.MetricsHandlers(handlers...)
+}
+
+// TeeLogsHandlers returns a handler that calls the handlers concurrently per request with TeeAllMustSucceed.
+func TeeLogsHandlers(handlers ...LogsHandler) LogsHandler {
+	return TeeAllMustSucceed.LogsHandlers(handlers...)
+}
+
+// TraceHandlers returns a handler that calls the handlers concurrently per request, and aggregates the errors with the policy into *MultiDestinationError,
+// whose indexes are of the handlers. the response is of the first handler that succeeds.
+// the request is shared by the handlers, so they must not modify it.
+func (p TeePolicy) TraceHandlers(handlers ...TraceHandler) TraceHandler {
+	return TraceHandlerFunc(func(ctx context.Context, request *TraceRequest) (*TraceResponse, error) {
+		return teeHandle(ctx, p, handlers, func(h TraceHandler, ctx context.Context) (*TraceResponse, error) {
+			return h.HandleTrace(ctx, request)
+		})
+	})
+}
+
+// MetricsHandlers returns a handler that calls the handlers concurrently per request, see TraceHandlers.
+func (p TeePolicy) MetricsHandlers(handlers ...MetricsHandler) MetricsHandler {
+	return MetricsHandlerFunc(func(ctx context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+		return teeHandle(ctx, p, handlers, func(h MetricsHandler, ctx context.Context) (*MetricsResponse, error) {
+			return h.HandleMetrics(ctx, request)
+		})
+	})
+}
+
+// LogsHandlers returns a handler that calls the handlers concurrently per request, see TraceHandlers.
+func (p TeePolicy) LogsHandlers(handlers ...LogsHandler) LogsHandler {
+	return LogsHandlerFunc(func(ctx context.Context, request *LogsRequest) (*LogsResponse, error) {
+		return teeHandle(ctx, p, handlers, func(h LogsHandler, ctx context.Context) (*LogsResponse, error) {
+			return h.HandleLogs(ctx, request)
+		})
+	})
+}
+
+func teeHandle[H any, Resp any](ctx context.Context, p TeePolicy, handlers []H, f func(H, context.Context) (Resp, error)) (Resp, error) {
+	resps := make([]Resp, len(handlers))
+	errs := make([]error, len(handlers))
+	var wg sync.WaitGroup
+	for i, h := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the panics of the goroutines are not recovered by the mux, so convert them here as recoverPanic does.
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = status.Errorf(codes.Internal, "handler panicked: %v", r)
+				}
+			}()
+			resps[i], errs[i] = f(h, ctx)
+		}()
+	}
+	wg.Wait()
+	var (
+		resp      Resp
+		succeeded bool
+		destErrs  []*DestinationError
+	)
+	for i, err := range errs {
+		if err != nil {
+			destErrs = append(destErrs, &DestinationError{Index: i, Err: err})
+			continue
+		}
+		if !succeeded {
+			resp, succeeded = resps[i], true
+		}
+	}
+	if len(destErrs) == 0 || (p == TeeBestEffort && succeeded) {
+		return resp, nil
+	}
+	var zero Resp
+	return zero, &MultiDestinationError{Errors: destErrs}
+}
@@ -0,0 +1,69 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTeeTraceHandlers(t *testing.T) {
+	var calls atomic.Int32
+	ok := otlp.TraceHandlerFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		calls.Add(1)
+		return otlp.NewTracePartialSuccess(1, "first"), nil
+	})
+	failed := otlp.TraceHandlerFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		calls.Add(1)
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	ctx := context.Background()
+	req := &otlp.TraceRequest{ResourceSpans: newSpans(1)}
+
+	resp, err := otlp.TeeTraceHandlers(ok, ok).HandleTrace(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.GetPartialSuccess().GetErrorMessage())
+	require.EqualValues(t, 2, calls.Load())
+
+	_, err = otlp.TeeTraceHandlers(ok, failed).HandleTrace(ctx, req)
+	var multiErr *otlp.MultiDestinationError
+	require.ErrorAs(t, err, &multiErr)
+	require.Equal(t, []int{1}, multiErr.Indexes())
+	require.Equal(t, codes.Unavailable, status.Code(err), "the status of the handler is kept")
+
+	resp, err = otlp.TeeBestEffort.TraceHandlers(failed, ok).HandleTrace(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "first", resp.GetPartialSuccess().GetErrorMessage())
+
+	_, err = otlp.TeeBestEffort.TraceHandlers(failed, failed).HandleTrace(ctx, req)
+	require.ErrorAs(t, err, &multiErr)
+	require.Equal(t, []int{0, 1}, multiErr.Indexes())
+
+	panicked := otlp.TraceHandlerFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		panic("boom")
+	})
+	_, err = otlp.TeeTraceHandlers(ok, panicked).HandleTrace(ctx, req)
+	require.ErrorAs(t, err, &multiErr)
+	require.Equal(t, []int{1}, multiErr.Indexes())
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.ErrorContains(t, err, "boom")
+}
+
+func TestTeeLogsHandlers(t *testing.T) {
+	sinkErr := errors.New("sink")
+	h := otlp.TeeLogsHandlers(
+		otlp.LogsHandlerFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+			return &otlp.LogsResponse{}, nil
+		}),
+		otlp.LogsHandlerFunc(func(_ context.Context, _ *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+			return nil, sinkErr
+		}),
+	)
+	_, err := h.HandleLogs(context.Background(), &otlp.LogsRequest{})
+	require.ErrorIs(t, err, sinkErr)
+}
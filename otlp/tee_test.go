@@ -0,0 +1,58 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeeClient(t *testing.T) {
+	var calls [3]atomic.Int32
+	newServer := func(i int, code int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				calls[i].Add(1)
+				if code != http.StatusOK {
+					w.WriteHeader(code)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("{}"))
+			},
+		))
+		t.Cleanup(server.Close)
+		return server
+	}
+	clients := make([]*otlp.Client, 0, 3)
+	for i, code := range []int{http.StatusOK, http.StatusBadRequest, http.StatusOK} {
+		client, err := otlp.NewClient(newServer(i, code).URL, otlp.WithProtocol("http/json"))
+		require.NoError(t, err)
+		clients = append(clients, client)
+	}
+	tee := otlp.NewTeeClient(clients...)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, tee.Start(ctx))
+	defer tee.Stop(ctx)
+
+	err := tee.UploadTraces(ctx, newSpans(1))
+	var multiErr *otlp.MultiDestinationError
+	require.ErrorAs(t, err, &multiErr)
+	require.Equal(t, []int{1}, multiErr.Indexes())
+	var statusErr *otlp.HTTPStatusError
+	require.True(t, errors.As(err, &statusErr))
+	require.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	for i := range calls {
+		require.EqualValues(t, 1, calls[i].Load())
+	}
+
+	require.Error(t, tee.UploadLogs(ctx, []*otlp.ResourceLogs{}))
+	require.NoError(t, tee.ForceFlush(ctx))
+}
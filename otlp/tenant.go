@@ -0,0 +1,85 @@
+package otlp
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// TenantExtractor derives the tenant id of the request from the context of the handlers. it returns false if the request has no tenant.
+type TenantExtractor func(ctx context.Context) (string, bool)
+
+// TenantFromHeader extracts the tenant id from the header, e.g. "X-Scope-OrgID", over gRPC and HTTP alike.
+func TenantFromHeader(name string) TenantExtractor {
+	return func(ctx context.Context) (string, bool) {
+		headers, _ := HeadersFromContext(ctx)
+		tenant := headers.Get(name)
+		return tenant, tenant != ""
+	}
+}
+
+// TenantFromClientCertificate extracts the tenant id from the common name of the verified client certificate of mTLS, see PeerCertificatesFromContext.
+func TenantFromClientCertificate() TenantExtractor {
+	return func(ctx context.Context) (string, bool) {
+		chain, ok := PeerCertificatesFromContext(ctx)
+		if !ok || chain[0].Subject.CommonName == "" {
+			return "", false
+		}
+		return chain[0].Subject.CommonName, true
+	}
+}
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant id stored by TenantMiddleware.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+type tenantOptions struct {
+	router func(ctx context.Context, tenant string) (ProtoHandlerFunc, error)
+}
+
+// TenantOption is the option for TenantMiddleware.
+type TenantOption func(*tenantOptions)
+
+// WithTenantRouter routes the requests to the handler returned by router for the tenant, instead of the handlers of the mux,
+// e.g. the Export of a ForwardHandler per tenant from a registry. router returning nil passes the request to the handlers of the mux,
+// and its error is returned to the exporter as is, e.g. PERMISSION_DENIED for the unknown tenants.
+func WithTenantRouter(router func(ctx context.Context, tenant string) (ProtoHandlerFunc, error)) TenantOption {
+	return func(o *tenantOptions) {
+		o.router = router
+	}
+}
+
+// TenantMiddleware returns a middleware that derives the tenant id of each request with extract, and stores it in the context, see TenantFromContext.
+// the requests without the tenant are rejected with UNAUTHENTICATED.
+func TenantMiddleware(extract TenantExtractor, opts ...TenantOption) MiddlewareFunc {
+	o := tenantOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			tenant, ok := extract(ctx)
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "tenant is required")
+			}
+			ctx = context.WithValue(ctx, tenantKey{}, tenant)
+			if o.router == nil {
+				return next(ctx, req)
+			}
+			handler, err := o.router(ctx, tenant)
+			if err != nil {
+				return nil, err
+			}
+			if handler == nil {
+				return next(ctx, req)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	tenants := make(chan string, 1)
+	routed := make(chan string, 1)
+	mux := otlp.NewServerMux()
+	mux.Use(otlp.TenantMiddleware(
+		otlp.TenantFromHeader("X-Scope-OrgID"),
+		otlp.WithTenantRouter(func(_ context.Context, tenant string) (otlp.ProtoHandlerFunc, error) {
+			switch tenant {
+			case "routed":
+				return func(ctx context.Context, _ proto.Message) (proto.Message, error) {
+					tenant, _ := otlp.TenantFromContext(ctx)
+					routed <- tenant
+					return &otlp.TraceResponse{}, nil
+				}, nil
+			case "default":
+				return nil, nil
+			}
+			return nil, status.Errorf(codes.PermissionDenied, "unknown tenant %q", tenant)
+		}),
+	))
+	mux.Trace().HandleFunc(func(ctx context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		tenant, ok := otlp.TenantFromContext(ctx)
+		require.True(t, ok)
+		tenants <- tenant
+		return &otlp.TraceResponse{}, nil
+	})
+	body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+	require.NoError(t, err)
+	post := func(tenant string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		if tenant != "" {
+			req.Header.Set("X-Scope-OrgID", tenant)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, post("default"))
+	require.Equal(t, "default", <-tenants)
+	require.Equal(t, http.StatusOK, post("routed"))
+	require.Equal(t, "routed", <-routed)
+	require.Empty(t, tenants, "the routed request does not reach the handler of the mux")
+	require.Equal(t, http.StatusForbidden, post("unknown"))
+	require.Equal(t, http.StatusUnauthorized, post(""))
+}
+
+func TestTenantFromClientCertificate(t *testing.T) {
+	extract := otlp.TenantFromClientCertificate()
+	_, ok := extract(context.Background())
+	require.False(t, ok)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "tenant-1"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+	tenant, ok := extract(ctx)
+	require.True(t, ok)
+	require.Equal(t, "tenant-1", tenant)
+}
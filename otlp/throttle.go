@@ -0,0 +1,25 @@
+package otlp
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ThrottleError returns RESOURCE_EXHAUSTED with RetryInfo of d for the handlers to throttle the exporters as OTLP specification describes.
+// it is sent as is over gRPC, and as 429 Too Many Requests with Retry-After in seconds rounded up and the status details over HTTP.
+func ThrottleError(d time.Duration) error {
+	return throttleStatus(fmt.Sprintf("throttled, retry after %s", d), d).Err()
+}
+
+func throttleStatus(message string, d time.Duration) *status.Status {
+	st := status.New(codes.ResourceExhausted, message)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d)}); err == nil {
+		st = detailed
+	}
+	return st
+}
@@ -0,0 +1,64 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/mashiike/go-otlp-helper/otlp/otlptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestThrottleError(t *testing.T) {
+	mux := otlp.NewServerMux()
+	mux.Trace().HandleFunc(func(_ context.Context, _ *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		return nil, otlp.ThrottleError(1500 * time.Millisecond)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		body, err := proto.Marshal(&otlp.TraceRequest{ResourceSpans: newSpans(1)})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "2", w.Header().Get("Retry-After"))
+		var st spb.Status
+		require.NoError(t, proto.Unmarshal(w.Body.Bytes(), &st))
+		require.EqualValues(t, codes.ResourceExhausted, st.GetCode())
+		require.Len(t, st.GetDetails(), 2)
+		var ri errdetails.RetryInfo
+		require.NoError(t, st.GetDetails()[0].UnmarshalTo(&ri))
+		require.Equal(t, 1500*time.Millisecond, ri.GetRetryDelay().AsDuration())
+		var reqInfo errdetails.RequestInfo
+		require.NoError(t, st.GetDetails()[1].UnmarshalTo(&reqInfo))
+		require.Equal(t, w.Header().Get(otlp.RequestIDHeader), reqInfo.GetRequestId())
+	})
+	t.Run("grpc", func(t *testing.T) {
+		server := otlptest.NewServer(mux)
+		defer server.Close()
+		client, err := otlp.NewClient(server.URL, otlp.WithProtocol("grpc"))
+		require.NoError(t, err)
+		ctx := context.Background()
+		require.NoError(t, client.Start(ctx))
+		defer client.Stop(ctx)
+		err = client.UploadTraces(ctx, newSpans(1))
+		st, ok := status.FromError(err)
+		require.True(t, ok)
+		require.Equal(t, codes.ResourceExhausted, st.Code())
+		require.Len(t, st.Details(), 2)
+		ri, ok := st.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		require.Equal(t, 1500*time.Millisecond, ri.GetRetryDelay().AsDuration())
+	})
+}
@@ -0,0 +1,96 @@
+package otlp
+
+import (
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	// SpanTransformer is a function that mutates a Span in place.
+	SpanTransformer func(*resourcepb.Resource, *commonpb.InstrumentationScope, *tracepb.Span)
+	// MetricTransformer is a function that mutates a Metric in place.
+	MetricTransformer func(*resourcepb.Resource, *commonpb.InstrumentationScope, *metricspb.Metric)
+	// LogRecordTransformer is a function that mutates a LogRecord in place.
+	LogRecordTransformer func(*resourcepb.Resource, *commonpb.InstrumentationScope, *logspb.LogRecord)
+)
+
+// TransformResourceSpans applies the transformers in order to each span of the clone of the given ResourceSpans slice, and returns the clone,
+// e.g. for the processors enriching or renaming the attributes. src is not modified, so it is safe to transform the data shared with the others,
+// unlike the Transform of TraceEntry, which mutates the requests in place.
+// the resource and the scope are shared by the spans of the same ResourceSpans and ScopeSpans, so their modifications should be idempotent,
+// e.g. setting an attribute only if it is not set yet.
+func TransformResourceSpans(src []*tracepb.ResourceSpans, transformers ...SpanTransformer) []*tracepb.ResourceSpans {
+	dst := cloneMessages(src)
+	transformResourceSpansInPlace(dst, transformers...)
+	return dst
+}
+
+// TransformResourceMetrics applies the transformers in order to each metric of the clone of the given ResourceMetrics slice, and returns the clone.
+// see TransformResourceSpans for the details.
+func TransformResourceMetrics(src []*metricspb.ResourceMetrics, transformers ...MetricTransformer) []*metricspb.ResourceMetrics {
+	dst := cloneMessages(src)
+	transformResourceMetricsInPlace(dst, transformers...)
+	return dst
+}
+
+// TransformResourceLogs applies the transformers in order to each log record of the clone of the given ResourceLogs slice, and returns the clone.
+// see TransformResourceSpans for the details.
+func TransformResourceLogs(src []*logspb.ResourceLogs, transformers ...LogRecordTransformer) []*logspb.ResourceLogs {
+	dst := cloneMessages(src)
+	transformResourceLogsInPlace(dst, transformers...)
+	return dst
+}
+
+func cloneMessages[T proto.Message](src []T) []T {
+	dst := make([]T, 0, len(src))
+	for _, elem := range src {
+		dst = append(dst, proto.Clone(elem).(T))
+	}
+	return dst
+}
+
+func transformResourceSpansInPlace(src []*tracepb.ResourceSpans, transformers ...SpanTransformer) {
+	for _, elem := range src {
+		resource := elem.GetResource()
+		for _, elemScopeSpan := range elem.GetScopeSpans() {
+			scope := elemScopeSpan.GetScope()
+			for _, elemSpan := range elemScopeSpan.GetSpans() {
+				for _, t := range transformers {
+					t(resource, scope, elemSpan)
+				}
+			}
+		}
+	}
+}
+
+func transformResourceMetricsInPlace(src []*metricspb.ResourceMetrics, transformers ...MetricTransformer) {
+	for _, elem := range src {
+		resource := elem.GetResource()
+		for _, elemScopeMetric := range elem.GetScopeMetrics() {
+			scope := elemScopeMetric.GetScope()
+			for _, elemMetric := range elemScopeMetric.GetMetrics() {
+				for _, t := range transformers {
+					t(resource, scope, elemMetric)
+				}
+			}
+		}
+	}
+}
+
+func transformResourceLogsInPlace(src []*logspb.ResourceLogs, transformers ...LogRecordTransformer) {
+	for _, elem := range src {
+		resource := elem.GetResource()
+		for _, elemScopeLogs := range elem.GetScopeLogs() {
+			scope := elemScopeLogs.GetScope()
+			for _, elemLogRecord := range elemScopeLogs.GetLogRecords() {
+				for _, t := range transformers {
+					t(resource, scope, elemLogRecord)
+				}
+			}
+		}
+	}
+}
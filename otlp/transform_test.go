@@ -0,0 +1,113 @@
+package otlp_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTransformResourceSpans(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_trace.json")
+	require.NoError(t, err)
+	var data tracepb.TracesData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	original := proto.Clone(&data).(*tracepb.TracesData)
+
+	var calls int
+	transformed := otlp.TransformResourceSpans(data.GetResourceSpans(), func(resource *resourcepb.Resource, scope *commonpb.InstrumentationScope, span *tracepb.Span) {
+		calls++
+		if _, ok := otlp.ResourceAttribute(resource, "deployment.environment"); !ok {
+			resource.Attributes = append(resource.Attributes, &commonpb.KeyValue{
+				Key:   "deployment.environment",
+				Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "production"}},
+			})
+		}
+		span.Name = strings.ToUpper(span.GetName())
+	})
+	require.Equal(t, otlp.TotalSpans(data.GetResourceSpans()), calls)
+	assertEqualMessage(t, original, &data)
+
+	require.Len(t, transformed, len(data.GetResourceSpans()))
+	for _, elem := range transformed {
+		value, ok := otlp.ResourceAttribute(elem.GetResource(), "deployment.environment")
+		require.True(t, ok)
+		require.Equal(t, "production", value)
+		require.Len(t, elem.GetResource().GetAttributes(), len(original.GetResourceSpans()[0].GetResource().GetAttributes())+1)
+		for _, scopeSpans := range elem.GetScopeSpans() {
+			for _, span := range scopeSpans.GetSpans() {
+				require.Equal(t, strings.ToUpper(span.GetName()), span.GetName())
+			}
+		}
+	}
+}
+
+func TestTransformResourceMetrics(t *testing.T) {
+	bs, err := os.ReadFile("testdata/batched_metrics.json")
+	require.NoError(t, err)
+	var data metricspb.MetricsData
+	require.NoError(t, otlp.UnmarshalJSON(bs, &data))
+	original := proto.Clone(&data).(*metricspb.MetricsData)
+
+	transformed := otlp.TransformResourceMetrics(data.GetResourceMetrics(), func(_ *resourcepb.Resource, scope *commonpb.InstrumentationScope, metric *metricspb.Metric) {
+		metric.Name = scope.GetName() + "." + metric.GetName()
+	})
+	assertEqualMessage(t, original, &data)
+	require.Equal(t, otlp.TotalDataPoints(data.GetResourceMetrics()), otlp.TotalDataPoints(transformed))
+	for i, elem := range transformed {
+		for j, scopeMetrics := range elem.GetScopeMetrics() {
+			for k, metric := range scopeMetrics.GetMetrics() {
+				originalMetric := original.GetResourceMetrics()[i].GetScopeMetrics()[j].GetMetrics()[k]
+				require.Equal(t, scopeMetrics.GetScope().GetName()+"."+originalMetric.GetName(), metric.GetName())
+			}
+		}
+	}
+}
+
+func TestTransformResourceLogs(t *testing.T) {
+	src := []*logspb.ResourceLogs{
+		{
+			ScopeLogs: []*logspb.ScopeLogs{
+				{
+					LogRecords: []*logspb.LogRecord{
+						{
+							SeverityText: "warn",
+							Attributes: []*commonpb.KeyValue{
+								{Key: "userId", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "u-1"}}},
+							},
+						},
+						{SeverityText: "info"},
+					},
+				},
+			},
+		},
+		nil,
+	}
+	transformed := otlp.TransformResourceLogs(src, func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, record *logspb.LogRecord) {
+		record.SeverityText = strings.ToUpper(record.GetSeverityText())
+		for _, attr := range record.GetAttributes() {
+			if attr.GetKey() == "userId" {
+				attr.Key = "user.id"
+			}
+		}
+	}, func(_ *resourcepb.Resource, _ *commonpb.InstrumentationScope, record *logspb.LogRecord) {
+		record.SeverityText = "[" + record.GetSeverityText() + "]"
+	})
+	require.Equal(t, "warn", src[0].GetScopeLogs()[0].GetLogRecords()[0].GetSeverityText())
+	require.Equal(t, "userId", src[0].GetScopeLogs()[0].GetLogRecords()[0].GetAttributes()[0].GetKey())
+
+	records := transformed[0].GetScopeLogs()[0].GetLogRecords()
+	require.Equal(t, "[WARN]", records[0].GetSeverityText())
+	require.Equal(t, "user.id", records[0].GetAttributes()[0].GetKey())
+	require.Equal(t, "[INFO]", records[1].GetSeverityText())
+	require.Len(t, transformed, 2)
+	require.Nil(t, transformed[1])
+}
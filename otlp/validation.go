@@ -0,0 +1,218 @@
+package otlp
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxValidationMessages is the number of the invalid items described in the error message of ValidationMiddleware.
+const maxValidationMessages = 5
+
+// ValidationRules is the rules of ValidationMiddleware.
+type ValidationRules struct {
+	// SpanIDs requires the trace ids of 16 bytes and the span ids of 8 bytes, not all zero.
+	SpanIDs bool
+	// SpanTimes requires the end time of the spans not before the start time.
+	SpanTimes bool
+	// RequiredResourceAttributes is the resource attributes required for all the signals, e.g. service.name.
+	RequiredResourceAttributes []string
+	// PartialReject drops the invalid items and passes the rest to the handler, responding with a partial success that reports them.
+	// by default, a request with any invalid item is rejected with INVALID_ARGUMENT.
+	PartialReject bool
+}
+
+// ValidationMiddleware returns a middleware that validates the items of the export requests by the rules,
+// with the error message describing the first invalid items.
+func ValidationMiddleware(rules ValidationRules) MiddlewareFunc {
+	return func(next ProtoHandlerFunc) ProtoHandlerFunc {
+		return func(ctx context.Context, req proto.Message) (proto.Message, error) {
+			var v validation
+			switch req := req.(type) {
+			case *TraceRequest:
+				req.ResourceSpans = rules.validateResourceSpans(&v, req.GetResourceSpans())
+			case *MetricsRequest:
+				req.ResourceMetrics = rules.validateResourceMetrics(&v, req.GetResourceMetrics())
+			case *LogsRequest:
+				req.ResourceLogs = rules.validateResourceLogs(&v, req.GetResourceLogs())
+			}
+			if v.invalid == 0 {
+				return next(ctx, req)
+			}
+			message := v.message()
+			if !rules.PartialReject {
+				return nil, status.Error(codes.InvalidArgument, message)
+			}
+			_, remaining := requestItems(req)
+			if remaining == 0 {
+				return partialSuccessResponse(req, v.invalid, message), nil
+			}
+			resp, err := next(ctx, req)
+			if err != nil {
+				return resp, err
+			}
+			return addRejected(resp, v.invalid, message), nil
+		}
+	}
+}
+
+type validation struct {
+	invalid  int64
+	messages []string
+}
+
+func (v *validation) reject(n int, format string, args ...any) {
+	v.invalid += int64(n)
+	if len(v.messages) < maxValidationMessages {
+		v.messages = append(v.messages, fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validation) message() string {
+	message := fmt.Sprintf("%d invalid items: %s", v.invalid, strings.Join(v.messages, "; "))
+	if more := v.invalid - int64(len(v.messages)); more > 0 && len(v.messages) == maxValidationMessages {
+		message += fmt.Sprintf("; and %d more", more)
+	}
+	return message
+}
+
+// missingResourceAttribute returns the first required attribute missing in the resource.
+func (rules ValidationRules) missingResourceAttribute(resource *resourcepb.Resource) (string, bool) {
+	for _, key := range rules.RequiredResourceAttributes {
+		if !hasAttribute(resource.GetAttributes(), key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func hasAttribute(attrs []*commonpb.KeyValue, key string) bool {
+	for _, attr := range attrs {
+		if attr.GetKey() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (rules ValidationRules) validateResourceSpans(v *validation, src []*tracepb.ResourceSpans) []*tracepb.ResourceSpans {
+	dst := src[:0]
+	for _, elem := range src {
+		if key, ok := rules.missingResourceAttribute(elem.GetResource()); ok {
+			v.reject(TotalSpans([]*tracepb.ResourceSpans{elem}), "resource attribute %q is required", key)
+			continue
+		}
+		scopeSpans := elem.GetScopeSpans()[:0]
+		for _, elemScopeSpans := range elem.GetScopeSpans() {
+			spans := elemScopeSpans.GetSpans()[:0]
+			for _, span := range elemScopeSpans.GetSpans() {
+				if reason, ok := rules.invalidSpan(span); ok {
+					v.reject(1, "span %s: %s", hex.EncodeToString(span.GetSpanId()), reason)
+					continue
+				}
+				spans = append(spans, span)
+			}
+			elemScopeSpans.Spans = spans
+			if len(spans) > 0 {
+				scopeSpans = append(scopeSpans, elemScopeSpans)
+			}
+		}
+		elem.ScopeSpans = scopeSpans
+		if len(scopeSpans) > 0 {
+			dst = append(dst, elem)
+		}
+	}
+	return dst
+}
+
+func (rules ValidationRules) invalidSpan(span *tracepb.Span) (string, bool) {
+	if rules.SpanIDs {
+		if !validID(span.GetTraceId(), 16) {
+			return "invalid trace id", true
+		}
+		if !validID(span.GetSpanId(), 8) {
+			return "invalid span id", true
+		}
+	}
+	if rules.SpanTimes && span.GetEndTimeUnixNano() < span.GetStartTimeUnixNano() {
+		return "end time is before start time", true
+	}
+	return "", false
+}
+
+func validID(id []byte, size int) bool {
+	if len(id) != size {
+		return false
+	}
+	for _, b := range id {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (rules ValidationRules) validateResourceMetrics(v *validation, src []*metricspb.ResourceMetrics) []*metricspb.ResourceMetrics {
+	dst := src[:0]
+	for _, elem := range src {
+		if key, ok := rules.missingResourceAttribute(elem.GetResource()); ok {
+			v.reject(TotalDataPoints([]*metricspb.ResourceMetrics{elem}), "resource attribute %q is required", key)
+			continue
+		}
+		dst = append(dst, elem)
+	}
+	return dst
+}
+
+func (rules ValidationRules) validateResourceLogs(v *validation, src []*logspb.ResourceLogs) []*logspb.ResourceLogs {
+	dst := src[:0]
+	for _, elem := range src {
+		if key, ok := rules.missingResourceAttribute(elem.GetResource()); ok {
+			v.reject(TotalLogRecords([]*logspb.ResourceLogs{elem}), "resource attribute %q is required", key)
+			continue
+		}
+		dst = append(dst, elem)
+	}
+	return dst
+}
+
+// addRejected adds the rejected items to the partial success of the response.
+func addRejected(resp proto.Message, rejected int64, message string) proto.Message {
+	join := func(a, b string) string {
+		if a == "" {
+			return b
+		}
+		return a + "; " + b
+	}
+	switch resp := resp.(type) {
+	case *TraceResponse:
+		ps := NewTracePartialSuccess(resp.GetPartialSuccess().GetRejectedSpans()+rejected, join(resp.GetPartialSuccess().GetErrorMessage(), message))
+		if resp == nil {
+			return ps
+		}
+		resp.PartialSuccess = ps.PartialSuccess
+	case *MetricsResponse:
+		ps := NewMetricsPartialSuccess(resp.GetPartialSuccess().GetRejectedDataPoints()+rejected, join(resp.GetPartialSuccess().GetErrorMessage(), message))
+		if resp == nil {
+			return ps
+		}
+		resp.PartialSuccess = ps.PartialSuccess
+	case *LogsResponse:
+		ps := NewLogsPartialSuccess(resp.GetPartialSuccess().GetRejectedLogRecords()+rejected, join(resp.GetPartialSuccess().GetErrorMessage(), message))
+		if resp == nil {
+			return ps
+		}
+		resp.PartialSuccess = ps.PartialSuccess
+	}
+	return resp
+}
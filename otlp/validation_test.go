@@ -0,0 +1,70 @@
+package otlp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/require"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValidationMiddleware(t *testing.T) {
+	newRequest := func() *otlp.TraceRequest {
+		spans := newSpans(4)
+		spans[0].Resource = &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{
+				{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "test"}}},
+			},
+		}
+		s := spans[0].ScopeSpans[0].Spans
+		s[1].TraceId = make([]byte, 16)
+		s[2].StartTimeUnixNano, s[2].EndTimeUnixNano = 2, 1
+		return &otlp.TraceRequest{ResourceSpans: spans}
+	}
+	rules := otlp.ValidationRules{SpanIDs: true, SpanTimes: true, RequiredResourceAttributes: []string{"service.name"}}
+	var received *otlp.TraceRequest
+	handler := func(_ context.Context, req proto.Message) (proto.Message, error) {
+		received = req.(*otlp.TraceRequest)
+		return &otlp.TraceResponse{}, nil
+	}
+	logsHandler := func(_ context.Context, _ proto.Message) (proto.Message, error) {
+		t.Fatal("the handler is not called when all the items are rejected")
+		return nil, nil
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		received = nil
+		_, err := otlp.ValidationMiddleware(rules)(handler)(context.Background(), newRequest())
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Contains(t, err.Error(), "2 invalid items")
+		require.Contains(t, err.Error(), "invalid trace id")
+		require.Contains(t, err.Error(), "end time is before start time")
+		require.Nil(t, received)
+	})
+	t.Run("partial reject", func(t *testing.T) {
+		rules := rules
+		rules.PartialReject = true
+		resp, err := otlp.ValidationMiddleware(rules)(handler)(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.EqualValues(t, 2, resp.(*otlp.TraceResponse).GetPartialSuccess().GetRejectedSpans())
+		require.Equal(t, 2, otlp.TotalSpans(received.GetResourceSpans()))
+	})
+	t.Run("required resource attributes", func(t *testing.T) {
+		rules := otlp.ValidationRules{RequiredResourceAttributes: []string{"service.name"}, PartialReject: true}
+		logs := &otlp.LogsRequest{
+			ResourceLogs: []*otlp.ResourceLogs{
+				{ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{{}, {}, {}}}}},
+			},
+		}
+		resp, err := otlp.ValidationMiddleware(rules)(logsHandler)(context.Background(), logs)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, resp.(*otlp.LogsResponse).GetPartialSuccess().GetRejectedLogRecords())
+		require.Contains(t, resp.(*otlp.LogsResponse).GetPartialSuccess().GetErrorMessage(), `resource attribute "service.name" is required`)
+	})
+}
@@ -0,0 +1,249 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The WebSocket transport is experimental.
+// each binary message from the client is a 1 byte signal type followed by the protobuf encoded export request,
+// each binary message from the server is a 1 byte result followed by the protobuf encoded export response or google.rpc.Status.
+// requests on a connection are processed one at a time.
+const (
+	webSocketPath = "v1/ws"
+
+	wsSignalTraces  byte = 1
+	wsSignalMetrics byte = 2
+	wsSignalLogs    byte = 3
+
+	wsResultOK    byte = 0
+	wsResultError byte = 1
+
+	wsReadLimit = 64 << 20
+)
+
+// WebSocketHandler returns an http.Handler that accepts OTLP export requests over WebSocket, sent by the client with WithProtocol("ws").
+// the client connects to /v1/ws of the endpoint, so mount the handler there. the headers of the upgrade request are available with HeadersFromContext.
+// this transport is experimental, for environments where only HTTP/1.1 websockets pass through middleboxes.
+func (mux *ServerMux) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			mux.getLogger().DebugContext(r.Context(), "failed to accept websocket", "details", err)
+			return
+		}
+		defer conn.CloseNow()
+		conn.SetReadLimit(wsReadLimit)
+		ctx := incomingContext(r, "websocket")
+		for {
+			typ, data, err := conn.Read(ctx)
+			if err != nil {
+				if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+					mux.getLogger().DebugContext(ctx, "failed to read websocket message", "details", err)
+				}
+				return
+			}
+			if typ != websocket.MessageBinary {
+				conn.Close(websocket.StatusUnsupportedData, "binary message is required")
+				return
+			}
+			if err := conn.Write(ctx, websocket.MessageBinary, mux.serveWebSocketMessage(ctx, data)); err != nil {
+				mux.getLogger().DebugContext(ctx, "failed to write websocket message", "details", err)
+				return
+			}
+		}
+	})
+}
+
+func (mux *ServerMux) serveWebSocketMessage(ctx context.Context, data []byte) []byte {
+	resp, err := mux.exportWebSocketMessage(ctx, data)
+	if err != nil {
+		bs, marshalErr := proto.Marshal(status.Convert(err).Proto())
+		if marshalErr != nil {
+			bs, _ = proto.Marshal(status.New(codes.Internal, marshalErr.Error()).Proto())
+		}
+		return append([]byte{wsResultError}, bs...)
+	}
+	bs, err := proto.Marshal(resp)
+	if err != nil {
+		bs, _ = proto.Marshal(status.New(codes.Internal, err.Error()).Proto())
+		return append([]byte{wsResultError}, bs...)
+	}
+	return append([]byte{wsResultOK}, bs...)
+}
+
+func (mux *ServerMux) exportWebSocketMessage(ctx context.Context, data []byte) (proto.Message, error) {
+	if len(data) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty message")
+	}
+	switch data[0] {
+	case wsSignalTraces:
+		entry, ok := mux.getTraceEntry()
+		if !ok {
+			return nil, status.Error(codes.Unimplemented, "no handler registered for traces")
+		}
+		req := &TraceRequest{}
+		if err := proto.Unmarshal(data[1:], req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return entry.Export(ctx, req)
+	case wsSignalMetrics:
+		entry, ok := mux.getMetricsEntry()
+		if !ok {
+			return nil, status.Error(codes.Unimplemented, "no handler registered for metrics")
+		}
+		req := &MetricsRequest{}
+		if err := proto.Unmarshal(data[1:], req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return entry.Export(ctx, req)
+	case wsSignalLogs:
+		entry, ok := mux.getLogsEntry()
+		if !ok {
+			return nil, status.Error(codes.Unimplemented, "no handler registered for logs")
+		}
+		req := &LogsRequest{}
+		if err := proto.Unmarshal(data[1:], req); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return entry.Export(ctx, req)
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unknown signal type %d", data[0])
+}
+
+type wsClientConn struct {
+	mu   sync.Mutex
+	so   *clientSignalsOptions
+	conn *websocket.Conn
+}
+
+func (wc *wsClientConn) dial(ctx context.Context) error {
+	header := make(http.Header, len(wc.so.headers)+1)
+	for k, v := range wc.so.headers {
+		header.Set(k, v)
+	}
+	header.Set("User-Agent", wc.so.userAgent)
+	opts := &websocket.DialOptions{
+		HTTPClient: wc.so.httpClient,
+		HTTPHeader: header,
+	}
+	if *wc.so.gzip {
+		opts.CompressionMode = websocket.CompressionContextTakeover
+	}
+	conn, _, err := websocket.Dial(ctx, wc.so.endpoint.String(), opts)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %w", err)
+	}
+	conn.SetReadLimit(wsReadLimit)
+	wc.conn = conn
+	return nil
+}
+
+// roundTrip sends the request and waits for the response, the connection is dialed on demand and redialed after failures.
+func (wc *wsClientConn) roundTrip(ctx context.Context, signal byte, req, resp proto.Message) error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.conn == nil {
+		if err := wc.dial(ctx); err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+	}
+	bs, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	if err := wc.conn.Write(ctx, websocket.MessageBinary, append([]byte{signal}, bs...)); err != nil {
+		wc.reset()
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	_, data, err := wc.conn.Read(ctx)
+	if err != nil {
+		wc.reset()
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if len(data) == 0 {
+		return errors.New("empty websocket response")
+	}
+	switch data[0] {
+	case wsResultOK:
+		if err := proto.Unmarshal(data[1:], resp); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		return nil
+	case wsResultError:
+		var st spb.Status
+		if err := proto.Unmarshal(data[1:], &st); err != nil {
+			return fmt.Errorf("failed to unmarshal error response: %w", err)
+		}
+		return status.ErrorProto(&st)
+	}
+	return fmt.Errorf("unknown websocket result %d", data[0])
+}
+
+func (wc *wsClientConn) reset() {
+	wc.conn.CloseNow()
+	wc.conn = nil
+}
+
+func (wc *wsClientConn) close() error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.conn == nil {
+		return nil
+	}
+	err := wc.conn.Close(websocket.StatusNormalClosure, "")
+	wc.conn = nil
+	return err
+}
+
+func (c *Client) newWebSocketContext(parent context.Context, so *clientSignalsOptions) (context.Context, context.CancelFunc) {
+	if so.exportTimeout > 0 {
+		return context.WithTimeout(parent, so.exportTimeout)
+	}
+	return context.WithCancel(parent)
+}
+
+func (c *Client) uploadTracesWithWebSocket(ctx context.Context, protoSpans []*ResourceSpans) error {
+	ctx, cancel := c.newWebSocketContext(ctx, &c.o.traces)
+	defer cancel()
+	c.o.logger.InfoContext(ctx, "uploading traces with WebSocket", "endpoint", c.o.traces.endpoint.String(), "num_resource_spans", len(protoSpans))
+	var resp coltracepb.ExportTraceServiceResponse
+	if err := c.wsConns["traces"].roundTrip(ctx, wsSignalTraces, &coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans}, &resp); err != nil {
+		return err
+	}
+	return errorCheckForUploadTraces(&resp)
+}
+
+func (c *Client) uploadMetricsWithWebSocket(ctx context.Context, protoMetrics []*ResourceMetrics) error {
+	ctx, cancel := c.newWebSocketContext(ctx, &c.o.metrics)
+	defer cancel()
+	c.o.logger.InfoContext(ctx, "uploading metrics with WebSocket", "endpoint", c.o.metrics.endpoint.String(), "num_resource_metrics", len(protoMetrics))
+	var resp colmetricpb.ExportMetricsServiceResponse
+	if err := c.wsConns["metrics"].roundTrip(ctx, wsSignalMetrics, &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: protoMetrics}, &resp); err != nil {
+		return err
+	}
+	return errorCheckForUploadMetrics(&resp)
+}
+
+func (c *Client) uploadLogsWithWebSocket(ctx context.Context, protoLogs []*ResourceLogs) error {
+	ctx, cancel := c.newWebSocketContext(ctx, &c.o.logs)
+	defer cancel()
+	c.o.logger.InfoContext(ctx, "uploading logs with WebSocket", "endpoint", c.o.logs.endpoint.String(), "num_resource_logs", len(protoLogs))
+	var resp collogspb.ExportLogsServiceResponse
+	if err := c.wsConns["logs"].roundTrip(ctx, wsSignalLogs, &collogspb.ExportLogsServiceRequest{ResourceLogs: protoLogs}, &resp); err != nil {
+		return err
+	}
+	return errorCheckForUploadLogs(&resp)
+}
@@ -0,0 +1,75 @@
+package otlp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClient_WebSocket(t *testing.T) {
+	mux := otlp.NewServerMux()
+	var actualTraces *otlp.TraceRequest
+	mux.Trace().HandleFunc(func(ctx context.Context, request *otlp.TraceRequest) (*otlp.TraceResponse, error) {
+		actualTraces = request
+		headers, ok := otlp.HeadersFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "dummy", headers.Get("Api-Key"))
+		return &otlp.TraceResponse{}, nil
+	})
+	mux.Logs().HandleFunc(func(ctx context.Context, request *otlp.LogsRequest) (*otlp.LogsResponse, error) {
+		return nil, status.Error(codes.InvalidArgument, "invalid logs")
+	})
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/v1/ws", mux.WebSocketHandler())
+	server := httptest.NewServer(httpMux)
+	defer server.Close()
+
+	expected, err := os.ReadFile("testdata/trace.json")
+	require.NoError(t, err)
+	var req otlp.TraceRequest
+	require.NoError(t, otlp.UnmarshalJSON(expected, &req))
+
+	client, err := otlp.NewClient(
+		server.URL,
+		otlp.WithProtocol("ws"),
+		otlp.WithHeaders(map[string]string{"Api-Key": "dummy"}),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, client.Start(ctx))
+	require.NoError(t, client.UploadTraces(ctx, req.GetResourceSpans()))
+	assertEqualMessage(t, &req, actualTraces)
+	// the connection is reused for subsequent requests.
+	require.NoError(t, client.UploadTraces(ctx, req.GetResourceSpans()))
+
+	err = client.UploadLogs(ctx, []*otlp.ResourceLogs{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = client.UploadMetrics(ctx, []*otlp.ResourceMetrics{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	require.NoError(t, client.Stop(ctx))
+}
+
+func TestClient_WebSocket_Options(t *testing.T) {
+	require.NotContains(t, otlp.AllowedProtocols, "ws")
+
+	_, err := otlp.NewClient("http://localhost:4318", otlp.WithProtocol("ws"), otlp.WithCompressor("gzip", nil))
+	require.ErrorContains(t, err, "not supported over WebSocket")
+	_, err = otlp.NewClient("http://localhost:4318", otlp.WithProtocol("ws"), otlp.WithGzip(true))
+	require.ErrorContains(t, err, "not supported over WebSocket")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "ws")
+	_, err = otlp.NewClient("http://localhost:4318", otlp.DefaultClientOptions("OTEL_EXPORTER_"))
+	var envErr *otlp.EnvError
+	require.ErrorAs(t, err, &envErr)
+	require.Equal(t, "OTEL_EXPORTER_OTLP_PROTOCOL", envErr.Name)
+}
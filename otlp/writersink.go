@@ -0,0 +1,104 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type writerSinkOptions struct {
+	maxSize int64
+	rotate  func(current io.Writer) (io.Writer, error)
+}
+
+// WriterSinkOption is the option for NewJSONLinesSink and NewProtoSink.
+type WriterSinkOption func(*writerSinkOptions)
+
+// WithWriterSinkRotation calls rotate for the next writer when maxSize bytes or more are written to the current one,
+// e.g. to switch the files by size. the current writer is passed to be closed by rotate if needed.
+func WithWriterSinkRotation(maxSize int64, rotate func(current io.Writer) (io.Writer, error)) WriterSinkOption {
+	return func(o *writerSinkOptions) {
+		o.maxSize = maxSize
+		o.rotate = rotate
+	}
+}
+
+// WriterSink is a handler of all signals that writes the requests to an io.Writer, e.g. os.Stdout for debugging or piping.
+// the writes are serialized, so a request is never interleaved with another.
+type WriterSink struct {
+	format FileSinkFormat
+	o      writerSinkOptions
+
+	mu      sync.Mutex
+	w       io.Writer
+	written int64
+}
+
+var (
+	_ TraceHandler   = (*WriterSink)(nil)
+	_ MetricsHandler = (*WriterSink)(nil)
+	_ LogsHandler    = (*WriterSink)(nil)
+)
+
+// NewJSONLinesSink returns a new WriterSink writing a request per line in OTLP JSON, with trace and span ids in hex.
+func NewJSONLinesSink(w io.Writer, opts ...WriterSinkOption) *WriterSink {
+	return newWriterSink(w, FileSinkJSONLines, opts...)
+}
+
+// NewProtoSink returns a new WriterSink writing requests in protobuf, each prefixed by its size as a big endian uint32,
+// the same format as FileSinkProtobuf.
+func NewProtoSink(w io.Writer, opts ...WriterSinkOption) *WriterSink {
+	return newWriterSink(w, FileSinkProtobuf, opts...)
+}
+
+func newWriterSink(w io.Writer, format FileSinkFormat, opts ...WriterSinkOption) *WriterSink {
+	s := &WriterSink{
+		format: format,
+		w:      w,
+	}
+	for _, opt := range opts {
+		opt(&s.o)
+	}
+	return s
+}
+
+// HandleTrace writes the trace request.
+func (s *WriterSink) HandleTrace(_ context.Context, request *TraceRequest) (*TraceResponse, error) {
+	return &TraceResponse{}, s.write("traces", request)
+}
+
+// HandleMetrics writes the metrics request.
+func (s *WriterSink) HandleMetrics(_ context.Context, request *MetricsRequest) (*MetricsResponse, error) {
+	return &MetricsResponse{}, s.write("metrics", request)
+}
+
+// HandleLogs writes the logs request.
+func (s *WriterSink) HandleLogs(_ context.Context, request *LogsRequest) (*LogsResponse, error) {
+	return &LogsResponse{}, s.write("logs", request)
+}
+
+func (s *WriterSink) write(signalType string, msg proto.Message) error {
+	data, err := encodeSinkRecord(s.format, msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", signalType, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.o.rotate != nil && s.o.maxSize > 0 && s.written >= s.o.maxSize {
+		w, err := s.o.rotate(s.w)
+		if err != nil {
+			return fmt.Errorf("failed to rotate: %w", err)
+		}
+		if w == nil {
+			return errors.New("failed to rotate: no writer")
+		}
+		s.w, s.written = w, 0
+	}
+	n, err := s.w.Write(data)
+	s.written += int64(n)
+	return err
+}
@@ -0,0 +1,73 @@
+package otlp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mashiike/go-otlp-helper/otlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSinkSpans(t *testing.T, r io.Reader, signal string) []int {
+	t.Helper()
+	reader := otlp.NewFileExporterReader(r)
+	defer reader.Close()
+	if signal != "" {
+		require.NoError(t, reader.SetSignal(signal))
+	}
+	var spans []int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return spans
+		}
+		require.NoError(t, err)
+		spans = append(spans, otlp.TotalSpans(record.Traces.GetResourceSpans()))
+	}
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := otlp.NewJSONLinesSink(&buf)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sink.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(2)})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 10, strings.Count(buf.String(), "\n"), "a request per line")
+	require.Contains(t, buf.String(), `"traceId":"00000000000000000000000000000001"`, "ids in hex")
+	require.Equal(t, []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 2}, readSinkSpans(t, &buf, ""))
+}
+
+func TestProtoSink_Rotation(t *testing.T) {
+	var writers []*bytes.Buffer
+	first := &bytes.Buffer{}
+	writers = append(writers, first)
+	sink := otlp.NewProtoSink(first, otlp.WithWriterSinkRotation(1, func(current io.Writer) (io.Writer, error) {
+		require.Same(t, writers[len(writers)-1], current)
+		next := &bytes.Buffer{}
+		writers = append(writers, next)
+		return next, nil
+	}))
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		_, err := sink.HandleTrace(ctx, &otlp.TraceRequest{ResourceSpans: newSpans(i)})
+		require.NoError(t, err)
+	}
+	require.Len(t, writers, 3)
+	for i, w := range writers {
+		require.Equal(t, []int{i + 1}, readSinkSpans(t, w, "traces"))
+	}
+}